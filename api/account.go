@@ -1,16 +1,16 @@
 package api
 
 import (
-	"database/sql"
-	"errors"
-	"fmt"
+	"context"
 	"net/http"
+	"simple_bank/audit"
 	db "simple_bank/db/sqlc"
+	"simple_bank/policy"
 	"simple_bank/token"
-	"strings"
+	"simple_bank/util"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 )
 
 type createAccountRequest struct {
@@ -21,7 +21,7 @@ type createAccountRequest struct {
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
@@ -33,52 +33,36 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		Currency: req.Currency,
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	account, err := policy.CreateAccount(ctx, server.store, arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			fmt.Println("pq error:", pqErr.Code.Name())
-			switch pqErr.Code.Name() {
-			case "unique_violation", "foreign_key_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
-		if strings.Contains(err.Error(), "23505") || strings.Contains(err.Error(), "23503") {
-			ctx.JSON(http.StatusForbidden, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
+	server.recordAudit(ctx, authPayload.Username, audit.ActionAccountCreated, audit.ResourceAccount, account.AccountNumber, nil, account)
+
 	ctx.JSON(http.StatusOK, account)
 }
 
 type getAccountRequest struct {
-	ID int64 `uri:"id" binding:"required,min=1"`
+	ID string `uri:"id" binding:"required,account_ref"`
 }
 
 func (server *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
-	account, err := server.store.GetAccount(ctx, req.ID)
+	accountID, accountNumber := parseAccountRef(req.ID)
+	account, err := server.lookupAccount(ctx, accountID, accountNumber)
 	if err != nil {
-		if err.Error() == sql.ErrNoRows.Error() {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account does not belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	if _, valid := server.authorizeAccount(ctx, account.ID); !valid {
 		return
 	}
 
@@ -88,28 +72,28 @@ func (server *Server) getAccount(ctx *gin.Context) {
 
 type listAccountRequest struct {
 	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5",max=10"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
 }
 
 func (server *Server) listAccount(ctx *gin.Context) {
 	var req listAccountRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, http.StatusBadRequest, err)
 		return
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
 	arg := db.ListAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+		Username: authPayload.Username,
+		Limit:    req.PageSize,
+		Offset:   (req.PageID - 1) * req.PageSize,
 	}
 
-	account, err := server.store.ListAccounts(ctx, arg)
+	account, err := server.store.ListAccountsFromReplica(ctx, arg)
 	if err != nil {
 
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -118,57 +102,123 @@ func (server *Server) listAccount(ctx *gin.Context) {
 }
 
 type deleteAccountRequest struct {
-	ID int64 `uri:"id" binding:"required,min=1"`
+	ID string `uri:"id" binding:"required,account_ref"`
 }
 
 func (server *Server) deleteAccount(ctx *gin.Context) {
 	var req deleteAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	accountID, accountNumber := parseAccountRef(req.ID)
+	account, err := server.lookupAccount(ctx, accountID, accountNumber)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	err := server.store.DeleteAccount(ctx, req.ID)
+	if !server.authorizeAccountManagement(ctx, account.ID) {
+		return
+	}
+
+	err = server.store.DeleteAccount(ctx, account.ID)
 	if err != nil {
-		if "sql: "+err.Error() == sql.ErrNoRows.Error() {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	server.recordAudit(ctx, authPayload.Username, audit.ActionAccountClosed, audit.ResourceAccount, account.AccountNumber, account, nil)
+
 	ctx.JSON(http.StatusOK, err)
 
 }
 
 type updateAccountRequest struct {
-	ID      int64 `json:"id" binding:"required,min=1"`
-	Balance int64 `json:"balance" binding:"required,min=0"`
+	ID            int64  `json:"id" binding:"required_without=AccountNumber,omitempty,min=1"`
+	AccountNumber string `json:"account_number" binding:"required_without=ID,omitempty,account_number"`
+	Balance       int64  `json:"balance" binding:"required,min=0"`
 }
 
 func (server *Server) updateAccount(ctx *gin.Context) {
 	var req updateAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	before, err := server.lookupAccount(ctx, req.ID, req.AccountNumber)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !server.authorizeAccountManagement(ctx, before.ID) {
 		return
 	}
 
 	arg := db.UpdateAccountParams{
-		ID:      req.ID,
+		ID:      before.ID,
 		Balance: req.Balance,
 	}
 
 	account, err := server.store.UpdateAccount(ctx, arg)
 	if err != nil {
-		if "sql: "+err.Error() == sql.ErrNoRows.Error() {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	server.recordAudit(ctx, authPayload.Username, audit.ActionBalanceAdjusted, audit.ResourceAccount, account.AccountNumber, before, account)
+
 	ctx.JSON(http.StatusOK, account)
 
 }
+
+// authorizeAccountManagement checks that the authenticated user may update or
+// close the account: bankers may manage any account, other users only the
+// accounts they are the primary holder of.
+func (server *Server) authorizeAccountManagement(ctx *gin.Context, accountID int64) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if server.isBanker(authPayload.Username) {
+		return true
+	}
+
+	_, valid := server.authorizeAccount(ctx, accountID, db.AccountHolderRolePrimary)
+	return valid
+}
+
+// parseAccountRef splits a path parameter that holds either an internal
+// account ID or an account number.
+func parseAccountRef(ref string) (accountID int64, accountNumber string) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		return id, ""
+	}
+	return 0, ref
+}
+
+// lookupAccount loads an account by account number when one is given, and
+// by internal ID otherwise.
+func (server *Server) lookupAccount(ctx context.Context, accountID int64, accountNumber string) (db.Account, error) {
+	if accountNumber != "" {
+		return server.store.GetAccountByNumber(ctx, util.NormalizeAccountNumber(accountNumber))
+	}
+	return server.store.GetAccount(ctx, accountID)
+}
+
+// accountNumbers maps the given internal account IDs to their account
+// numbers, which responses carry in place of the IDs.
+func (server *Server) accountNumbers(ctx context.Context, accountIDs ...int64) (map[int64]string, error) {
+	rows, err := server.store.ListAccountNumbers(ctx, accountIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers := make(map[int64]string, len(rows))
+	for _, row := range rows {
+		numbers[row.ID] = row.AccountNumber
+	}
+	return numbers, nil
+}
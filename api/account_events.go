@@ -0,0 +1,128 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	db "simple_bank/db/sqlc"
+	"simple_bank/logger"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const accountEventsBatchSize = 100
+
+type streamAccountEventsRequest struct {
+	ID string `uri:"id" binding:"required,account_ref"`
+}
+
+type streamAccountEventsQuery struct {
+	LastEventID int64 `form:"last_event_id" binding:"min=0"`
+}
+
+// streamAccountEvents streams the balance changes of an account as
+// Server-Sent Events. Each event carries its outbox id, so a client that
+// reconnects with a Last-Event-ID header (or last_event_id query parameter)
+// receives every event it missed before the live ones.
+func (server *Server) streamAccountEvents(ctx *gin.Context) {
+	var req streamAccountEventsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	var query streamAccountEventsQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	lastEventID, resume := query.LastEventID, query.LastEventID > 0
+	if header := ctx.GetHeader("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			respondError(ctx, http.StatusBadRequest, fmt.Errorf("invalid Last-Event-ID %q", header))
+			return
+		}
+		lastEventID, resume = id, true
+	}
+
+	account, valid := server.accountByRef(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	if _, valid := server.authorizeAccount(ctx, account.ID); !valid {
+		return
+	}
+
+	// subscribe before reading the outbox so that nothing committed in
+	// between is missed
+	wake, unsubscribe := server.events.subscribe(account.ID)
+	defer unsubscribe()
+
+	if !resume {
+		var err error
+		lastEventID, err = server.store.GetLastOutboxEventID(ctx)
+		if err != nil {
+			respondError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(server.config.EventStreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		lastEventID, err = server.sendAccountEvents(ctx, account.ID, lastEventID)
+		if err != nil {
+			logger.FromContext(ctx).ErrorContext(ctx, "cannot stream account events", "account_id", account.ID, "error", err)
+			return
+		}
+
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-server.streams.Done():
+			return
+		case <-wake:
+		case <-heartbeat.C:
+			fmt.Fprint(ctx.Writer, ": heartbeat\n\n")
+			ctx.Writer.Flush()
+		}
+	}
+}
+
+// sendAccountEvents writes every event of the account after afterID to the
+// stream and returns the id of the last one written.
+func (server *Server) sendAccountEvents(ctx *gin.Context, accountID int64, afterID int64) (int64, error) {
+	for {
+		events, err := server.store.ListAccountEvents(ctx, db.ListAccountEventsParams{
+			AccountID: accountID,
+			AfterID:   afterID,
+			BatchSize: accountEventsBatchSize,
+		})
+		if err != nil {
+			return afterID, err
+		}
+
+		for _, event := range events {
+			fmt.Fprintf(ctx.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.EventType, event.Payload)
+			afterID = event.ID
+		}
+		ctx.Writer.Flush()
+
+		if len(events) < accountEventsBatchSize {
+			return afterID, nil
+		}
+	}
+}
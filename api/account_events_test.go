@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamAccountEventsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	event := func(id int64) db.OutboxEvent {
+		return db.OutboxEvent{
+			ID:          id,
+			EventType:   db.EventBalanceChanged,
+			AggregateID: account.ID,
+			Payload:     []byte(fmt.Sprintf(`{"entry":{"id":%d}}`, id)),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		lastEventID   string
+		buildStubs    func(store *mockdb.MockStore)
+		afterStart    func(t *testing.T, server *Server)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Resume",
+			username:    user.Username,
+			lastEventID: "5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLastOutboxEventID(gomock.Any()).Times(0)
+				store.EXPECT().
+					ListAccountEvents(gomock.Any(), gomock.Eq(db.ListAccountEventsParams{
+						AccountID: account.ID,
+						AfterID:   5,
+						BatchSize: accountEventsBatchSize,
+					})).
+					Times(1).
+					Return([]db.OutboxEvent{event(6), event(9)}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/event-stream", recorder.Header().Get("Content-Type"))
+
+				body := recorder.Body.String()
+				require.Contains(t, body, "id: 6\nevent: account.balance_changed\ndata: {\"entry\":{\"id\":6}}\n\n")
+				require.Contains(t, body, "id: 9\n")
+			},
+		},
+		{
+			name:     "Live",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLastOutboxEventID(gomock.Any()).Times(1).Return(int64(10), nil)
+				gomock.InOrder(
+					store.EXPECT().
+						ListAccountEvents(gomock.Any(), gomock.Eq(db.ListAccountEventsParams{
+							AccountID: account.ID,
+							AfterID:   10,
+							BatchSize: accountEventsBatchSize,
+						})).
+						Times(1).
+						Return([]db.OutboxEvent{}, nil),
+					store.EXPECT().
+						ListAccountEvents(gomock.Any(), gomock.Eq(db.ListAccountEventsParams{
+							AccountID: account.ID,
+							AfterID:   10,
+							BatchSize: accountEventsBatchSize,
+						})).
+						Times(1).
+						Return([]db.OutboxEvent{event(11)}, nil),
+				)
+			},
+			afterStart: func(t *testing.T, server *Server) {
+				require.Eventually(t, func() bool {
+					return server.events.subscriberCount(account.ID) == 1
+				}, time.Second, time.Millisecond)
+				server.events.notify(db.AccountEventNotification{EventID: 11, AccountID: account.ID})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "id: 11\n")
+			},
+		},
+		{
+			name:        "InvalidLastEventID",
+			username:    user.Username,
+			lastEventID: "abc",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "NotHolder",
+			username: other.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).AnyTimes().Return(account, nil)
+			stubAccountOwners(store, account)
+
+			server := NewTestServer(t, store)
+			server.config.EventStreamHeartbeatInterval = time.Minute
+			recorder := httptest.NewRecorder()
+
+			reqCtx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			url := fmt.Sprintf("/accounts/%d/events", account.ID)
+			request, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+			require.NoError(t, err)
+			if tc.lastEventID != "" {
+				request.Header.Set("Last-Event-ID", tc.lastEventID)
+			}
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				server.router.ServeHTTP(recorder, request)
+			}()
+
+			if tc.afterStart != nil {
+				tc.afterStart(t, server)
+			}
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+			<-done
+
+			require.Zero(t, server.events.subscriberCount(account.ID))
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"simple_bank/audit"
+	db "simple_bank/db/sqlc"
+	"simple_bank/policy"
+	"simple_bank/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+type accountHolderURI struct {
+	ID string `uri:"id" binding:"required,account_ref"`
+}
+
+// accountHolderResponse is a holding with the account number in place of the
+// internal account ID.
+type accountHolderResponse struct {
+	db.AccountHolder
+	AccountNumber string `json:"account_number"`
+}
+
+type addAccountHolderRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Role     string `json:"role" binding:"required,oneof=joint view_only"`
+}
+
+// addAccountHolder lets the primary holder invite another user to share an
+// account. The user has no access to the account until it accepts.
+func (server *Server) addAccountHolder(ctx *gin.Context) {
+	var uri accountHolderURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	var req addAccountHolderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	account, valid := server.accountByRef(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	caller, valid := server.authorizeAccount(ctx, account.ID, db.AccountHolderRolePrimary)
+	if !valid {
+		return
+	}
+
+	arg := db.CreateAccountHolderParams{
+		AccountID: account.ID,
+		Username:  req.Username,
+		Role:      req.Role,
+		Status:    db.AccountHolderStatusPending,
+	}
+
+	holder, err := server.store.CreateAccountHolder(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := accountHolderResponse{AccountHolder: holder, AccountNumber: account.AccountNumber}
+	server.recordAudit(ctx, caller.Username, audit.ActionAccountHolderInvited, audit.ResourceAccountHolder,
+		accountHolderResourceID(account, holder.Username), nil, rsp)
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) listAccountHolders(ctx *gin.Context) {
+	var uri accountHolderURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	account, valid := server.accountByRef(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	if _, valid := server.authorizeAccount(ctx, account.ID); !valid {
+		return
+	}
+
+	holders, err := server.store.ListAccountHolders(ctx, account.ID)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := make([]accountHolderResponse, len(holders))
+	for i, holder := range holders {
+		rsp[i] = accountHolderResponse{AccountHolder: holder, AccountNumber: account.AccountNumber}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// listAccountInvitations lists the accounts the caller was invited to and has
+// not accepted yet.
+func (server *Server) listAccountInvitations(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	invitations, err := server.store.ListAccountInvitations(ctx, authPayload.Username)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	accountIDs := make([]int64, len(invitations))
+	for i, invitation := range invitations {
+		accountIDs[i] = invitation.AccountID
+	}
+	accountNumbers, err := server.accountNumbers(ctx, accountIDs...)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := make([]accountHolderResponse, len(invitations))
+	for i, invitation := range invitations {
+		rsp[i] = accountHolderResponse{AccountHolder: invitation, AccountNumber: accountNumbers[invitation.AccountID]}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type accountInvitationURI struct {
+	ID string `uri:"id" binding:"required,account_ref"`
+}
+
+// acceptAccountInvitation makes the caller a holder of an account it was
+// invited to.
+func (server *Server) acceptAccountInvitation(ctx *gin.Context) {
+	var uri accountInvitationURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	account, valid := server.accountByRef(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.AcceptAccountHolderParams{
+		AccountID: account.ID,
+		Username:  authPayload.Username,
+	}
+
+	holder, err := server.store.AcceptAccountHolder(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := accountHolderResponse{AccountHolder: holder, AccountNumber: account.AccountNumber}
+	server.recordAudit(ctx, authPayload.Username, audit.ActionAccountHolderAccepted, audit.ResourceAccountHolder,
+		accountHolderResourceID(account, holder.Username), nil, rsp)
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// declineAccountInvitation drops an invitation of the caller to an account.
+func (server *Server) declineAccountInvitation(ctx *gin.Context) {
+	var uri accountInvitationURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	account, valid := server.accountByRef(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	holder, err := server.store.GetAccountHolder(ctx, db.GetAccountHolderParams{
+		AccountID: account.ID,
+		Username:  authPayload.Username,
+	})
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if holder.Status != db.AccountHolderStatusPending {
+		respondError(ctx, http.StatusConflict, db.ErrAccountInvitationNotPending)
+		return
+	}
+
+	err = server.store.DeleteAccountHolder(ctx, db.DeleteAccountHolderParams{
+		AccountID: account.ID,
+		Username:  authPayload.Username,
+	})
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.recordAudit(ctx, authPayload.Username, audit.ActionAccountHolderRemoved, audit.ResourceAccountHolder,
+		accountHolderResourceID(account, holder.Username),
+		accountHolderResponse{AccountHolder: holder, AccountNumber: account.AccountNumber}, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+type removeAccountHolderURI struct {
+	ID       string `uri:"id" binding:"required,account_ref"`
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// removeAccountHolder lets the primary holder remove a co-holder, and lets a
+// co-holder leave the account. The primary holder itself cannot be removed.
+func (server *Server) removeAccountHolder(ctx *gin.Context) {
+	var uri removeAccountHolderURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	account, valid := server.accountByRef(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	caller, valid := server.authorizeAccount(ctx, account.ID)
+	if !valid {
+		return
+	}
+
+	if caller.Username != uri.Username && caller.Role != db.AccountHolderRolePrimary {
+		err := errors.New("only the primary holder can remove other holders")
+		respondError(ctx, http.StatusForbidden, err)
+		return
+	}
+
+	arg := db.GetAccountHolderParams{
+		AccountID: account.ID,
+		Username:  uri.Username,
+	}
+
+	holder, err := server.store.GetAccountHolder(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if holder.Role == db.AccountHolderRolePrimary {
+		err := errors.New("the primary holder cannot be removed")
+		respondError(ctx, http.StatusForbidden, err)
+		return
+	}
+
+	err = server.store.DeleteAccountHolder(ctx, db.DeleteAccountHolderParams{
+		AccountID: account.ID,
+		Username:  uri.Username,
+	})
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.recordAudit(ctx, caller.Username, audit.ActionAccountHolderRemoved, audit.ResourceAccountHolder,
+		accountHolderResourceID(account, holder.Username),
+		accountHolderResponse{AccountHolder: holder, AccountNumber: account.AccountNumber}, nil)
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+// accountHolderResourceID identifies the holding of account by username in
+// the audit log.
+func accountHolderResourceID(account db.Account, username string) string {
+	return account.AccountNumber + "/" + username
+}
+
+// accountByRef loads an account referenced by internal ID or account number.
+func (server *Server) accountByRef(ctx *gin.Context, ref string) (db.Account, bool) {
+	accountID, accountNumber := parseAccountRef(ref)
+	account, err := server.lookupAccount(ctx, accountID, accountNumber)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return account, false
+	}
+
+	return account, true
+}
+
+// authorizeAccount enforces policy.AuthorizeAccount for the authenticated
+// user.
+func (server *Server) authorizeAccount(ctx *gin.Context, accountID int64, roles ...string) (db.AccountHolder, bool) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	holder, err := policy.AuthorizeAccount(ctx, server.store, accountID, authPayload.Username, roles...)
+	if err != nil {
+		switch {
+		case errors.Is(err, policy.ErrNotAccountHolder):
+			respondError(ctx, http.StatusUnauthorized, err)
+		case errors.Is(err, policy.ErrRoleNotAllowed):
+			respondError(ctx, http.StatusForbidden, err)
+		default:
+			respondError(ctx, http.StatusInternalServerError, err)
+		}
+		return holder, false
+	}
+
+	return holder, true
+}
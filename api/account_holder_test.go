@@ -0,0 +1,444 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"simple_bank/audit"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAccountHolderAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	coHolder, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			body:     gin.H{"username": coHolder.Username, "role": db.AccountHolderRoleJoint},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.CreateAccountHolderParams{
+					AccountID: account.ID,
+					Username:  coHolder.Username,
+					Role:      db.AccountHolderRoleJoint,
+					Status:    db.AccountHolderStatusPending,
+				}
+				store.EXPECT().
+					CreateAccountHolder(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.AccountHolder{AccountID: account.ID, Username: coHolder.Username, Role: arg.Role, Status: arg.Status}, nil)
+				expectAudit(store, user.Username, audit.ActionAccountHolderInvited)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var holder db.AccountHolder
+				err := json.Unmarshal(recorder.Body.Bytes(), &holder)
+				require.NoError(t, err)
+				require.Equal(t, coHolder.Username, holder.Username)
+				require.Equal(t, db.AccountHolderRoleJoint, holder.Role)
+				require.Equal(t, db.AccountHolderStatusPending, holder.Status)
+			},
+		},
+		{
+			name:     "PrimaryRoleNotAllowed",
+			body:     gin.H{"username": coHolder.Username, "role": db.AccountHolderRolePrimary},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccountHolder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "JointHolderCannotInvite",
+			body:     gin.H{"username": "someoneelse", "role": db.AccountHolderRoleViewOnly},
+			username: coHolder.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				stubAccountHolder(store, account, coHolder.Username, db.AccountHolderRoleJoint)
+				store.EXPECT().CreateAccountHolder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "PendingHolderCannotInvite",
+			body:     gin.H{"username": "someoneelse", "role": db.AccountHolderRoleViewOnly},
+			username: coHolder.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				stubPendingAccountHolder(store, account, coHolder.Username, db.AccountHolderRolePrimary)
+				store.EXPECT().CreateAccountHolder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "AlreadyHolder",
+			body:     gin.H{"username": coHolder.Username, "role": db.AccountHolderRoleJoint},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreateAccountHolder(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountHolder{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAccountOwners(store, account)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/holders", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRemoveAccountHolderAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	coHolder, _ := randomUser(t)
+	viewer, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		target        string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "PrimaryRemovesJoint",
+			target:   coHolder.Username,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				stubAccountHolder(store, account, coHolder.Username, db.AccountHolderRoleJoint)
+
+				arg := db.DeleteAccountHolderParams{AccountID: account.ID, Username: coHolder.Username}
+				store.EXPECT().DeleteAccountHolder(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+				expectAudit(store, user.Username, audit.ActionAccountHolderRemoved)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "JointLeaves",
+			target:   coHolder.Username,
+			username: coHolder.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				stubAccountHolder(store, account, coHolder.Username, db.AccountHolderRoleJoint)
+
+				arg := db.DeleteAccountHolderParams{AccountID: account.ID, Username: coHolder.Username}
+				store.EXPECT().DeleteAccountHolder(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+				expectAudit(store, coHolder.Username, audit.ActionAccountHolderRemoved)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "JointRemovesViewer",
+			target:   viewer.Username,
+			username: coHolder.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				stubAccountHolder(store, account, coHolder.Username, db.AccountHolderRoleJoint)
+				store.EXPECT().DeleteAccountHolder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "PrimaryCannotBeRemoved",
+			target:   user.Username,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DeleteAccountHolder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotAHolder",
+			target:   coHolder.Username,
+			username: viewer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().DeleteAccountHolder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAccountOwners(store, account)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/holders/%s", account.ID, tc.target)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestAccountInvitationsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	invitee, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	invitation := db.AccountHolder{
+		AccountID: account.ID,
+		Username:  invitee.Username,
+		Role:      db.AccountHolderRoleJoint,
+		Status:    db.AccountHolderStatusPending,
+	}
+	getArg := db.GetAccountHolderParams{AccountID: account.ID, Username: invitee.Username}
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "List",
+			method: http.MethodGet,
+			url:    "/account-invitations",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountInvitations(gomock.Any(), gomock.Eq(invitee.Username)).
+					Times(1).
+					Return([]db.AccountHolder{invitation}, nil)
+				expectAccountNumbers(store, account)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var invitations []accountHolderResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &invitations)
+				require.NoError(t, err)
+				require.Len(t, invitations, 1)
+				require.Equal(t, account.AccountNumber, invitations[0].AccountNumber)
+				require.Equal(t, db.AccountHolderStatusPending, invitations[0].Status)
+			},
+		},
+		{
+			name:   "Accept",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/account-invitations/%d/accept", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				accepted := invitation
+				accepted.Status = db.AccountHolderStatusAccepted
+				arg := db.AcceptAccountHolderParams{AccountID: account.ID, Username: invitee.Username}
+				store.EXPECT().AcceptAccountHolder(gomock.Any(), gomock.Eq(arg)).Times(1).Return(accepted, nil)
+				expectAudit(store, invitee.Username, audit.ActionAccountHolderAccepted)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var holder accountHolderResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &holder)
+				require.NoError(t, err)
+				require.Equal(t, db.AccountHolderStatusAccepted, holder.Status)
+			},
+		},
+		{
+			name:   "AcceptNotInvited",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/account-invitations/%d/accept", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					AcceptAccountHolder(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountHolder{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "Decline",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/account-invitations/%d/decline", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountHolder(gomock.Any(), gomock.Eq(getArg)).Times(1).Return(invitation, nil)
+
+				arg := db.DeleteAccountHolderParams{AccountID: account.ID, Username: invitee.Username}
+				store.EXPECT().DeleteAccountHolder(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+				expectAudit(store, invitee.Username, audit.ActionAccountHolderRemoved)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "DeclineAlreadyAccepted",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("/account-invitations/%d/decline", account.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				stubAccountHolder(store, account, invitee.Username, db.AccountHolderRoleJoint)
+				store.EXPECT().DeleteAccountHolder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(tc.method, tc.url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, invitee.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+// stubAccountHolder makes username a holder of account with the given role.
+func stubAccountHolder(store *mockdb.MockStore, account db.Account, username string, role string) {
+	arg := db.GetAccountHolderParams{
+		AccountID: account.ID,
+		Username:  username,
+	}
+
+	store.EXPECT().
+		GetAccountHolder(gomock.Any(), gomock.Eq(arg)).
+		AnyTimes().
+		Return(db.AccountHolder{
+			AccountID: account.ID,
+			Username:  username,
+			Role:      role,
+			Status:    db.AccountHolderStatusAccepted,
+		}, nil)
+}
+
+// stubPendingAccountHolder makes username an invited holder of account that has
+// not accepted yet.
+func stubPendingAccountHolder(store *mockdb.MockStore, account db.Account, username string, role string) {
+	arg := db.GetAccountHolderParams{
+		AccountID: account.ID,
+		Username:  username,
+	}
+
+	store.EXPECT().
+		GetAccountHolder(gomock.Any(), gomock.Eq(arg)).
+		AnyTimes().
+		Return(db.AccountHolder{
+			AccountID: account.ID,
+			Username:  username,
+			Role:      role,
+			Status:    db.AccountHolderStatusPending,
+		}, nil)
+}
+
+// stubAccountOwners answers every other holder lookup as if each account were
+// held only by its owner, as the primary holder.
+func stubAccountOwners(store *mockdb.MockStore, accounts ...db.Account) {
+	store.EXPECT().
+		GetAccountHolder(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, arg db.GetAccountHolderParams) (db.AccountHolder, error) {
+			for _, account := range accounts {
+				if account.ID == arg.AccountID && account.Owner == arg.Username {
+					return db.AccountHolder{
+						AccountID: account.ID,
+						Username:  account.Owner,
+						Role:      db.AccountHolderRolePrimary,
+						Status:    db.AccountHolderStatusAccepted,
+					}, nil
+				}
+			}
+			return db.AccountHolder{}, db.ErrRecordNotFound
+		})
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"simple_bank/audit"
+	db "simple_bank/db/sqlc"
+	"simple_bank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordAudit records in the audit log that actor performed action on a
+// resource, along with the client the request came from. before and after
+// are the resource before and after the action, nil if it did not exist.
+func (server *Server) recordAudit(ctx *gin.Context, actor string, action string, resourceType string, resourceID string, before any, after any) {
+	audit.Record(ctx, server.store, audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		ClientIP:     ctx.ClientIP(),
+		UserAgent:    ctx.Request.UserAgent(),
+		RequestID:    ctx.Writer.Header().Get(requestIDHeaderKey),
+		Before:       before,
+		After:        after,
+	})
+}
+
+type listAuditLogsRequest struct {
+	Actor        string    `form:"actor" binding:"omitempty,alphanum"`
+	ResourceType string    `form:"resource_type" binding:"omitempty,oneof=user account account_holder transfer transfer_approval payment_request"`
+	ResourceID   string    `form:"resource_id" binding:"omitempty,max=64"`
+	From         time.Time `form:"from"`
+	To           time.Time `form:"to" binding:"omitempty,gtfield=From"`
+	PageID       int32     `form:"page_id" binding:"required,min=1"`
+	PageSize     int32     `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listAuditLogs lists the audit log entries that match the filters, the most
+// recent first. from is inclusive and to exclusive; without them the whole
+// log is searched.
+func (server *Server) listAuditLogs(ctx *gin.Context) {
+	var req listAuditLogsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.isBanker(authPayload.Username) {
+		err := errors.New("only bankers can view the audit log")
+		respondError(ctx, http.StatusForbidden, err)
+		return
+	}
+
+	until := req.To
+	if until.IsZero() {
+		// created_at is taken from the clock of the database, which may be
+		// slightly ahead of ours
+		until = time.Now().Add(time.Minute)
+	}
+
+	arg := db.ListAuditLogsParams{
+		Actor:        req.Actor,
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceID,
+		Since:        req.From,
+		Until:        until,
+		Limit:        req.PageSize,
+		Offset:       (req.PageID - 1) * req.PageSize,
+	}
+
+	logs, err := server.store.ListAuditLogsFromReplica(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, logs)
+}
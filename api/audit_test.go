@@ -0,0 +1,236 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"simple_bank/audit"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type auditActionMatcher struct {
+	actor  string
+	action string
+}
+
+func (e auditActionMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateAuditLogParams)
+	if !ok {
+		return false
+	}
+
+	return arg.Actor == e.actor && arg.Action == e.action
+}
+
+func (e auditActionMatcher) String() string {
+	return fmt.Sprintf("records %s by %s", e.action, e.actor)
+}
+
+// expectAudit expects the action of actor to be recorded once in the audit
+// log.
+func expectAudit(store *mockdb.MockStore, actor string, action string) {
+	store.EXPECT().
+		CreateAuditLog(gomock.Any(), auditActionMatcher{actor, action}).
+		Times(1).
+		Return(db.AuditLog{}, nil)
+}
+
+func TestRecordAudit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateAccountTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(account, nil)
+	store.EXPECT().
+		CreateAuditLog(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+			require.Equal(t, user.Username, arg.Actor)
+			require.Equal(t, audit.ActionAccountCreated, arg.Action)
+			require.Equal(t, audit.ResourceAccount, arg.ResourceType)
+			require.Equal(t, account.AccountNumber, arg.ResourceID)
+			require.Equal(t, "192.0.2.1", arg.ClientIp)
+			require.Equal(t, "simple-bank-test/1.0", arg.UserAgent)
+			require.Equal(t, "audited-request", arg.RequestID)
+			require.Nil(t, arg.Before)
+
+			var after db.Account
+			require.NoError(t, json.Unmarshal(arg.After, &after))
+			require.Equal(t, account.AccountNumber, after.AccountNumber)
+			require.Equal(t, account.Balance, after.Balance)
+			return db.AuditLog{}, nil
+		})
+
+	server := NewTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	body := fmt.Sprintf(`{"owner": %q, "currency": %q}`, user.Username, account.Currency)
+	request, err := http.NewRequest(http.MethodPost, "/accounts", strings.NewReader(body))
+	require.NoError(t, err)
+	request.RemoteAddr = "192.0.2.1:4242"
+	request.Header.Set("User-Agent", "simple-bank-test/1.0")
+	request.Header.Set(requestIDHeaderKey, "audited-request")
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestListAuditLogsAPI(t *testing.T) {
+	banker, _ := randomUser(t)
+	user, _ := randomUser(t)
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	logs := []db.AuditLog{{
+		ID:           1,
+		Actor:        user.Username,
+		Action:       audit.ActionBalanceAdjusted,
+		ResourceType: audit.ResourceAccount,
+		ResourceID:   "SB00000000000000000001",
+		Before:       json.RawMessage(`{"balance":10}`),
+		After:        json.RawMessage(`{"balance":20}`),
+		CreatedAt:    from.Add(time.Hour),
+	}}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: url.Values{
+				"actor":         {user.Username},
+				"resource_type": {audit.ResourceAccount},
+				"resource_id":   {"SB00000000000000000001"},
+				"from":          {from.Format(time.RFC3339)},
+				"to":            {to.Format(time.RFC3339)},
+				"page_id":       {"2"},
+				"page_size":     {"5"},
+			},
+			username: banker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAuditLogsParams{
+					Actor:        user.Username,
+					ResourceType: audit.ResourceAccount,
+					ResourceID:   "SB00000000000000000001",
+					Since:        from,
+					Until:        to,
+					Limit:        5,
+					Offset:       5,
+				}
+				store.EXPECT().
+					ListAuditLogsFromReplica(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(logs, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var body []map[string]any
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Len(t, body, 1)
+				// the values are returned as JSON rather than encoded bytes
+				require.Equal(t, map[string]any{"balance": float64(10)}, body[0]["before"])
+				require.Equal(t, map[string]any{"balance": float64(20)}, body[0]["after"])
+			},
+		},
+		{
+			name:     "NoFilters",
+			query:    url.Values{"page_id": {"1"}, "page_size": {"10"}},
+			username: banker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditLogsFromReplica(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.ListAuditLogsParams) ([]db.AuditLog, error) {
+						require.True(t, arg.Since.IsZero())
+						require.True(t, arg.Until.After(time.Now()))
+						return []db.AuditLog{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotBanker",
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditLogsFromReplica(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "ToBeforeFrom",
+			query: url.Values{
+				"from":      {to.Format(time.RFC3339)},
+				"to":        {from.Format(time.RFC3339)},
+				"page_id":   {"1"},
+				"page_size": {"5"},
+			},
+			username: banker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditLogsFromReplica(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidResourceType",
+			query:    url.Values{"resource_type": {"users"}, "page_id": {"1"}, "page_size": {"5"}},
+			username: banker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditLogsFromReplica(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			server.config.BankerUsernames = []string{banker.Username}
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/audit-logs?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -28,7 +28,7 @@ func BenchmarkCreateAccount(b *testing.B) {
 
 	store := mockdb.NewMockStore(ctrl)
 	store.EXPECT().
-		CreateAccount(gomock.Any(), gomock.Any()).
+		CreateAccountTx(gomock.Any(), gomock.Any()).
 		Times(b.N).
 		Return(account, nil)
 
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	db "simple_bank/db/sqlc"
+
+	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// errorResponse is the body of every error response.
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+type apiError struct {
+	// Code is a machine-readable identifier of the error, e.g. not_found.
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []fieldError `json:"fields,omitempty"`
+	// RequestID identifies the request in the server logs.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// fieldError describes a request field that failed validation.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// statusErrorCodes are the codes of errors that are not of a known kind, by
+// the status the handler chose for them.
+var statusErrorCodes = map[int]string{
+	http.StatusBadRequest:          "invalid_request",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not_found",
+	http.StatusConflict:            "conflict",
+	http.StatusServiceUnavailable:  "unavailable",
+	http.StatusGatewayTimeout:      "timeout",
+	http.StatusInternalServerError: "internal",
+}
+
+// mapError returns the status, code and message of the response to err.
+// Errors of a known kind map to the same response whichever handler returns
+// them; any other error gets the status chosen by the handler.
+func mapError(err error, status int) (int, string, string) {
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, db.ErrRecordNotFound):
+		return http.StatusNotFound, "not_found", "record not found"
+	case errors.Is(err, db.ErrUniqueViolation):
+		return http.StatusForbidden, "already_exists", db.ErrUniqueViolation.Error()
+	case errors.Is(err, db.ErrForeignKeyViolation):
+		return http.StatusForbidden, "invalid_reference", db.ErrForeignKeyViolation.Error()
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return http.StatusUnprocessableEntity, "insufficient_funds", err.Error()
+	case errors.Is(err, db.ErrSerializationFailure):
+		return http.StatusConflict, "concurrent_update", "the request conflicted with a concurrent request, please retry"
+	case errors.Is(err, db.ErrPaymentRequestNotPending),
+		errors.Is(err, db.ErrPaymentRequestExpired),
+		errors.Is(err, db.ErrPaymentRequestAwaitingApproval),
+		errors.Is(err, db.ErrTransferApprovalNotPending),
+		errors.Is(err, db.ErrTransferApprovalExpired),
+		errors.Is(err, db.ErrAccountInvitationNotPending):
+		return http.StatusConflict, "invalid_state", err.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "timeout", "the request timed out"
+	case errors.Is(err, db.ErrQueryTimeout):
+		return http.StatusServiceUnavailable, "unavailable", "the database is too busy to serve the request, please retry"
+	case errors.As(err, &validationErrors), errors.As(err, &typeError):
+		return http.StatusBadRequest, "validation_failed", "the request is invalid"
+	}
+
+	code, ok := statusErrorCodes[status]
+	if !ok {
+		code = "error"
+	}
+	if status >= http.StatusInternalServerError {
+		// the details of internal errors are logged, not returned
+		return status, code, http.StatusText(status)
+	}
+	return status, code, err.Error()
+}
+
+// respondError aborts the request with the error response for err; status
+// is used unless err is of a known kind. The error is attached to the
+// context so that the request logger records it.
+func respondError(ctx *gin.Context, status int, err error) {
+	_ = ctx.Error(err)
+
+	status, code, message := mapError(err, status)
+	res := errorResponse{Error: apiError{
+		Code:      code,
+		Message:   message,
+		Fields:    fieldErrors(ctx, err),
+		RequestID: ctx.Writer.Header().Get(requestIDHeaderKey),
+	}}
+
+	ctx.AbortWithStatusJSON(status, res)
+}
+
+// fieldErrors lists the fields that failed validation, if err is a
+// validation error, in the language of the request.
+func fieldErrors(ctx *gin.Context, err error) []fieldError {
+	trans, ok := ctx.Value(translatorKey).(ut.Translator)
+	if !ok {
+		return nil
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		message, err := trans.T(typeMismatchKey, typeError.Field, typeError.Type.String())
+		if err != nil {
+			message = typeError.Error()
+		}
+		return []fieldError{{Field: typeError.Field, Message: message}}
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	fields := make([]fieldError, len(validationErrors))
+	for i, fe := range validationErrors {
+		fields[i] = fieldError{
+			Field:   fe.Field(),
+			Message: fe.Translate(trans),
+		}
+	}
+	return fields
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// requireErrorCode checks that the response is an error envelope with code.
+func requireErrorCode(t *testing.T, recorder *httptest.ResponseRecorder, code string) apiError {
+	var res errorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &res))
+	require.Equal(t, code, res.Error.Code)
+	require.NotEmpty(t, res.Error.Message)
+	return res.Error
+}
+
+func TestMapError(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		status         int
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "NotFound",
+			err:            fmt.Errorf("get account: %w", db.ErrRecordNotFound),
+			status:         http.StatusInternalServerError,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "not_found",
+		},
+		{
+			name:           "UniqueViolation",
+			err:            &db.Error{Kind: db.ErrUniqueViolation, Constraint: "users_pkey"},
+			status:         http.StatusInternalServerError,
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "already_exists",
+		},
+		{
+			name:           "ForeignKeyViolation",
+			err:            &db.Error{Kind: db.ErrForeignKeyViolation},
+			status:         http.StatusInternalServerError,
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "invalid_reference",
+		},
+		{
+			name:           "InsufficientFunds",
+			err:            db.ErrInsufficientFunds,
+			status:         http.StatusInternalServerError,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "insufficient_funds",
+		},
+		{
+			name:           "SerializationFailure",
+			err:            &db.Error{Kind: db.ErrSerializationFailure},
+			status:         http.StatusInternalServerError,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "concurrent_update",
+		},
+		{
+			name:           "InvalidState",
+			err:            db.ErrPaymentRequestExpired,
+			status:         http.StatusInternalServerError,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "invalid_state",
+		},
+		{
+			name:           "RequestTimeout",
+			err:            fmt.Errorf("timeout: %w", context.DeadlineExceeded),
+			status:         http.StatusInternalServerError,
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedCode:   "timeout",
+		},
+		{
+			name:           "QueryTimeout",
+			err:            &db.Error{Kind: db.ErrQueryTimeout},
+			status:         http.StatusInternalServerError,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   "unavailable",
+		},
+		{
+			name:           "HandlerStatus",
+			err:            errors.New("account does not belong to the authenticated user"),
+			status:         http.StatusUnauthorized,
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "unauthorized",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			status, code, message := mapError(tc.err, tc.status)
+			require.Equal(t, tc.expectedStatus, status)
+			require.Equal(t, tc.expectedCode, code)
+			require.NotEmpty(t, message)
+		})
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	t.Run("ValidationFailed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+
+		server := NewTestServer(t, store)
+		recorder := httptest.NewRecorder()
+
+		request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader([]byte(`{}`)))
+		require.NoError(t, err)
+		request.Header.Set(requestIDHeaderKey, "3f5e0c1a-8d1e-4c4b-9d51-5b2f7c9a1e20")
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+		apiErr := requireErrorCode(t, recorder, "validation_failed")
+		require.Equal(t, "3f5e0c1a-8d1e-4c4b-9d51-5b2f7c9a1e20", apiErr.RequestID)
+		require.Len(t, apiErr.Fields, 2)
+		require.Equal(t, "owner", apiErr.Fields[0].Field)
+		require.Equal(t, "currency", apiErr.Fields[1].Field)
+	})
+
+	t.Run("InternalErrorHidesDetails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+			Times(1).
+			Return(db.Account{}, errors.New("connection refused by 10.0.0.12"))
+
+		server := NewTestServer(t, store)
+		recorder := httptest.NewRecorder()
+
+		request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+		apiErr := requireErrorCode(t, recorder, "internal")
+		require.NotContains(t, apiErr.Message, "10.0.0.12")
+		require.NotEmpty(t, apiErr.RequestID)
+	})
+}
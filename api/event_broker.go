@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	db "simple_bank/db/sqlc"
+	"sync"
+	"time"
+)
+
+// eventBroker fans account event notifications out to the streams subscribed
+// to each account. Subscribers are only woken up; they read the events
+// themselves from the outbox, so a dropped or coalesced wake-up loses nothing.
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan struct{}]struct{}
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subscribers: make(map[int64]map[chan struct{}]struct{}),
+	}
+}
+
+// subscribe returns a channel that is signalled whenever accountID has new
+// events, and a function that ends the subscription.
+func (broker *eventBroker) subscribe(accountID int64) (<-chan struct{}, func()) {
+	wake := make(chan struct{}, 1)
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	if broker.subscribers[accountID] == nil {
+		broker.subscribers[accountID] = make(map[chan struct{}]struct{})
+	}
+	broker.subscribers[accountID][wake] = struct{}{}
+
+	return wake, func() {
+		broker.mu.Lock()
+		defer broker.mu.Unlock()
+
+		delete(broker.subscribers[accountID], wake)
+		if len(broker.subscribers[accountID]) == 0 {
+			delete(broker.subscribers, accountID)
+		}
+	}
+}
+
+// notify wakes up every subscriber of the event's account.
+func (broker *eventBroker) notify(event db.AccountEventNotification) {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	for wake := range broker.subscribers[event.AccountID] {
+		signal(wake)
+	}
+}
+
+// notifyAll wakes up every subscriber, e.g. after notifications may have been
+// missed while reconnecting.
+func (broker *eventBroker) notifyAll() {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	for _, subscribers := range broker.subscribers {
+		for wake := range subscribers {
+			signal(wake)
+		}
+	}
+}
+
+func (broker *eventBroker) subscriberCount(accountID int64) int {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	return len(broker.subscribers[accountID])
+}
+
+func signal(wake chan struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// ListenForEvents feeds the account event streams from Postgres until ctx is
+// cancelled, reconnecting whenever the listening connection fails.
+func (server *Server) ListenForEvents(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := server.store.ListenAccountEvents(ctx, server.events.notify)
+		if err != nil {
+			slog.ErrorContext(ctx, "cannot listen for account events", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			server.events.notifyAll()
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simple_bank/db/sqlc"
+	"simple_bank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout bounds each dependency check, so that a hung database
+// fails the probe instead of stalling it.
+const healthCheckTimeout = 2 * time.Second
+
+const (
+	healthStatusOK      = "ok"
+	healthStatusFailing = "failing"
+)
+
+// dependencyHealth is the result of checking a single dependency.
+type dependencyHealth struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type healthReport struct {
+	Status string                      `json:"status"`
+	Checks map[string]dependencyHealth `json:"checks"`
+}
+
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type livenessResponse struct {
+	Status string `json:"status"`
+}
+
+// checkHealth checks every dependency the server needs to serve requests.
+func (server *Server) checkHealth(ctx context.Context) healthReport {
+	checks := map[string]func(context.Context) error{
+		"database": server.store.Ping,
+		"migrations": func(ctx context.Context) error {
+			version, dirty, err := server.store.MigrationVersion(ctx)
+			if err != nil {
+				return err
+			}
+			if dirty {
+				return fmt.Errorf("migration %d is dirty", version)
+			}
+			// a newer schema is fine: during a rolling deploy the new
+			// version migrates the database while the old one still serves
+			if version < db.SchemaVersion {
+				return fmt.Errorf("schema is at version %d, expected at least %d", version, db.SchemaVersion)
+			}
+			return nil
+		},
+		"token_maker": func(context.Context) error {
+			if server.tokenMaker == nil {
+				return errors.New("token maker is not initialized")
+			}
+			return nil
+		},
+	}
+
+	report := healthReport{
+		Status: healthStatusOK,
+		Checks: make(map[string]dependencyHealth, len(checks)),
+	}
+	for name, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		start := time.Now()
+		err := check(checkCtx)
+		latency := time.Since(start)
+		cancel()
+
+		result := dependencyHealth{
+			Status:    healthStatusOK,
+			LatencyMS: float64(latency.Microseconds()) / 1000,
+		}
+		if err != nil {
+			result.Status = healthStatusFailing
+			result.Error = err.Error()
+			report.Status = healthStatusFailing
+		}
+		report.Checks[name] = result
+	}
+
+	return report
+}
+
+// getLiveness reports that the process is up and serving requests. It does
+// not check any dependency, so that a database outage does not get the pod
+// restarted.
+func (server *Server) getLiveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, livenessResponse{Status: healthStatusOK})
+}
+
+// getReadiness reports whether the server should receive traffic. It fails
+// as soon as a graceful shutdown begins, so that the load balancer stops
+// routing to the pod before its listeners close. Error details are only
+// shown in the admin health report.
+func (server *Server) getReadiness(ctx *gin.Context) {
+	if server.draining.Load() {
+		ctx.JSON(http.StatusServiceUnavailable, readinessResponse{Status: "shutting down"})
+		return
+	}
+
+	report := server.checkHealth(ctx)
+	res := readinessResponse{
+		Status: report.Status,
+		Checks: make(map[string]string, len(report.Checks)),
+	}
+	for name, check := range report.Checks {
+		res.Checks[name] = check.Status
+	}
+
+	if report.Status != healthStatusOK {
+		ctx.JSON(http.StatusServiceUnavailable, res)
+		return
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// getHealthReport returns the status, latency and error of every dependency
+// check. Only bankers may see it since errors can reveal infrastructure
+// details.
+func (server *Server) getHealthReport(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.isBanker(authPayload.Username) {
+		err := errors.New("only bankers can view the health report")
+		respondError(ctx, http.StatusForbidden, err)
+		return
+	}
+
+	report := server.checkHealth(ctx)
+	if report.Status != healthStatusOK {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
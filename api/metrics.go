@@ -1,59 +1,52 @@
 package api
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
-// Metrics struct to store API metrics
-type Metrics struct {
-	TotalRequests   int64
-	SuccessRequests int64
-	ErrorRequests   int64
-	TotalLatency    time.Duration
-}
-
-var apiMetrics = &Metrics{}
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "simple_bank",
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route and status code.",
+	}, []string{"method", "route", "code"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "simple_bank",
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	httpRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "simple_bank",
+		Name:      "http_requests_in_flight",
+		Help:      "Number of HTTP requests being served.",
+	})
+)
 
-// MetricsMiddleware tracks API performance metrics
-func MetricsMiddleware() gin.HandlerFunc {
+// metricsMiddleware records the latency, status code and concurrency of every
+// request. Requests are labelled with the route template rather than the raw
+// path to keep the number of series bounded.
+func metricsMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		start := time.Now()
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
 
-		// Process request
 		ctx.Next()
 
-		// Calculate metrics
-		latency := time.Since(start)
-		apiMetrics.TotalRequests++
-		apiMetrics.TotalLatency += latency
-
-		if ctx.Writer.Status() >= 200 && ctx.Writer.Status() < 400 {
-			apiMetrics.SuccessRequests++
-		} else {
-			apiMetrics.ErrorRequests++
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
 		}
-	}
-}
-
-// GetMetrics returns current API metrics
-func GetMetrics() *Metrics {
-	return apiMetrics
-}
-
-// GetSuccessRate returns the success rate percentage
-func GetSuccessRate() float64 {
-	if apiMetrics.TotalRequests == 0 {
-		return 0
-	}
-	return float64(apiMetrics.SuccessRequests) / float64(apiMetrics.TotalRequests) * 100
-}
 
-// GetAverageLatency returns average response time
-func GetAverageLatency() time.Duration {
-	if apiMetrics.TotalRequests == 0 {
-		return 0
+		httpRequestDuration.WithLabelValues(ctx.Request.Method, route).Observe(time.Since(start).Seconds())
+		httpRequestsTotal.WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
 	}
-	return apiMetrics.TotalLatency / time.Duration(apiMetrics.TotalRequests)
 }
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/require"
+)
+
+// scrapeMetrics returns the metrics of the default registry in the
+// Prometheus exposition format.
+func scrapeMetrics(t *testing.T) string {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	promhttp.Handler().ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	return recorder.Body.String()
+}
+
+func TestMetricsAPI(t *testing.T) {
+	server := NewTestServer(t, nil)
+
+	// an unauthenticated request is recorded under its route template
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/accounts/42", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	body := scrapeMetrics(t)
+	require.Contains(t, body, `simple_bank_http_requests_total{code="401",method="GET",route="/accounts/:id"}`)
+	require.Contains(t, body, `simple_bank_http_request_duration_seconds_bucket{method="GET",route="/accounts/:id"`)
+	require.Contains(t, body, "simple_bank_http_requests_in_flight 0")
+
+	// the metrics are served apart from the public API
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestMetricsCountPanics(t *testing.T) {
+	server := NewTestServer(t, nil)
+	server.router.GET("/test-panic", func(ctx *gin.Context) {
+		panic("boom")
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/test-panic", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	body := scrapeMetrics(t)
+	require.Contains(t, body, `simple_bank_http_requests_total{code="500",method="GET",route="/test-panic"}`)
+	require.Contains(t, body, "simple_bank_http_requests_in_flight 0")
+}
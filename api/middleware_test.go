@@ -1,14 +1,23 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"simple_bank/logger"
 	"simple_bank/token"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -102,3 +111,147 @@ func TestAuthMiddleware(t *testing.T) {
 
 	}
 }
+
+func TestRequestLogger(t *testing.T) {
+	testCases := []struct {
+		name      string
+		requestID string
+		checkID   func(t *testing.T, requestID string)
+	}{
+		{
+			name:      "ClientRequestID",
+			requestID: "client-id-123",
+			checkID: func(t *testing.T, requestID string) {
+				require.Equal(t, "client-id-123", requestID)
+			},
+		},
+		{
+			name:      "GeneratedRequestID",
+			requestID: "",
+			checkID: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "InvalidRequestID",
+			requestID: "bad id\n{\"level\":\"ERROR\"}",
+			checkID: func(t *testing.T, requestID string) {
+				_, err := uuid.Parse(requestID)
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			server := NewTestServer(t, nil)
+
+			router := gin.New()
+			router.ContextWithFallback = true
+			router.Use(requestLogger(logger.New(&buf, "debug")))
+			router.GET("/log/:id", authMiddleWare(server.tokenMaker), func(ctx *gin.Context) {
+				logger.FromContext(ctx).Info("from handler")
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/log/1", nil)
+			require.NoError(t, err)
+			request.Header.Set(requestIDHeaderKey, tc.requestID)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "alice", time.Minute)
+
+			router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			requestID := recorder.Header().Get(requestIDHeaderKey)
+			tc.checkID(t, requestID)
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			require.Len(t, lines, 2)
+
+			var handlerLine, requestLine map[string]any
+			require.NoError(t, json.Unmarshal([]byte(lines[0]), &handlerLine))
+			require.NoError(t, json.Unmarshal([]byte(lines[1]), &requestLine))
+
+			require.Equal(t, "from handler", handlerLine["msg"])
+			require.Equal(t, requestID, handlerLine["request_id"])
+			require.Equal(t, "alice", handlerLine["username"])
+
+			require.Equal(t, "request served", requestLine["msg"])
+			require.Equal(t, requestID, requestLine["request_id"])
+			require.Equal(t, "alice", requestLine["username"])
+			require.Equal(t, "/log/:id", requestLine["route"])
+			require.EqualValues(t, http.StatusOK, requestLine["status"])
+			require.Contains(t, requestLine, "latency")
+
+			// the access token never reaches the logs
+			require.NotContains(t, buf.String(), strings.Fields(request.Header.Get(authorizationHeaderKey))[1])
+		})
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(timeoutMiddleware(time.Minute))
+
+	hasDeadline := func(ctx *gin.Context) {
+		_, ok := ctx.Deadline()
+		ctx.JSON(http.StatusOK, gin.H{"deadline": ok})
+	}
+	router.GET("/accounts/:id", hasDeadline)
+	router.GET("/accounts/:id/events", hasDeadline)
+
+	testCases := []struct {
+		path             string
+		expectedDeadline bool
+	}{
+		{path: "/accounts/1", expectedDeadline: true},
+		{path: "/accounts/1/events", expectedDeadline: false},
+	}
+
+	for _, tc := range testCases {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, tc.path, nil)
+		require.NoError(t, err)
+
+		router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.JSONEq(t, fmt.Sprintf(`{"deadline": %t}`, tc.expectedDeadline), recorder.Body.String())
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+			// a query that outlives the request deadline
+			<-ctx.Done()
+			return db.Account{}, fmt.Errorf("timeout: %w", ctx.Err())
+		})
+
+	server := NewTestServer(t, store)
+	server.config.DBRequestTimeout = 10 * time.Millisecond
+	server.setupRouter()
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+	requireErrorCode(t, recorder, "timeout")
+}
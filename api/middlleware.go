@@ -1,26 +1,119 @@
 package api
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"net/http"
+	"regexp"
+	"simple_bank/logger"
 	"simple_bank/token"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authorization_payload"
+	requestIDHeaderKey      = "X-Request-ID"
 )
 
+// requestIDPattern limits the request IDs accepted from clients, so that they
+// cannot inject arbitrary content into the logs.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// probeRoutes are only logged at debug level unless they fail.
+var probeRoutes = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+// longLivedRoutes stream their response for as long as the client stays
+// connected, so they are not subject to the request timeout.
+var longLivedRoutes = map[string]bool{
+	"/accounts/:id/events": true,
+}
+
+// timeoutMiddleware sets a deadline of timeout on the request context, which
+// handlers pass to the store, so that a request cannot hold a database
+// connection indefinitely. A zero timeout disables the deadline.
+func timeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if timeout <= 0 || longLivedRoutes[ctx.FullPath()] {
+			ctx.Next()
+			return
+		}
+
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+		ctx.Request = ctx.Request.WithContext(timeoutCtx)
+
+		ctx.Next()
+	}
+}
+
+// requestLogger tags every request with an ID, taken from the X-Request-ID
+// header when it is well-formed and generated otherwise, and logs one line
+// per request once it has been served. Requests that are being traced are
+// also tagged with their trace ID. The tagged logger is stored in the
+// request context so that handlers and the db layer log with the same ID.
+func requestLogger(base *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		requestID := ctx.GetHeader(requestIDHeaderKey)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx.Header(requestIDHeaderKey, requestID)
+
+		l := base.With("request_id", requestID)
+		if spanContext := trace.SpanContextFromContext(ctx.Request.Context()); spanContext.HasTraceID() {
+			l = l.With("trace_id", spanContext.TraceID().String())
+		}
+		ctx.Request = ctx.Request.WithContext(logger.WithContext(ctx.Request.Context(), l))
+
+		ctx.Next()
+
+		attrs := []any{
+			"method", ctx.Request.Method,
+			"route", ctx.FullPath(),
+			"path", ctx.Request.URL.Path,
+			"status", ctx.Writer.Status(),
+			"latency", time.Since(start),
+			"client_ip", ctx.ClientIP(),
+		}
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			attrs = append(attrs, "username", payload.(*token.Payload).Username)
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, "errors", ctx.Errors.String())
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case ctx.Writer.Status() >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case probeRoutes[ctx.FullPath()]:
+			// probes hit the server every few seconds
+			level = slog.LevelDebug
+		}
+		l.Log(ctx.Request.Context(), level, "request served", attrs...)
+	}
+}
+
 func authMiddleWare(tokenMaker token.Maker) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
 			err := errors.New("authorization header is required")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			respondError(ctx, http.StatusUnauthorized, err)
 			return
 
 		}
@@ -28,25 +121,31 @@ func authMiddleWare(tokenMaker token.Maker) gin.HandlerFunc {
 		fields := strings.Fields(authorizationHeader)
 		if len(fields) < 2 {
 			err := errors.New("invalid authorization header format")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			respondError(ctx, http.StatusUnauthorized, err)
 			return
 		}
 
 		authorizationType := strings.ToLower(fields[0])
 		if authorizationType != authorizationTypeBearer {
 			err := errors.New("unsupported authorization type " + authorizationType)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			respondError(ctx, http.StatusUnauthorized, err)
 			return
 		}
 
 		accessToken := fields[1]
+		_, span := tracer.Start(ctx.Request.Context(), "VerifyToken")
 		payload, err := tokenMaker.VerifyToken(accessToken)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+			respondError(ctx, http.StatusUnauthorized, err)
 			return
 		}
+		span.End()
 
 		ctx.Set(authorizationPayloadKey, payload)
+		l := logger.FromContext(ctx.Request.Context()).With("username", payload.Username)
+		ctx.Request = ctx.Request.WithContext(logger.WithContext(ctx.Request.Context(), l))
 
 		ctx.Next()
 
@@ -0,0 +1,433 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	db "simple_bank/db/sqlc"
+	"simple_bank/util"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// apiOperation documents one route of setupRouter. The OpenAPI document is
+// generated from these, using the same request and response types the
+// handlers bind and return, so the two cannot drift apart.
+type apiOperation struct {
+	Method  string
+	Path    string
+	Tag     string
+	Summary string
+	Public  bool
+	// URI, Query and Body are the types bound with ShouldBindUri,
+	// ShouldBindQuery and ShouldBindJSON.
+	URI   any
+	Query any
+	Body  any
+	// Responses maps each documented status to the type of its body; nil
+	// documents a server-sent event stream.
+	Responses map[int]any
+}
+
+var apiOperations = []apiOperation{
+	// users
+	{Method: http.MethodPost, Path: "/users", Tag: "users", Summary: "Create a user", Public: true,
+		Body: createUserRequest{}, Responses: map[int]any{http.StatusOK: userResponse{}}},
+	{Method: http.MethodPost, Path: "/users/login", Tag: "users", Summary: "Log in and get an access token", Public: true,
+		Body: loginUserRequest{}, Responses: map[int]any{http.StatusOK: loginUserResponse{}}},
+	// accounts
+	{Method: http.MethodPost, Path: "/accounts", Tag: "accounts", Summary: "Open an account",
+		Body: createAccountRequest{}, Responses: map[int]any{http.StatusOK: db.Account{}}},
+	{Method: http.MethodGet, Path: "/accounts/:id", Tag: "accounts", Summary: "Get an account",
+		URI: getAccountRequest{}, Responses: map[int]any{http.StatusOK: db.Account{}}},
+	{Method: http.MethodDelete, Path: "/accounts/:id", Tag: "accounts", Summary: "Delete an account",
+		URI: deleteAccountRequest{}, Responses: map[int]any{http.StatusOK: gin.H{}}},
+	{Method: http.MethodPatch, Path: "/accounts", Tag: "accounts", Summary: "Update an account balance",
+		Body: updateAccountRequest{}, Responses: map[int]any{http.StatusOK: db.Account{}}},
+	{Method: http.MethodGet, Path: "/accounts", Tag: "accounts", Summary: "List the caller's accounts",
+		Query: listAccountRequest{}, Responses: map[int]any{http.StatusOK: []db.Account{}}},
+	{Method: http.MethodPost, Path: "/accounts/:id/holders", Tag: "accounts", Summary: "Invite another user to share an account",
+		URI: accountHolderURI{}, Body: addAccountHolderRequest{}, Responses: map[int]any{http.StatusOK: accountHolderResponse{}}},
+	{Method: http.MethodGet, Path: "/accounts/:id/holders", Tag: "accounts", Summary: "List the holders of an account",
+		URI: accountHolderURI{}, Responses: map[int]any{http.StatusOK: []accountHolderResponse{}}},
+	{Method: http.MethodDelete, Path: "/accounts/:id/holders/:username", Tag: "accounts", Summary: "Remove a holder from an account",
+		URI: removeAccountHolderURI{}, Responses: map[int]any{http.StatusOK: gin.H{}}},
+	{Method: http.MethodGet, Path: "/account-invitations", Tag: "accounts", Summary: "List the caller's pending account invitations",
+		Responses: map[int]any{http.StatusOK: []accountHolderResponse{}}},
+	{Method: http.MethodPost, Path: "/account-invitations/:id/accept", Tag: "accounts", Summary: "Accept an invitation to hold an account",
+		URI: accountInvitationURI{}, Responses: map[int]any{http.StatusOK: accountHolderResponse{}}},
+	{Method: http.MethodPost, Path: "/account-invitations/:id/decline", Tag: "accounts", Summary: "Decline an invitation to hold an account",
+		URI: accountInvitationURI{}, Responses: map[int]any{http.StatusOK: gin.H{}}},
+	{Method: http.MethodGet, Path: "/accounts/:id/events", Tag: "accounts", Summary: "Stream balance changes as server-sent events",
+		URI: streamAccountEventsRequest{}, Query: streamAccountEventsQuery{}, Responses: map[int]any{http.StatusOK: nil}},
+	// transfers
+	{Method: http.MethodPost, Path: "/transfers", Tag: "transfers", Summary: "Transfer money between accounts",
+		Body: transferRequest{}, Responses: map[int]any{http.StatusOK: transferTxResponse{}, http.StatusAccepted: transferApprovalResponse{}}},
+	{Method: http.MethodGet, Path: "/transfers", Tag: "transfers", Summary: "Search the transfers of an account",
+		Query: listTransfersRequest{}, Responses: map[int]any{http.StatusOK: []transferResponse{}}},
+	{Method: http.MethodGet, Path: "/transfer-approvals", Tag: "transfers", Summary: "List transfers waiting for approval",
+		Query: listTransferApprovalsRequest{}, Responses: map[int]any{http.StatusOK: []transferApprovalResponse{}}},
+	{Method: http.MethodPost, Path: "/transfer-approvals/:id/approve", Tag: "transfers", Summary: "Approve and execute a pending transfer",
+		URI: transferApprovalURI{}, Responses: map[int]any{http.StatusOK: approveTransferResponse{}}},
+	{Method: http.MethodPost, Path: "/transfer-approvals/:id/reject", Tag: "transfers", Summary: "Reject a pending transfer",
+		URI: transferApprovalURI{}, Responses: map[int]any{http.StatusOK: transferApprovalResponse{}}},
+	// notifications
+	{Method: http.MethodGet, Path: "/notifications", Tag: "notifications", Summary: "List the caller's notifications",
+		Query: listNotificationsRequest{}, Responses: map[int]any{http.StatusOK: []db.Notification{}}},
+	// payees
+	{Method: http.MethodPost, Path: "/payees", Tag: "payees", Summary: "Save a payee",
+		Body: createPayeeRequest{}, Responses: map[int]any{http.StatusOK: payeeResponse{}}},
+	{Method: http.MethodGet, Path: "/payees/:id", Tag: "payees", Summary: "Get a payee",
+		URI: getPayeeRequest{}, Responses: map[int]any{http.StatusOK: payeeResponse{}}},
+	{Method: http.MethodGet, Path: "/payees", Tag: "payees", Summary: "List saved payees",
+		Query: listPayeesRequest{}, Responses: map[int]any{http.StatusOK: []payeeResponse{}}},
+	{Method: http.MethodPatch, Path: "/payees/:id", Tag: "payees", Summary: "Rename a payee",
+		URI: updatePayeeURI{}, Body: updatePayeeRequest{}, Responses: map[int]any{http.StatusOK: payeeResponse{}}},
+	{Method: http.MethodDelete, Path: "/payees/:id", Tag: "payees", Summary: "Delete a payee",
+		URI: deletePayeeRequest{}, Responses: map[int]any{http.StatusOK: gin.H{}}},
+	// payment requests
+	{Method: http.MethodPost, Path: "/payment-requests", Tag: "payment-requests", Summary: "Request money from another user",
+		Body: createPaymentRequestRequest{}, Responses: map[int]any{http.StatusOK: paymentRequestResponse{}}},
+	{Method: http.MethodGet, Path: "/payment-requests", Tag: "payment-requests", Summary: "List payment requests",
+		Query: listPaymentRequestsRequest{}, Responses: map[int]any{http.StatusOK: []paymentRequestResponse{}}},
+	{Method: http.MethodPost, Path: "/payment-requests/:id/accept", Tag: "payment-requests", Summary: "Pay a payment request",
+		URI: paymentRequestURI{}, Body: acceptPaymentRequestRequest{}, Responses: map[int]any{http.StatusOK: acceptPaymentRequestResponse{}, http.StatusAccepted: transferApprovalResponse{}}},
+	{Method: http.MethodPost, Path: "/payment-requests/:id/decline", Tag: "payment-requests", Summary: "Decline a payment request",
+		URI: paymentRequestURI{}, Responses: map[int]any{http.StatusOK: paymentRequestResponse{}}},
+	// webhooks
+	{Method: http.MethodPost, Path: "/webhooks", Tag: "webhooks", Summary: "Register a webhook",
+		Body: createWebhookRequest{}, Responses: map[int]any{http.StatusOK: db.Webhook{}}},
+	{Method: http.MethodGet, Path: "/webhooks", Tag: "webhooks", Summary: "List webhooks",
+		Query: listWebhooksRequest{}, Responses: map[int]any{http.StatusOK: []db.Webhook{}}},
+	{Method: http.MethodDelete, Path: "/webhooks/:id", Tag: "webhooks", Summary: "Delete a webhook",
+		URI: deleteWebhookRequest{}, Responses: map[int]any{http.StatusOK: gin.H{}}},
+	{Method: http.MethodGet, Path: "/webhook-deliveries", Tag: "webhooks", Summary: "List webhook deliveries",
+		Query: listWebhookDeliveriesRequest{}, Responses: map[int]any{http.StatusOK: []db.WebhookDelivery{}}},
+	{Method: http.MethodPost, Path: "/webhook-deliveries/:id/replay", Tag: "webhooks", Summary: "Queue a delivery to be sent again",
+		URI: replayWebhookDeliveryRequest{}, Responses: map[int]any{http.StatusOK: db.WebhookDelivery{}}},
+	// audit log
+	{Method: http.MethodGet, Path: "/audit-logs", Tag: "audit", Summary: "Search the audit log",
+		Query: listAuditLogsRequest{}, Responses: map[int]any{http.StatusOK: []db.AuditLog{}}},
+	// health
+	{Method: http.MethodGet, Path: "/healthz", Tag: "health", Summary: "Check that the process is alive", Public: true,
+		Responses: map[int]any{http.StatusOK: livenessResponse{}}},
+	{Method: http.MethodGet, Path: "/readyz", Tag: "health", Summary: "Check that the server can receive traffic", Public: true,
+		Responses: map[int]any{http.StatusOK: readinessResponse{}, http.StatusServiceUnavailable: readinessResponse{}}},
+	{Method: http.MethodGet, Path: "/health", Tag: "health", Summary: "Get the health of every dependency",
+		Responses: map[int]any{http.StatusOK: healthReport{}, http.StatusServiceUnavailable: healthReport{}}},
+}
+
+// OpenAPISpec returns the OpenAPI 3 document of the HTTP API.
+func OpenAPISpec() ([]byte, error) {
+	return json.MarshalIndent(newOpenAPIBuilder().build(), "", "  ")
+}
+
+type openAPIBuilder struct {
+	schemas map[string]any
+}
+
+func newOpenAPIBuilder() *openAPIBuilder {
+	return &openAPIBuilder{schemas: map[string]any{}}
+}
+
+func (b *openAPIBuilder) build() map[string]any {
+	paths := map[string]any{}
+	for _, op := range apiOperations {
+		path := openAPIPath(op.Path)
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[strings.ToLower(op.Method)] = b.operation(op)
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Simple Bank API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": b.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "PASETO",
+				},
+			},
+		},
+	}
+}
+
+func (b *openAPIBuilder) operation(op apiOperation) map[string]any {
+	operation := map[string]any{
+		"operationId": operationID(op),
+		"summary":     op.Summary,
+		"tags":        []string{op.Tag},
+	}
+
+	var parameters []any
+	if op.URI != nil {
+		parameters = append(parameters, b.parameters(reflect.TypeOf(op.URI), "uri", "path")...)
+	}
+	if op.Query != nil {
+		parameters = append(parameters, b.parameters(reflect.TypeOf(op.Query), "form", "query")...)
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if op.Body != nil {
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": b.schema(reflect.TypeOf(op.Body))},
+			},
+		}
+	}
+
+	errorContent := map[string]any{
+		"application/json": map[string]any{"schema": b.schema(reflect.TypeOf(errorResponse{}))},
+	}
+	responses := map[string]any{
+		"default": map[string]any{"description": "Error", "content": errorContent},
+	}
+	for code, body := range op.Responses {
+		response := map[string]any{"description": http.StatusText(code)}
+		if body == nil {
+			response["content"] = map[string]any{
+				"text/event-stream": map[string]any{"schema": map[string]any{"type": "string"}},
+			}
+		} else {
+			response["content"] = map[string]any{
+				"application/json": map[string]any{"schema": b.schema(reflect.TypeOf(body))},
+			}
+		}
+		responses[strconv.Itoa(code)] = response
+	}
+	operation["responses"] = responses
+
+	if !op.Public {
+		operation["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+	}
+
+	return operation
+}
+
+// parameters documents every field of t tagged with tagKey as a parameter.
+func (b *openAPIBuilder) parameters(t reflect.Type, tagKey string, in string) []any {
+	var parameters []any
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get(tagKey)
+		if name == "" || name == "-" {
+			continue
+		}
+
+		schema, required := b.fieldSchema(field)
+		parameters = append(parameters, map[string]any{
+			"name":     name,
+			"in":       in,
+			"required": required || in == "path",
+			"schema":   schema,
+		})
+	}
+	return parameters
+}
+
+// schema returns the schema of t, registering named structs as components.
+func (b *openAPIBuilder) schema(t reflect.Type) map[string]any {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return map[string]any{"type": "string", "format": "date-time"}
+	case reflect.TypeOf(pgtype.Int8{}):
+		return map[string]any{"type": "integer", "format": "int64", "nullable": true}
+	case reflect.TypeOf(json.RawMessage{}):
+		return map[string]any{"type": "object"}
+	case reflect.TypeOf([]byte{}):
+		// encoding/json encodes bytes as base64
+		return map[string]any{"type": "string", "format": "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]any{"type": "object"}
+		}
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Interface:
+		return map[string]any{"type": "object"}
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, ok := b.schemas[name]; !ok {
+			// placeholder so that recursive types terminate
+			b.schemas[name] = map[string]any{}
+			properties := map[string]any{}
+			var required []string
+			b.structProperties(t, properties, &required)
+			object := map[string]any{"type": "object", "properties": properties}
+			if len(required) > 0 {
+				object["required"] = required
+			}
+			b.schemas[name] = object
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+
+	return map[string]any{}
+}
+
+// structProperties adds the JSON fields of t to properties, flattening
+// embedded structs the way encoding/json does.
+func (b *openAPIBuilder) structProperties(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" {
+			b.structProperties(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, isRequired := b.fieldSchema(field)
+		properties[name] = schema
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// fieldSchema returns the schema of a request or response field, refined by
+// its binding rules, and whether the field is required.
+func (b *openAPIBuilder) fieldSchema(field reflect.StructField) (map[string]any, bool) {
+	schema := b.schema(field.Type)
+	if _, isRef := schema["$ref"]; isRef {
+		return schema, false
+	}
+
+	required := false
+	isString := field.Type.Kind() == reflect.String
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			switch {
+			case isString && name == "min":
+				schema["minLength"] = n
+			case isString:
+				schema["maxLength"] = n
+			case name == "min":
+				schema["minimum"] = n
+			default:
+				schema["maximum"] = n
+			}
+		case "gt":
+			if n, err := strconv.Atoi(param); err == nil {
+				schema["minimum"] = n
+				schema["exclusiveMinimum"] = true
+			}
+		case "oneof":
+			schema["enum"] = strings.Fields(param)
+		case "email":
+			schema["format"] = "email"
+		case "http_url":
+			schema["format"] = "uri"
+		case "alphanum":
+			schema["pattern"] = "^[a-zA-Z0-9]+$"
+		case "currency":
+			schema["enum"] = util.SupportedCurrencies
+		case "account_number":
+			schema["description"] = "IBAN-style account number"
+		case "account_ref":
+			schema["description"] = "account ID or IBAN-style account number"
+		case "memo":
+			schema["pattern"] = util.MemoPattern.String()
+		case "reference":
+			schema["pattern"] = util.ReferencePattern.String()
+		case "category":
+			schema["pattern"] = util.CategoryPattern.String()
+		}
+	}
+
+	return schema, required
+}
+
+// schemaName names the component of a struct after its Go type, e.g.
+// "Account" or "TransferRequest".
+func schemaName(t reflect.Type) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
+
+// openAPIPath turns a gin path such as /accounts/:id into /accounts/{id}.
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// operationID derives an identifier such as "postAccountsIdHolders".
+func operationID(op apiOperation) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(op.Method))
+	for _, word := range strings.FieldsFunc(op.Path, func(r rune) bool {
+		return r == '/' || r == ':' || r == '-'
+	}) {
+		id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return id.String()
+}
+
+func (server *Server) getOpenAPISpec(ctx *gin.Context) {
+	spec, err := OpenAPISpec()
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/json", spec)
+}
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+func (server *Server) getSwaggerUI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", swaggerUI)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var updateOpenAPI = flag.Bool("update", false, "rewrite docs/openapi.json from the route table")
+
+const openAPIFile = "../docs/openapi.json"
+
+func loadOpenAPISpec(t *testing.T) map[string]any {
+	data, err := OpenAPISpec()
+	require.NoError(t, err)
+
+	var spec map[string]any
+	require.NoError(t, json.Unmarshal(data, &spec))
+	return spec
+}
+
+// TestOpenAPIMatchesRouter fails when a route is added to or removed from
+// setupRouter without updating apiOperations.
+func TestOpenAPIMatchesRouter(t *testing.T) {
+	server := NewTestServer(t, nil)
+	undocumented := map[string]bool{
+		"GET /openapi.json": true,
+		"GET /docs":         true,
+	}
+
+	routes := map[string]bool{}
+	for _, route := range server.router.Routes() {
+		key := route.Method + " " + openAPIPath(route.Path)
+		if !undocumented[key] {
+			routes[key] = true
+		}
+	}
+
+	documented := map[string]bool{}
+	paths := loadOpenAPISpec(t)["paths"].(map[string]any)
+	for path, item := range paths {
+		for method := range item.(map[string]any) {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	require.Equal(t, routes, documented)
+}
+
+func TestOpenAPIRequestSchemas(t *testing.T) {
+	spec := loadOpenAPISpec(t)
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+
+	transfer := schemas["TransferRequest"].(map[string]any)
+	properties := transfer["properties"].(map[string]any)
+	for _, name := range []string{"from_account_id", "to_account_number", "payee_id", "amount", "currency", "category"} {
+		require.Contains(t, properties, name)
+	}
+	require.Contains(t, transfer["required"], "currency")
+	require.Len(t, properties["currency"].(map[string]any)["enum"], 6)
+
+	// hidden fields stay hidden
+	account := schemas["Account"].(map[string]any)["properties"].(map[string]any)
+	require.NotContains(t, account, "id")
+	webhook := schemas["Webhook"].(map[string]any)["properties"].(map[string]any)
+	require.NotContains(t, webhook, "secret")
+
+	// every reference resolves
+	data, err := json.Marshal(spec)
+	require.NoError(t, err)
+	for _, part := range strings.Split(string(data), `"$ref":"#/components/schemas/`)[1:] {
+		name := part[:strings.Index(part, `"`)]
+		require.Contains(t, schemas, name)
+	}
+}
+
+// TestOpenAPISpecUpToDate keeps docs/openapi.json, which client generators
+// consume, in sync with the route table. Run with -update to rewrite it.
+func TestOpenAPISpecUpToDate(t *testing.T) {
+	spec, err := OpenAPISpec()
+	require.NoError(t, err)
+	spec = append(spec, '\n')
+
+	if *updateOpenAPI {
+		require.NoError(t, os.WriteFile(openAPIFile, spec, 0o644))
+	}
+
+	committed, err := os.ReadFile(openAPIFile)
+	require.NoError(t, err)
+	require.Equal(t, string(committed), string(spec), "docs/openapi.json is stale, run make openapi")
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	server := NewTestServer(t, nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var spec map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &spec))
+	require.Equal(t, "3.0.3", spec["openapi"])
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/docs", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "/openapi.json")
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simple_bank/db/sqlc"
+	"simple_bank/policy"
+	"simple_bank/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+// payeeResponse is a payee with the account number in place of the internal
+// account ID.
+type payeeResponse struct {
+	db.Payee
+	AccountNumber string `json:"account_number"`
+}
+
+type createPayeeRequest struct {
+	Nickname      string `json:"nickname" binding:"required,max=50,memo"`
+	AccountID     int64  `json:"account_id" binding:"required_without=AccountNumber,omitempty,min=1"`
+	AccountNumber string `json:"account_number" binding:"omitempty,account_number"`
+	Currency      string `json:"currency" binding:"required,currency"`
+}
+
+func (server *Server) createPayee(ctx *gin.Context) {
+	var req createPayeeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	account, valid := server.validAccount(ctx, req.AccountID, req.AccountNumber, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.CreatePayeeParams{
+		Owner:     authPayload.Username,
+		Nickname:  req.Nickname,
+		AccountID: account.ID,
+		Currency:  req.Currency,
+	}
+
+	payee, err := server.store.CreatePayee(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, payeeResponse{Payee: payee, AccountNumber: account.AccountNumber})
+}
+
+type getPayeeRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getPayee(ctx *gin.Context) {
+	var req getPayeeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	payee, valid := server.validPayee(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	server.respondPayee(ctx, payee)
+}
+
+type listPayeesRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listPayees(ctx *gin.Context) {
+	var req listPayeesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.ListPayeesParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	payees, err := server.store.ListPayees(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	accountIDs := make([]int64, len(payees))
+	for i, payee := range payees {
+		accountIDs[i] = payee.AccountID
+	}
+	accountNumbers, err := server.accountNumbers(ctx, accountIDs...)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := make([]payeeResponse, len(payees))
+	for i, payee := range payees {
+		rsp[i] = payeeResponse{Payee: payee, AccountNumber: accountNumbers[payee.AccountID]}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updatePayeeURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type updatePayeeRequest struct {
+	Nickname string `json:"nickname" binding:"required,max=50,memo"`
+}
+
+func (server *Server) updatePayee(ctx *gin.Context) {
+	var uri updatePayeeURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	var req updatePayeeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, valid := server.validPayee(ctx, uri.ID); !valid {
+		return
+	}
+
+	arg := db.UpdatePayeeParams{
+		ID:       uri.ID,
+		Nickname: req.Nickname,
+	}
+
+	payee, err := server.store.UpdatePayee(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	server.respondPayee(ctx, payee)
+}
+
+type deletePayeeRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deletePayee(ctx *gin.Context) {
+	var req deletePayeeRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, valid := server.validPayee(ctx, req.ID); !valid {
+		return
+	}
+
+	err := server.store.DeletePayee(ctx, req.ID)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+// respondPayee looks up the account number of the payee and responds with it.
+func (server *Server) respondPayee(ctx *gin.Context, payee db.Payee) {
+	accountNumbers, err := server.accountNumbers(ctx, payee.AccountID)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, payeeResponse{Payee: payee, AccountNumber: accountNumbers[payee.AccountID]})
+}
+
+// validPayee loads a payee and checks that it belongs to the authenticated user.
+func (server *Server) validPayee(ctx *gin.Context, payeeID int64) (db.Payee, bool) {
+	payee, err := server.store.GetPayee(ctx, payeeID)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return payee, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if payee.Owner != authPayload.Username {
+		err := errors.New("payee does not belong to the authenticated user")
+		respondError(ctx, http.StatusUnauthorized, err)
+		return payee, false
+	}
+
+	return payee, true
+}
+
+// checkPayeeCoolingOff enforces policy.CheckPayeeCoolingOff for a transfer
+// by the authenticated user.
+func (server *Server) checkPayeeCoolingOff(ctx *gin.Context, toAccountID int64, amount int64) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	err := policy.CheckPayeeCoolingOff(ctx, server.store, server.config, authPayload.Username, toAccountID, amount)
+	if err != nil {
+		if errors.Is(err, policy.ErrPayeeCoolingOff) {
+			respondError(ctx, http.StatusForbidden, err)
+			return false
+		}
+		respondError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+
+	return true
+}
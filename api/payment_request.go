@@ -0,0 +1,273 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"simple_bank/audit"
+	db "simple_bank/db/sqlc"
+	"simple_bank/token"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const paymentRequestRoleRequester = "requester"
+
+// paymentRequestResponse is a payment request with the account number of the
+// requester's account in place of its internal ID.
+type paymentRequestResponse struct {
+	db.PaymentRequest
+	ToAccountNumber string `json:"to_account_number"`
+}
+
+type createPaymentRequestRequest struct {
+	Payer           string `json:"payer" binding:"required,alphanum"`
+	ToAccountID     int64  `json:"to_account_id" binding:"required_without=ToAccountNumber,omitempty,min=1"`
+	ToAccountNumber string `json:"to_account_number" binding:"omitempty,account_number"`
+	Amount          int64  `json:"amount" binding:"required,gt=0"`
+	Currency        string `json:"currency" binding:"required,currency"`
+	Description     string `json:"description" binding:"omitempty,max=140,memo"`
+}
+
+func (server *Server) createPaymentRequest(ctx *gin.Context) {
+	var req createPaymentRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.Payer == authPayload.Username {
+		err := errors.New("cannot request money from yourself")
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	toAccount, valid := server.validAccount(ctx, req.ToAccountID, req.ToAccountNumber, req.Currency)
+	if !valid {
+		return
+	}
+
+	if _, valid := server.authorizeAccount(ctx, toAccount.ID, db.AccountHolderRolePrimary, db.AccountHolderRoleJoint); !valid {
+		return
+	}
+
+	_, err := server.store.GetUser(ctx, req.Payer)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	arg := db.CreatePaymentRequestParams{
+		Requester:   authPayload.Username,
+		Payer:       req.Payer,
+		ToAccountID: toAccount.ID,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		ExpiresAt:   time.Now().Add(server.config.PaymentRequestTTL),
+	}
+
+	paymentRequest, err := server.store.CreatePaymentRequest(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentRequestResponse{PaymentRequest: paymentRequest, ToAccountNumber: toAccount.AccountNumber})
+}
+
+type listPaymentRequestsRequest struct {
+	Role     string `form:"role" binding:"omitempty,oneof=payer requester"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listPaymentRequests lists the requests addressed to the caller, or the ones
+// the caller sent when role=requester.
+func (server *Server) listPaymentRequests(ctx *gin.Context) {
+	var req listPaymentRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	var paymentRequests []db.PaymentRequest
+	var err error
+	if req.Role == paymentRequestRoleRequester {
+		paymentRequests, err = server.store.ListPaymentRequestsByRequester(ctx, db.ListPaymentRequestsByRequesterParams{
+			Requester: authPayload.Username,
+			Limit:     req.PageSize,
+			Offset:    (req.PageID - 1) * req.PageSize,
+		})
+	} else {
+		paymentRequests, err = server.store.ListPaymentRequestsByPayer(ctx, db.ListPaymentRequestsByPayerParams{
+			Payer:  authPayload.Username,
+			Limit:  req.PageSize,
+			Offset: (req.PageID - 1) * req.PageSize,
+		})
+	}
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	accountIDs := make([]int64, len(paymentRequests))
+	for i, paymentRequest := range paymentRequests {
+		accountIDs[i] = paymentRequest.ToAccountID
+	}
+	accountNumbers, err := server.accountNumbers(ctx, accountIDs...)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := make([]paymentRequestResponse, len(paymentRequests))
+	for i, paymentRequest := range paymentRequests {
+		rsp[i] = paymentRequestResponse{PaymentRequest: paymentRequest, ToAccountNumber: accountNumbers[paymentRequest.ToAccountID]}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type paymentRequestURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type acceptPaymentRequestResponse struct {
+	PaymentRequest paymentRequestResponse `json:"payment_request"`
+	transferTxResponse
+}
+
+type acceptPaymentRequestRequest struct {
+	FromAccountID     int64  `json:"from_account_id" binding:"required_without=FromAccountNumber,excluded_with=FromAccountNumber,omitempty,min=1"`
+	FromAccountNumber string `json:"from_account_number" binding:"omitempty,account_number"`
+}
+
+// acceptPaymentRequest pays a pending request from one of the payer's accounts.
+// Like a transfer, a payment above the approval threshold is parked until a
+// second user approves it.
+func (server *Server) acceptPaymentRequest(ctx *gin.Context) {
+	var uri paymentRequestURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	var req acceptPaymentRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	paymentRequest, valid := server.validPaymentRequest(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.FromAccountNumber, paymentRequest.Currency)
+	if !valid {
+		return
+	}
+
+	if _, valid := server.authorizeAccount(ctx, fromAccount.ID, db.AccountHolderRolePrimary, db.AccountHolderRoleJoint); !valid {
+		return
+	}
+
+	if !server.checkPayeeCoolingOff(ctx, paymentRequest.ToAccountID, paymentRequest.Amount) {
+		return
+	}
+
+	if server.needsApproval(paymentRequest.Amount) {
+		server.requestPaymentApproval(ctx, paymentRequest, fromAccount)
+		return
+	}
+
+	arg := db.AcceptPaymentRequestTxParams{
+		PaymentRequestID: paymentRequest.ID,
+		FromAccountID:    fromAccount.ID,
+	}
+
+	result, err := server.store.AcceptPaymentRequestTx(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := acceptPaymentRequestResponse{
+		PaymentRequest: paymentRequestResponse{
+			PaymentRequest:  result.PaymentRequest,
+			ToAccountNumber: result.ToAccount.AccountNumber,
+		},
+		transferTxResponse: newTransferTxResponse(result.TransferTXResult),
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	server.recordAudit(ctx, authPayload.Username, audit.ActionPaymentRequestAccepted, audit.ResourcePaymentRequest,
+		strconv.FormatInt(paymentRequest.ID, 10),
+		paymentRequestResponse{PaymentRequest: paymentRequest, ToAccountNumber: result.ToAccount.AccountNumber}, rsp)
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) declinePaymentRequest(ctx *gin.Context) {
+	var uri paymentRequestURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	paymentRequest, valid := server.validPaymentRequest(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	if paymentRequest.Status != db.PaymentRequestStatusPending {
+		respondError(ctx, http.StatusConflict, db.ErrPaymentRequestNotPending)
+		return
+	}
+
+	arg := db.UpdatePaymentRequestStatusParams{
+		ID:     paymentRequest.ID,
+		Status: db.PaymentRequestStatusDeclined,
+	}
+
+	paymentRequest, err := server.store.UpdatePaymentRequestStatus(ctx, arg)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		// the request was answered or expired since it was read
+		err = db.ErrPaymentRequestNotPending
+	}
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	accountNumbers, err := server.accountNumbers(ctx, paymentRequest.ToAccountID)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, paymentRequestResponse{PaymentRequest: paymentRequest, ToAccountNumber: accountNumbers[paymentRequest.ToAccountID]})
+}
+
+// validPaymentRequest loads a payment request and checks that the
+// authenticated user is the one being asked to pay.
+func (server *Server) validPaymentRequest(ctx *gin.Context, paymentRequestID int64) (db.PaymentRequest, bool) {
+	paymentRequest, err := server.store.GetPaymentRequest(ctx, paymentRequestID)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return paymentRequest, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if paymentRequest.Payer != authPayload.Username {
+		err := errors.New("payment request is not addressed to the authenticated user")
+		respondError(ctx, http.StatusUnauthorized, err)
+		return paymentRequest, false
+	}
+
+	return paymentRequest, true
+}
@@ -1,22 +1,45 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
 	db "simple_bank/db/sqlc"
 	"simple_bank/token"
 	"simple_bank/util"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 )
 
+// readHeaderTimeout bounds how long a client may take to send request
+// headers, so that idle connections cannot be held open indefinitely.
+const readHeaderTimeout = 10 * time.Second
+
 // Server serves HTTP requests for our banking service.
 type Server struct {
 	config     util.Config
 	tokenMaker token.Maker
 	store      db.Store
 	router     *gin.Engine
+	httpServer *http.Server
+	events     *eventBroker
+	// translator translates validation errors into the request language.
+	translator *ut.UniversalTranslator
+	// draining is set once a graceful shutdown begins, which fails the
+	// readiness probe while the listeners are still open.
+	draining atomic.Bool
+	// streams is cancelled when the server shuts down, which ends the
+	// long-lived event streams that would otherwise hold the drain open.
+	streams     context.Context
+	stopStreams context.CancelFunc
 }
 
 // NewServer creates a new HTTP server and sets up routing.
@@ -29,18 +52,46 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 		config:     config,
 		store:      store,
 		tokenMaker: tokenMaker,
+		events:     newEventBroker(),
+	}
+
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil, errors.New("unexpected validator engine")
 	}
+	v.RegisterTagNameFunc(fieldName)
+	v.RegisterValidation("currency", validCurrency)
+	v.RegisterValidation("memo", validMemo)
+	v.RegisterValidation("reference", validReference)
+	v.RegisterValidation("category", validCategory)
+	v.RegisterValidation("account_number", validAccountNumber)
+	v.RegisterValidation("account_ref", validAccountRef)
 
-	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		v.RegisterValidation("currency", validCurrency)
+	server.translator, err = newTranslator(v)
+	if err != nil {
+		return nil, err
 	}
 
 	server.setupRouter()
+
+	server.streams, server.stopStreams = context.WithCancel(context.Background())
+	server.httpServer = &http.Server{
+		Handler:           server.router,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	server.httpServer.RegisterOnShutdown(server.stopStreams)
+
 	return server, nil
 }
 
 func (server *Server) setupRouter() {
-	router := gin.Default()
+	router := gin.New()
+	// handlers pass the gin context to the store, which must see the values
+	// of the request context, such as the request-scoped logger
+	router.ContextWithFallback = true
+	// metricsMiddleware wraps gin.Recovery so that panics are counted as 500s
+	router.Use(tracingMiddleware(), requestLogger(slog.Default()), metricsMiddleware(), gin.Recovery(),
+		localeMiddleware(server.translator), timeoutMiddleware(server.config.DBRequestTimeout))
 	authRoutes := router.Group("/").Use(authMiddleWare(server.tokenMaker))
 	//account routes
 	authRoutes.POST("/accounts", server.createAccount)
@@ -48,21 +99,87 @@ func (server *Server) setupRouter() {
 	authRoutes.DELETE("/accounts/:id", server.deleteAccount)
 	authRoutes.PATCH("/accounts", server.updateAccount)
 	authRoutes.GET("/accounts", server.listAccount)
+	authRoutes.POST("/accounts/:id/holders", server.addAccountHolder)
+	authRoutes.GET("/accounts/:id/holders", server.listAccountHolders)
+	authRoutes.DELETE("/accounts/:id/holders/:username", server.removeAccountHolder)
+	authRoutes.GET("/accounts/:id/events", server.streamAccountEvents)
+	authRoutes.GET("/account-invitations", server.listAccountInvitations)
+	authRoutes.POST("/account-invitations/:id/accept", server.acceptAccountInvitation)
+	authRoutes.POST("/account-invitations/:id/decline", server.declineAccountInvitation)
 	// transfer routes
 	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.GET("/transfers", server.listTransfers)
+	// transfer approval routes
+	authRoutes.GET("/transfer-approvals", server.listTransferApprovals)
+	authRoutes.POST("/transfer-approvals/:id/approve", server.approveTransfer)
+	authRoutes.POST("/transfer-approvals/:id/reject", server.rejectTransfer)
+	// notification routes
+	authRoutes.GET("/notifications", server.listNotifications)
+	// payee routes
+	authRoutes.POST("/payees", server.createPayee)
+	authRoutes.GET("/payees/:id", server.getPayee)
+	authRoutes.GET("/payees", server.listPayees)
+	authRoutes.PATCH("/payees/:id", server.updatePayee)
+	authRoutes.DELETE("/payees/:id", server.deletePayee)
+	// payment request routes
+	authRoutes.POST("/payment-requests", server.createPaymentRequest)
+	authRoutes.GET("/payment-requests", server.listPaymentRequests)
+	authRoutes.POST("/payment-requests/:id/accept", server.acceptPaymentRequest)
+	authRoutes.POST("/payment-requests/:id/decline", server.declinePaymentRequest)
+	// webhook routes
+	authRoutes.POST("/webhooks", server.createWebhook)
+	authRoutes.GET("/webhooks", server.listWebhooks)
+	authRoutes.DELETE("/webhooks/:id", server.deleteWebhook)
+	authRoutes.GET("/webhook-deliveries", server.listWebhookDeliveries)
+	authRoutes.POST("/webhook-deliveries/:id/replay", server.replayWebhookDelivery)
+	// audit routes
+	authRoutes.GET("/audit-logs", server.listAuditLogs)
 	// user routes
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
-	// auth routes
+	// documentation routes
+	router.GET("/openapi.json", server.getOpenAPISpec)
+	router.GET("/docs", server.getSwaggerUI)
+	// monitoring routes
+	router.GET("/healthz", server.getLiveness)
+	router.GET("/readyz", server.getReadiness)
+	authRoutes.GET("/health", server.getHealthReport)
 
 	server.router = router
 }
 
-// Start runs the HTTP server on the specified address.
+// Start listens on the specified address and serves HTTP requests until
+// Shutdown is called.
 func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return server.Serve(listener)
+}
+
+// Serve serves HTTP requests on the listener until Shutdown is called, after
+// which it returns nil.
+func (server *Server) Serve(listener net.Listener) error {
+	err := server.httpServer.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// Shutdown fails the readiness probe for the configured ShutdownDelay, so
+// that the load balancer stops sending new requests, then stops accepting
+// connections, ends open event streams and waits for in-flight requests to
+// complete, or for ctx to be done.
+func (server *Server) Shutdown(ctx context.Context) error {
+	server.draining.Store(true)
+
+	select {
+	case <-time.After(server.config.ShutdownDelay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return server.httpServer.Shutdown(ctx)
 }
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerShutdown(t *testing.T) {
+	server := NewTestServer(t, nil)
+
+	started := make(chan struct{})
+	server.router.GET("/slow", func(ctx *gin.Context) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		response, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		if err != nil {
+			t.Error(err)
+		}
+		responses <- response
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, server.Shutdown(ctx))
+
+	// the in-flight request is allowed to complete
+	response := <-responses
+	require.NotNil(t, response)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	response.Body.Close()
+
+	require.NoError(t, <-served)
+	require.True(t, server.draining.Load())
+
+	// open event streams are told to end
+	require.Eventually(t, func() bool {
+		return server.streams.Err() != nil
+	}, time.Second, 10*time.Millisecond)
+
+	// new connections are refused
+	_, err = http.Get("http://" + listener.Addr().String() + "/slow")
+	require.Error(t, err)
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	server := NewTestServer(t, nil)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server.router.GET("/stuck", func(ctx *gin.Context) {
+		close(started)
+		<-release
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+
+	go http.Get("http://" + listener.Addr().String() + "/stuck")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, server.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func TestServerShutdownDelay(t *testing.T) {
+	server := NewTestServer(t, nil)
+	server.config.ShutdownDelay = 100 * time.Millisecond
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- server.Shutdown(context.Background())
+	}()
+
+	// during the delay the server keeps serving but reports not ready
+	require.Eventually(t, server.draining.Load, time.Second, time.Millisecond)
+	response, err := http.Get("http://" + listener.Addr().String() + "/readyz")
+	require.NoError(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+
+	require.NoError(t, <-shutdown)
+}
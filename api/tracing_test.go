@@ -0,0 +1,130 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	spanRecorderOnce sync.Once
+	spanRecorder     *tracetest.SpanRecorder
+)
+
+// recordSpans installs a global tracer provider that records every span.
+// Tracers obtained from the global provider are bound to the first provider
+// installed, so it is installed once per test binary and tests filter the
+// recorded spans by trace ID.
+func recordSpans() *tracetest.SpanRecorder {
+	spanRecorderOnce.Do(func() {
+		spanRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return spanRecorder
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := recordSpans()
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		status    int
+		setupAuth bool
+		checkSpan func(t *testing.T, spans map[string]sdktrace.ReadOnlySpan)
+	}{
+		{
+			name:      "OK",
+			status:    http.StatusOK,
+			setupAuth: true,
+			checkSpan: func(t *testing.T, spans map[string]sdktrace.ReadOnlySpan) {
+				server := spans["GET /trace/:id"]
+				require.NotNil(t, server)
+				require.Equal(t, trace.SpanKindServer, server.SpanKind())
+				require.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+				require.Equal(t, "/trace/:id", spanAttribute(server, "http.route").AsString())
+				require.EqualValues(t, http.StatusOK, spanAttribute(server, "http.response.status_code").AsInt64())
+				require.Equal(t, codes.Unset, server.Status().Code)
+
+				verify := spans["VerifyToken"]
+				require.NotNil(t, verify)
+				require.Equal(t, server.SpanContext().SpanID(), verify.Parent().SpanID())
+				require.Equal(t, codes.Unset, verify.Status().Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			status:    http.StatusInternalServerError,
+			setupAuth: true,
+			checkSpan: func(t *testing.T, spans map[string]sdktrace.ReadOnlySpan) {
+				server := spans["GET /trace/:id"]
+				require.NotNil(t, server)
+				require.Equal(t, codes.Error, server.Status().Code)
+			},
+		},
+		{
+			name:      "Unauthorized",
+			setupAuth: false,
+			checkSpan: func(t *testing.T, spans map[string]sdktrace.ReadOnlySpan) {
+				server := spans["GET /trace/:id"]
+				require.NotNil(t, server)
+				require.EqualValues(t, http.StatusUnauthorized, spanAttribute(server, "http.response.status_code").AsInt64())
+				require.NotContains(t, spans, "VerifyToken")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewTestServer(t, nil)
+			server.router.GET("/trace/:id", authMiddleWare(server.tokenMaker), func(ctx *gin.Context) {
+				ctx.JSON(tc.status, gin.H{})
+			})
+
+			request, err := http.NewRequest(http.MethodGet, "/trace/1", nil)
+			require.NoError(t, err)
+			request.Header.Set("traceparent", traceparent)
+			if tc.setupAuth {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "user", time.Minute)
+			}
+
+			before := len(recorder.Ended())
+			server.router.ServeHTTP(httptest.NewRecorder(), request)
+
+			spans := make(map[string]sdktrace.ReadOnlySpan)
+			for _, span := range recorder.Ended()[before:] {
+				if span.SpanContext().TraceID() == traceID {
+					spans[span.Name()] = span
+				}
+			}
+			tc.checkSpan(t, spans)
+		})
+	}
+}
@@ -1,76 +1,211 @@
 package api
 
 import (
-	"database/sql"
-	"errors"
 	"fmt"
 	"net/http"
+	"simple_bank/audit"
 	db "simple_bank/db/sqlc"
 	"simple_bank/token"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type transferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required", min=1`
-	ToAccountID   int64  `json:"to_account_id" binding:"required", min=1`
-	Amount        int64  `json:"amount" binding:"required", gt=0`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID     int64  `json:"from_account_id" binding:"required_without=FromAccountNumber,excluded_with=FromAccountNumber,omitempty,min=1"`
+	FromAccountNumber string `json:"from_account_number" binding:"omitempty,account_number"`
+	ToAccountID       int64  `json:"to_account_id" binding:"required_without_all=ToAccountNumber PayeeID,excluded_with=ToAccountNumber PayeeID,omitempty,min=1"`
+	ToAccountNumber   string `json:"to_account_number" binding:"omitempty,excluded_with=PayeeID,account_number"`
+	PayeeID           int64  `json:"payee_id" binding:"omitempty,min=1"`
+	Amount            int64  `json:"amount" binding:"required,gt=0"`
+	Currency          string `json:"currency" binding:"required,currency"`
+	Description       string `json:"description" binding:"omitempty,max=140,memo"`
+	Reference         string `json:"reference" binding:"omitempty,max=35,reference"`
+	Category          string `json:"category" binding:"omitempty,max=32,category"`
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
 	var req transferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, http.StatusBadRequest, err)
 		return
 	}
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.FromAccountNumber, req.Currency)
 	if !valid {
 		return
 	}
 
-	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account does not belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	// view-only holders can see the account but not move money out of it
+	if _, valid := server.authorizeAccount(ctx, fromAccount.ID, db.AccountHolderRolePrimary, db.AccountHolderRoleJoint); !valid {
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	toAccountID, toAccountNumber := req.ToAccountID, req.ToAccountNumber
+	if req.PayeeID != 0 {
+		payee, valid := server.validPayee(ctx, req.PayeeID)
+		if !valid {
+			return
+		}
+		if payee.Currency != req.Currency {
+			err := fmt.Errorf("payee %d currency mismatch: expected %s, got %s", payee.ID, req.Currency, payee.Currency)
+			respondError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		toAccountID = payee.AccountID
+	}
+
+	toAccount, valid := server.validAccount(ctx, toAccountID, toAccountNumber, req.Currency)
 	if !valid {
 		return
 	}
 
+	// the cooling-off applies however the payee's account is given
+	if !server.checkPayeeCoolingOff(ctx, toAccount.ID, req.Amount) {
+		return
+	}
+
+	if server.needsApproval(req.Amount) {
+		server.requestTransferApproval(ctx, req, fromAccount, toAccount)
+		return
+	}
+
 	arg := db.TransferTXParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
 		Amount:        req.Amount,
+		Description:   req.Description,
+		Reference:     req.Reference,
+		Category:      req.Category,
 	}
 
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, result)
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	rsp := newTransferTxResponse(result)
+	server.recordAudit(ctx, authPayload.Username, audit.ActionTransferCreated, audit.ResourceTransfer,
+		strconv.FormatInt(result.Transfer.ID, 10), nil, rsp)
+
+	ctx.JSON(http.StatusOK, rsp)
 }
 
-func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
-	account, err := server.store.GetAccount(ctx, accountID)
+// transferResponse is a transfer with the account numbers of both sides in
+// place of their internal IDs.
+type transferResponse struct {
+	db.Transfer
+	FromAccountNumber string `json:"from_account_number"`
+	ToAccountNumber   string `json:"to_account_number"`
+}
+
+func newTransferResponse(transfer db.Transfer, accountNumbers map[int64]string) transferResponse {
+	return transferResponse{
+		Transfer:          transfer,
+		FromAccountNumber: accountNumbers[transfer.FromAccountID],
+		ToAccountNumber:   accountNumbers[transfer.ToAccountID],
+	}
+}
+
+type entryResponse struct {
+	db.Entry
+	AccountNumber string `json:"account_number"`
+}
+
+type transferTxResponse struct {
+	Transfer    transferResponse `json:"transfer"`
+	FromAccount db.Account       `json:"from_account"`
+	ToAccount   db.Account       `json:"to_account"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+}
+
+func newTransferTxResponse(result db.TransferTXResult) transferTxResponse {
+	accountNumbers := map[int64]string{
+		result.FromAccount.ID: result.FromAccount.AccountNumber,
+		result.ToAccount.ID:   result.ToAccount.AccountNumber,
+	}
+	return transferTxResponse{
+		Transfer:    newTransferResponse(result.Transfer, accountNumbers),
+		FromAccount: result.FromAccount,
+		ToAccount:   result.ToAccount,
+		FromEntry:   entryResponse{Entry: result.FromEntry, AccountNumber: result.FromAccount.AccountNumber},
+		ToEntry:     entryResponse{Entry: result.ToEntry, AccountNumber: result.ToAccount.AccountNumber},
+	}
+}
+
+type listTransfersRequest struct {
+	AccountID     int64  `form:"account_id" binding:"required_without=AccountNumber,omitempty,min=1"`
+	AccountNumber string `form:"account_number" binding:"omitempty,account_number"`
+	PageID        int32  `form:"page_id" binding:"required,min=1"`
+	PageSize      int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Query         string `form:"q" binding:"omitempty,max=140,memo"`
+	Category      string `form:"category" binding:"omitempty,max=32,category"`
+}
+
+// listTransfers returns the transfer history of one of the caller's accounts,
+// optionally filtered by category and a search term matched against the
+// description and reference.
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	account, err := server.lookupAccount(ctx, req.AccountID, req.AccountNumber)
 	if err != nil {
-		if "sql: "+err.Error() == sql.ErrNoRows.Error() {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return account, false
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if _, valid := server.authorizeAccount(ctx, account.ID); !valid {
+		return
+	}
+
+	arg := db.SearchTransfersParams{
+		AccountID: account.ID,
+		Category:  req.Category,
+		Query:     req.Query,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	}
+
+	transfers, err := server.store.SearchTransfersFromReplica(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	accountIDs := make([]int64, 0, 2*len(transfers))
+	for _, transfer := range transfers {
+		accountIDs = append(accountIDs, transfer.FromAccountID, transfer.ToAccountID)
+	}
+	accountNumbers, err := server.accountNumbers(ctx, accountIDs...)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := make([]transferResponse, len(transfers))
+	for i, transfer := range transfers {
+		rsp[i] = newTransferResponse(transfer, accountNumbers)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) validAccount(ctx *gin.Context, accountID int64, accountNumber string, currency string) (db.Account, bool) {
+	account, err := server.lookupAccount(ctx, accountID, accountNumber)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
 		return account, false
 	}
 
 	if account.Currency != currency {
-		err := fmt.Errorf("account %d currency mismatch: expected %s, got %s", accountID, currency, account.Currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		err := fmt.Errorf("account %s currency mismatch: expected %s, got %s", account.AccountNumber, currency, account.Currency)
+		respondError(ctx, http.StatusBadRequest, err)
 		return account, false
 	}
 
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"simple_bank/audit"
+	db "simple_bank/db/sqlc"
+	"simple_bank/token"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// needsApproval reports whether a transfer of amount has to be approved by a
+// second user before it is executed.
+func (server *Server) needsApproval(amount int64) bool {
+	return server.config.TransferApprovalThreshold > 0 && amount > server.config.TransferApprovalThreshold
+}
+
+// transferApprovalResponse is a transfer approval with the account numbers of
+// both sides in place of their internal IDs.
+type transferApprovalResponse struct {
+	db.TransferApproval
+	FromAccountNumber string `json:"from_account_number"`
+	ToAccountNumber   string `json:"to_account_number"`
+}
+
+func newTransferApprovalResponse(approval db.TransferApproval, accountNumbers map[int64]string) transferApprovalResponse {
+	return transferApprovalResponse{
+		TransferApproval:  approval,
+		FromAccountNumber: accountNumbers[approval.FromAccountID],
+		ToAccountNumber:   accountNumbers[approval.ToAccountID],
+	}
+}
+
+type approveTransferResponse struct {
+	TransferApproval transferApprovalResponse `json:"transfer_approval"`
+	transferTxResponse
+}
+
+// requestTransferApproval parks a validated transfer until it is approved.
+// No money moves until then.
+func (server *Server) requestTransferApproval(ctx *gin.Context, req transferRequest, fromAccount db.Account, toAccount db.Account) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.CreateTransferApprovalParams{
+		Maker:         authPayload.Username,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        req.Amount,
+		Description:   req.Description,
+		Reference:     req.Reference,
+		Category:      req.Category,
+		ExpiresAt:     time.Now().Add(server.config.TransferApprovalTTL),
+	}
+
+	approval, err := server.store.CreateTransferApprovalTx(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := newTransferApprovalResponse(approval, map[int64]string{
+		fromAccount.ID: fromAccount.AccountNumber,
+		toAccount.ID:   toAccount.AccountNumber,
+	})
+	server.recordAudit(ctx, authPayload.Username, audit.ActionTransferApprovalRequested, audit.ResourceTransferApproval,
+		strconv.FormatInt(approval.ID, 10), nil, rsp)
+
+	ctx.JSON(http.StatusAccepted, rsp)
+}
+
+// requestPaymentApproval parks the payment of a payment request until it is
+// approved. The request is marked accepted once the transfer is approved.
+func (server *Server) requestPaymentApproval(ctx *gin.Context, paymentRequest db.PaymentRequest, fromAccount db.Account) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.RequestPaymentApprovalTxParams{
+		PaymentRequestID: paymentRequest.ID,
+		FromAccountID:    fromAccount.ID,
+		Maker:            authPayload.Username,
+		ExpiresAt:        time.Now().Add(server.config.TransferApprovalTTL),
+	}
+
+	approval, err := server.store.RequestPaymentApprovalTx(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	accountNumbers, err := server.accountNumbers(ctx, approval.ToAccountID)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	accountNumbers[fromAccount.ID] = fromAccount.AccountNumber
+
+	rsp := newTransferApprovalResponse(approval, accountNumbers)
+	server.recordAudit(ctx, authPayload.Username, audit.ActionTransferApprovalRequested, audit.ResourceTransferApproval,
+		strconv.FormatInt(approval.ID, 10), nil, rsp)
+
+	ctx.JSON(http.StatusAccepted, rsp)
+}
+
+type listTransferApprovalsRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listTransferApprovals lists the pending transfers the caller may decide on:
+// every pending transfer for bankers, and otherwise the ones on accounts the
+// caller holds as a primary or joint holder.
+func (server *Server) listTransferApprovals(ctx *gin.Context) {
+	var req listTransferApprovalsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	var approvals []db.TransferApproval
+	var err error
+	if server.isBanker(authPayload.Username) {
+		approvals, err = server.store.ListPendingTransferApprovals(ctx, db.ListPendingTransferApprovalsParams{
+			Limit:  req.PageSize,
+			Offset: (req.PageID - 1) * req.PageSize,
+		})
+	} else {
+		approvals, err = server.store.ListPendingTransferApprovalsForHolder(ctx, db.ListPendingTransferApprovalsForHolderParams{
+			Username: authPayload.Username,
+			Limit:    req.PageSize,
+			Offset:   (req.PageID - 1) * req.PageSize,
+		})
+	}
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	accountIDs := make([]int64, 0, 2*len(approvals))
+	for _, approval := range approvals {
+		accountIDs = append(accountIDs, approval.FromAccountID, approval.ToAccountID)
+	}
+	accountNumbers, err := server.accountNumbers(ctx, accountIDs...)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := make([]transferApprovalResponse, len(approvals))
+	for i, approval := range approvals {
+		rsp[i] = newTransferApprovalResponse(approval, accountNumbers)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type transferApprovalURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) approveTransfer(ctx *gin.Context) {
+	var uri transferApprovalURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	approval, valid := server.validTransferApproval(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.DecideTransferTxParams{
+		TransferApprovalID: approval.ID,
+		Checker:            authPayload.Username,
+	}
+
+	result, err := server.store.ApproveTransferTx(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	accountNumbers := map[int64]string{
+		result.FromAccount.ID: result.FromAccount.AccountNumber,
+		result.ToAccount.ID:   result.ToAccount.AccountNumber,
+	}
+	rsp := approveTransferResponse{
+		TransferApproval:   newTransferApprovalResponse(result.TransferApproval, accountNumbers),
+		transferTxResponse: newTransferTxResponse(result.TransferTXResult),
+	}
+	server.recordAudit(ctx, authPayload.Username, audit.ActionTransferApproved, audit.ResourceTransferApproval,
+		strconv.FormatInt(approval.ID, 10), newTransferApprovalResponse(approval, accountNumbers), rsp)
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) rejectTransfer(ctx *gin.Context) {
+	var uri transferApprovalURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	approval, valid := server.validTransferApproval(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.DecideTransferTxParams{
+		TransferApprovalID: approval.ID,
+		Checker:            authPayload.Username,
+	}
+
+	rejected, err := server.store.RejectTransferTx(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	accountNumbers, err := server.accountNumbers(ctx, rejected.FromAccountID, rejected.ToAccountID)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := newTransferApprovalResponse(rejected, accountNumbers)
+	server.recordAudit(ctx, authPayload.Username, audit.ActionTransferRejected, audit.ResourceTransferApproval,
+		strconv.FormatInt(approval.ID, 10), newTransferApprovalResponse(approval, accountNumbers), rsp)
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// validTransferApproval loads a transfer approval and checks that the
+// authenticated user may decide on it: a banker, or a primary or joint holder
+// of the source account other than the user who made the transfer.
+func (server *Server) validTransferApproval(ctx *gin.Context, approvalID int64) (db.TransferApproval, bool) {
+	approval, err := server.store.GetTransferApproval(ctx, approvalID)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return approval, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if approval.Maker == authPayload.Username {
+		err := errors.New("transfers cannot be approved by the user who made them")
+		respondError(ctx, http.StatusForbidden, err)
+		return approval, false
+	}
+
+	if server.isBanker(authPayload.Username) {
+		return approval, true
+	}
+
+	_, valid := server.authorizeAccount(ctx, approval.FromAccountID, db.AccountHolderRolePrimary, db.AccountHolderRoleJoint)
+	return approval, valid
+}
+
+func (server *Server) isBanker(username string) bool {
+	return slices.Contains(server.config.BankerUsernames, username)
+}
@@ -0,0 +1,421 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"simple_bank/audit"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferAboveApprovalThresholdAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	threshold := int64(1000)
+
+	testCases := []struct {
+		name          string
+		amount        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "AboveThreshold",
+			amount: threshold + 1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					CreateTransferApprovalTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateTransferApprovalParams) (db.TransferApproval, error) {
+						require.Equal(t, user1.Username, arg.Maker)
+						require.Equal(t, account1.ID, arg.FromAccountID)
+						require.Equal(t, account2.ID, arg.ToAccountID)
+						require.Equal(t, threshold+1, arg.Amount)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt, time.Minute)
+						return db.TransferApproval{
+							ID:            1,
+							FromAccountID: arg.FromAccountID,
+							ToAccountID:   arg.ToAccountID,
+							Status:        db.TransferApprovalStatusPending,
+						}, nil
+					})
+				expectAudit(store, user1.Username, audit.ActionTransferApprovalRequested)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var approval transferApprovalResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &approval)
+				require.NoError(t, err)
+				require.Equal(t, db.TransferApprovalStatusPending, approval.Status)
+				require.Equal(t, account1.AccountNumber, approval.FromAccountNumber)
+				require.Equal(t, account2.AccountNumber, approval.ToAccountNumber)
+			},
+		},
+		{
+			name:   "AtThreshold",
+			amount: threshold,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CreateTransferApprovalTx(gomock.Any(), gomock.Any()).Times(0)
+				expectAudit(store, user1.Username, audit.ActionTransferCreated)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAccountOwners(store, account1, account2)
+
+			server := NewTestServer(t, store)
+			server.config.TransferApprovalThreshold = threshold
+			server.config.TransferApprovalTTL = time.Hour
+			recorder := httptest.NewRecorder()
+
+			body := gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          tc.amount,
+				"currency":        util.USD,
+			}
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestAcceptPaymentRequestAboveApprovalThresholdAPI(t *testing.T) {
+	requester, _ := randomUser(t)
+	payer, _ := randomUser(t)
+
+	toAccount := randomAccount(requester.Username)
+	fromAccount := randomAccount(payer.Username)
+	toAccount.Currency = util.USD
+	fromAccount.Currency = util.USD
+
+	threshold := int64(1000)
+	paymentRequest := db.PaymentRequest{
+		ID:          util.RandomInt(1, 1000),
+		Requester:   requester.Username,
+		Payer:       payer.Username,
+		ToAccountID: toAccount.ID,
+		Amount:      threshold + 1,
+		Currency:    util.USD,
+		Status:      db.PaymentRequestStatusPending,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "ParkedForApproval",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(paymentRequest.ID)).Times(1).Return(paymentRequest, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().AcceptPaymentRequestTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					RequestPaymentApprovalTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RequestPaymentApprovalTxParams) (db.TransferApproval, error) {
+						require.Equal(t, paymentRequest.ID, arg.PaymentRequestID)
+						require.Equal(t, fromAccount.ID, arg.FromAccountID)
+						require.Equal(t, payer.Username, arg.Maker)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt, time.Minute)
+						return db.TransferApproval{
+							ID:            1,
+							FromAccountID: arg.FromAccountID,
+							ToAccountID:   paymentRequest.ToAccountID,
+							Amount:        paymentRequest.Amount,
+							Status:        db.TransferApprovalStatusPending,
+						}, nil
+					})
+				expectAccountNumbers(store, toAccount)
+				expectAudit(store, payer.Username, audit.ActionTransferApprovalRequested)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var approval transferApprovalResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &approval)
+				require.NoError(t, err)
+				require.Equal(t, db.TransferApprovalStatusPending, approval.Status)
+				require.Equal(t, fromAccount.AccountNumber, approval.FromAccountNumber)
+				require.Equal(t, toAccount.AccountNumber, approval.ToAccountNumber)
+			},
+		},
+		{
+			name: "AlreadyWaitingForApproval",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentRequest(gomock.Any(), gomock.Eq(paymentRequest.ID)).Times(1).Return(paymentRequest, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().
+					RequestPaymentApprovalTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferApproval{}, db.ErrPaymentRequestAwaitingApproval)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAccountOwners(store, toAccount, fromAccount)
+
+			server := NewTestServer(t, store)
+			server.config.TransferApprovalThreshold = threshold
+			server.config.TransferApprovalTTL = time.Hour
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"from_account_id": fromAccount.ID})
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/payment-requests/%d/accept", paymentRequest.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, payer.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDecideTransferApprovalAPI(t *testing.T) {
+	maker, _ := randomUser(t)
+	coHolder, _ := randomUser(t)
+	viewer, _ := randomUser(t)
+	banker, _ := randomUser(t)
+
+	account := randomAccount(maker.Username)
+	toAccount := randomAccount(util.RandomOwner())
+	approval := db.TransferApproval{
+		ID:            util.RandomInt(1, 1000),
+		Maker:         maker.Username,
+		FromAccountID: account.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        util.RandomMoney(),
+		Status:        db.TransferApprovalStatusPending,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+
+	testCases := []struct {
+		name          string
+		action        string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "JointHolderApproves",
+			action:   "approve",
+			username: coHolder.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferApproval(gomock.Any(), gomock.Eq(approval.ID)).Times(1).Return(approval, nil)
+				stubAccountHolder(store, account, coHolder.Username, db.AccountHolderRoleJoint)
+
+				arg := db.DecideTransferTxParams{
+					TransferApprovalID: approval.ID,
+					Checker:            coHolder.Username,
+				}
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+				expectAudit(store, coHolder.Username, audit.ActionTransferApproved)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "BankerApproves",
+			action:   "approve",
+			username: banker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferApproval(gomock.Any(), gomock.Eq(approval.ID)).Times(1).Return(approval, nil)
+				store.EXPECT().GetAccountHolder(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(1)
+				expectAudit(store, banker.Username, audit.ActionTransferApproved)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "MakerCannotApprove",
+			action:   "approve",
+			username: maker.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferApproval(gomock.Any(), gomock.Eq(approval.ID)).Times(1).Return(approval, nil)
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "ViewOnlyHolderCannotApprove",
+			action:   "approve",
+			username: viewer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferApproval(gomock.Any(), gomock.Eq(approval.ID)).Times(1).Return(approval, nil)
+				stubAccountHolder(store, account, viewer.Username, db.AccountHolderRoleViewOnly)
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "ApproveExpired",
+			action:   "approve",
+			username: coHolder.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferApproval(gomock.Any(), gomock.Eq(approval.ID)).Times(1).Return(approval, nil)
+				stubAccountHolder(store, account, coHolder.Username, db.AccountHolderRoleJoint)
+				store.EXPECT().
+					ApproveTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApproveTransferTxResult{}, db.ErrTransferApprovalExpired)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "JointHolderRejects",
+			action:   "reject",
+			username: coHolder.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferApproval(gomock.Any(), gomock.Eq(approval.ID)).Times(1).Return(approval, nil)
+				stubAccountHolder(store, account, coHolder.Username, db.AccountHolderRoleJoint)
+
+				rejected := approval
+				rejected.Status = db.TransferApprovalStatusRejected
+				rejected.Checker = coHolder.Username
+
+				arg := db.DecideTransferTxParams{
+					TransferApprovalID: approval.ID,
+					Checker:            coHolder.Username,
+				}
+				store.EXPECT().RejectTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(rejected, nil)
+				expectAccountNumbers(store, account, toAccount)
+				expectAudit(store, coHolder.Username, audit.ActionTransferRejected)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rejected transferApprovalResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rejected)
+				require.NoError(t, err)
+				require.Equal(t, db.TransferApprovalStatusRejected, rejected.Status)
+				require.Equal(t, account.AccountNumber, rejected.FromAccountNumber)
+				require.Equal(t, toAccount.AccountNumber, rejected.ToAccountNumber)
+				require.NotContains(t, recorder.Body.String(), "account_id")
+			},
+		},
+		{
+			name:     "RejectNotPending",
+			action:   "reject",
+			username: coHolder.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransferApproval(gomock.Any(), gomock.Eq(approval.ID)).Times(1).Return(approval, nil)
+				stubAccountHolder(store, account, coHolder.Username, db.AccountHolderRoleJoint)
+				store.EXPECT().
+					RejectTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferApproval{}, db.ErrTransferApprovalNotPending)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			action:   "approve",
+			username: coHolder.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransferApproval(gomock.Any(), gomock.Eq(approval.ID)).
+					Times(1).
+					Return(db.TransferApproval{}, db.ErrRecordNotFound)
+				store.EXPECT().ApproveTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAccountOwners(store, account)
+
+			server := NewTestServer(t, store)
+			server.config.BankerUsernames = []string{banker.Username}
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfer-approvals/%d/%s", approval.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/vi"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	viTranslations "github.com/go-playground/validator/v10/translations/vi"
+	"golang.org/x/text/language"
+)
+
+const translatorKey = "translator"
+
+// typeMismatchKey is the translation of a JSON value of the wrong type, which
+// fails before validation runs.
+const typeMismatchKey = "type_mismatch"
+
+// customTranslations are the messages of our own validation tags, and of the
+// built-in tags the validator does not translate, by locale.
+var customTranslations = map[string]map[string]string{
+	"en": {
+		"currency":       "{0} must be a supported currency",
+		"memo":           "{0} contains characters that are not allowed",
+		"reference":      "{0} may only contain letters, digits, spaces and / - ? : ( ) . , ' +",
+		"category":       "{0} must be a lowercase slug such as groceries",
+		"account_number": "{0} must be a valid account number",
+		"account_ref":    "{0} must be an account ID or account number",
+		"http_url":       "{0} must be an HTTP or HTTPS URL",
+		typeMismatchKey:  "{0} must be of type {1}",
+	},
+	"vi": {
+		"currency":             "{0} phải là loại tiền tệ được hỗ trợ",
+		"memo":                 "{0} chứa ký tự không được phép",
+		"reference":            "{0} chỉ được chứa chữ cái, chữ số, khoảng trắng và / - ? : ( ) . , ' +",
+		"category":             "{0} phải là chuỗi chữ thường, ví dụ groceries",
+		"account_number":       "{0} phải là số tài khoản hợp lệ",
+		"account_ref":          "{0} phải là mã tài khoản hoặc số tài khoản",
+		"http_url":             "{0} phải là URL HTTP hoặc HTTPS",
+		"required_without":     "{0} không được bỏ trống",
+		"required_without_all": "{0} không được bỏ trống",
+		"excluded_with":        "{0} không được dùng cùng với {1}",
+		typeMismatchKey:        "{0} phải có kiểu {1}",
+	},
+}
+
+// newTranslator returns the translator of validation messages, English
+// being the fallback, and registers its translations with v.
+func newTranslator(v *validator.Validate) (*ut.UniversalTranslator, error) {
+	translator := ut.New(en.New(), en.New(), vi.New())
+
+	enTrans, _ := translator.GetTranslator("en")
+	if err := enTranslations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return nil, fmt.Errorf("cannot register English translations: %w", err)
+	}
+	viTrans, _ := translator.GetTranslator("vi")
+	if err := viTranslations.RegisterDefaultTranslations(v, viTrans); err != nil {
+		return nil, fmt.Errorf("cannot register Vietnamese translations: %w", err)
+	}
+
+	for locale, messages := range customTranslations {
+		trans, _ := translator.GetTranslator(locale)
+		for tag, message := range messages {
+			if err := registerTranslation(v, trans, tag, message); err != nil {
+				return nil, fmt.Errorf("cannot register %s translation of %s: %w", locale, tag, err)
+			}
+		}
+	}
+
+	return translator, nil
+}
+
+func registerTranslation(v *validator.Validate, trans ut.Translator, tag string, message string) error {
+	register := func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}
+	translate := func(trans ut.Translator, fe validator.FieldError) string {
+		message, err := trans.T(tag, fe.Field(), fe.Param())
+		if err != nil {
+			return fe.Error()
+		}
+		return message
+	}
+
+	return v.RegisterTranslation(tag, trans, register, translate)
+}
+
+// fieldName names a field in validation errors after the request key it is
+// bound to, e.g. from_account_id rather than FromAccountID.
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// localeMiddleware picks the translator of the request from its
+// Accept-Language header.
+func localeMiddleware(translator *ut.UniversalTranslator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(translatorKey, findTranslator(translator, ctx.GetHeader("Accept-Language")))
+		ctx.Next()
+	}
+}
+
+// findTranslator returns the translator of the most preferred language in
+// acceptLanguage that we support, or the fallback if there is none.
+func findTranslator(translator *ut.UniversalTranslator, acceptLanguage string) ut.Translator {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return translator.GetFallback()
+	}
+
+	locales := make([]string, len(tags))
+	for i, tag := range tags {
+		base, _ := tag.Base()
+		locales[i] = base.String()
+	}
+
+	trans, _ := translator.FindTranslator(locales...)
+	return trans
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simple_bank/db/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindTranslator(t *testing.T) {
+	server := NewTestServer(t, nil)
+
+	testCases := []struct {
+		name           string
+		acceptLanguage string
+		expectedLocale string
+	}{
+		{name: "NoHeader", acceptLanguage: "", expectedLocale: "en"},
+		{name: "Vietnamese", acceptLanguage: "vi", expectedLocale: "vi"},
+		{name: "Region", acceptLanguage: "vi-VN,vi;q=0.9", expectedLocale: "vi"},
+		{name: "Preference", acceptLanguage: "fr;q=0.9,vi;q=0.8,en;q=0.7", expectedLocale: "vi"},
+		{name: "Weight", acceptLanguage: "en;q=0.5,vi;q=0.8", expectedLocale: "vi"},
+		{name: "Unsupported", acceptLanguage: "de-DE", expectedLocale: "en"},
+		{name: "Malformed", acceptLanguage: ";;;", expectedLocale: "en"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			trans := findTranslator(server.translator, tc.acceptLanguage)
+			require.Equal(t, tc.expectedLocale, trans.Locale())
+		})
+	}
+}
+
+func TestValidationTranslations(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name           string
+		body           string
+		acceptLanguage string
+		expectedFields []fieldError
+	}{
+		{
+			name:           "English",
+			body:           `{"amount": 10, "currency": "XYZ", "from_account_id": 1, "to_account_id": 2}`,
+			acceptLanguage: "en-US",
+			expectedFields: []fieldError{{Field: "currency", Message: "currency must be a supported currency"}},
+		},
+		{
+			name:           "Vietnamese",
+			body:           `{"amount": 10, "currency": "XYZ", "from_account_id": 1, "to_account_id": 2}`,
+			acceptLanguage: "vi-VN",
+			expectedFields: []fieldError{{Field: "currency", Message: "currency phải là loại tiền tệ được hỗ trợ"}},
+		},
+		{
+			name:           "VietnameseBuiltInRule",
+			body:           `{"currency": "USD", "from_account_id": 1, "to_account_id": 2}`,
+			acceptLanguage: "vi",
+			expectedFields: []fieldError{{Field: "amount", Message: "amount không được bỏ trống"}},
+		},
+		{
+			name:           "TypeMismatch",
+			body:           `{"amount": "ten", "currency": "USD", "from_account_id": 1, "to_account_id": 2}`,
+			acceptLanguage: "en",
+			expectedFields: []fieldError{{Field: "amount", Message: "amount must be of type int64"}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			request.Header.Set("Accept-Language", tc.acceptLanguage)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+			apiErr := requireErrorCode(t, recorder, "validation_failed")
+			require.Equal(t, tc.expectedFields, apiErr.Fields)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"simple_bank/util"
+	"strconv"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -13,3 +14,48 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 
 	return false
 }
+
+var validMemo validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if memo, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.MemoPattern.MatchString(memo)
+	}
+
+	return false
+}
+
+var validReference validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if reference, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.ReferencePattern.MatchString(reference)
+	}
+
+	return false
+}
+
+var validCategory validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if category, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.CategoryPattern.MatchString(category)
+	}
+
+	return false
+}
+
+var validAccountNumber validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if accountNumber, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsValidAccountNumber(accountNumber)
+	}
+
+	return false
+}
+
+// validAccountRef accepts either a positive internal account ID or a valid
+// account number.
+var validAccountRef validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if ref, ok := fieldLevel.Field().Interface().(string); ok {
+		if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+			return id > 0
+		}
+		return util.IsValidAccountNumber(ref)
+	}
+
+	return false
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simple_bank/db/sqlc"
+	"simple_bank/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+type createWebhookRequest struct {
+	Url    string `json:"url" binding:"required,http_url"`
+	Secret string `json:"secret" binding:"required,min=16,max=256"`
+}
+
+func (server *Server) createWebhook(ctx *gin.Context) {
+	var req createWebhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !server.requireBanker(ctx) {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.CreateWebhookParams{
+		Owner:  authPayload.Username,
+		Url:    req.Url,
+		Secret: req.Secret,
+	}
+
+	webhook, err := server.store.CreateWebhook(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhook)
+}
+
+type listWebhooksRequest struct {
+	PageID   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listWebhooks(ctx *gin.Context) {
+	var req listWebhooksRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !server.requireBanker(ctx) {
+		return
+	}
+
+	arg := db.ListWebhooksParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	webhooks, err := server.store.ListWebhooks(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, webhooks)
+}
+
+type deleteWebhookRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) deleteWebhook(ctx *gin.Context) {
+	var req deleteWebhookRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !server.requireBanker(ctx) {
+		return
+	}
+
+	err := server.store.DeleteWebhook(ctx, req.ID)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{})
+}
+
+type listWebhookDeliveriesRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending delivered dead"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listWebhookDeliveries lists deliveries by status. Without a status it lists
+// the dead-lettered ones, which are the ones that need attention.
+func (server *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var req listWebhookDeliveriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !server.requireBanker(ctx) {
+		return
+	}
+
+	status := req.Status
+	if status == "" {
+		status = db.WebhookDeliveryStatusDead
+	}
+
+	arg := db.ListWebhookDeliveriesParams{
+		Status: status,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+
+	deliveries, err := server.store.ListWebhookDeliveries(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+type replayWebhookDeliveryRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// replayWebhookDelivery queues a delivery to be sent again on the next
+// dispatch, with a fresh set of attempts.
+func (server *Server) replayWebhookDelivery(ctx *gin.Context) {
+	var req replayWebhookDeliveryRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !server.requireBanker(ctx) {
+		return
+	}
+
+	delivery, err := server.store.ReplayWebhookDelivery(ctx, req.ID)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delivery)
+}
+
+// requireBanker rejects the request unless the authenticated user is a banker.
+func (server *Server) requireBanker(ctx *gin.Context) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.isBanker(authPayload.Username) {
+		err := errors.New("only bankers can manage webhooks")
+		respondError(ctx, http.StatusForbidden, err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,114 @@
+// Package audit records the security- and money-relevant actions of users in
+// the append-only audit log.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	db "simple_bank/db/sqlc"
+	"simple_bank/logger"
+	"simple_bank/metrics"
+	"time"
+)
+
+// The actions recorded in the audit log.
+const (
+	ActionLoggedIn                  = "user.logged_in"
+	ActionLoginFailed               = "user.login_failed"
+	ActionAccountCreated            = "account.created"
+	ActionAccountClosed             = "account.closed"
+	ActionBalanceAdjusted           = "account.balance_adjusted"
+	ActionAccountHolderInvited      = "account_holder.invited"
+	ActionAccountHolderAccepted     = "account_holder.accepted"
+	ActionAccountHolderRemoved      = "account_holder.removed"
+	ActionTransferCreated           = "transfer.created"
+	ActionTransferApprovalRequested = "transfer_approval.requested"
+	ActionTransferApproved          = "transfer_approval.approved"
+	ActionTransferRejected          = "transfer_approval.rejected"
+	ActionPaymentRequestAccepted    = "payment_request.accepted"
+)
+
+// The types of the resources acted on. Accounts are identified by their
+// account number, account holders by the account number and the username
+// separated by a slash, and the other resources by their ID.
+const (
+	ResourceUser             = "user"
+	ResourceAccount          = "account"
+	ResourceAccountHolder    = "account_holder"
+	ResourceTransfer         = "transfer"
+	ResourceTransferApproval = "transfer_approval"
+	ResourcePaymentRequest   = "payment_request"
+)
+
+// writeTimeout bounds how long writing an entry may take.
+const writeTimeout = 5 * time.Second
+
+// Entry describes an action to record.
+type Entry struct {
+	// Actor is the username of the user who acted.
+	Actor        string
+	Action       string
+	ResourceType string
+	ResourceID   string
+	ClientIP     string
+	UserAgent    string
+	RequestID    string
+	// Before and After are the resource before and after the action, which
+	// are stored as JSON. A nil value is stored as null.
+	Before any
+	After  any
+}
+
+// Record writes entry to the audit log using store.
+//
+// Actions are recorded once they have been committed, so a failure to record
+// one cannot undo it: the failure is logged and counted rather than returned.
+// For the same reason the entry is written even if ctx has been cancelled,
+// e.g. because the client went away as the action committed.
+func Record(ctx context.Context, store db.Querier, entry Entry) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+	defer cancel()
+
+	err := record(ctx, store, entry)
+	if err != nil {
+		metrics.AuditLogFailures.Inc()
+		logger.FromContext(ctx).ErrorContext(ctx, "cannot record audit log entry",
+			"action", entry.Action,
+			"resource_type", entry.ResourceType,
+			"resource_id", entry.ResourceID,
+			"actor", entry.Actor,
+			"error", err,
+		)
+	}
+}
+
+func record(ctx context.Context, store db.Querier, entry Entry) error {
+	before, err := marshal(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshal(entry.After)
+	if err != nil {
+		return err
+	}
+
+	_, err = store.CreateAuditLog(ctx, db.CreateAuditLogParams{
+		Actor:        entry.Actor,
+		Action:       entry.Action,
+		ResourceType: entry.ResourceType,
+		ResourceID:   entry.ResourceID,
+		ClientIp:     entry.ClientIP,
+		UserAgent:    entry.UserAgent,
+		RequestID:    entry.RequestID,
+		Before:       before,
+		After:        after,
+	})
+	return err
+}
+
+func marshal(value any) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
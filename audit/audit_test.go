@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"simple_bank/metrics"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	account := db.Account{Owner: "alice", Balance: 100, Currency: "EUR", AccountNumber: "SB00000000000000000001"}
+	entry := Entry{
+		Actor:        "alice",
+		Action:       ActionAccountCreated,
+		ResourceType: ResourceAccount,
+		ResourceID:   account.AccountNumber,
+		ClientIP:     "192.0.2.1",
+		UserAgent:    "curl/8.0",
+		RequestID:    "req-1",
+		After:        account,
+	}
+
+	after, err := json.Marshal(account)
+	require.NoError(t, err)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateAuditLog(gomock.Any(), gomock.Eq(db.CreateAuditLogParams{
+			Actor:        entry.Actor,
+			Action:       entry.Action,
+			ResourceType: entry.ResourceType,
+			ResourceID:   entry.ResourceID,
+			ClientIp:     entry.ClientIP,
+			UserAgent:    entry.UserAgent,
+			RequestID:    entry.RequestID,
+			After:        after,
+		})).
+		DoAndReturn(func(ctx context.Context, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+			// the entry of an action is recorded even if the client has gone
+			require.NoError(t, ctx.Err())
+			return db.AuditLog{}, nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	failures := testutil.ToFloat64(metrics.AuditLogFailures)
+	Record(ctx, store, entry)
+	require.Equal(t, failures, testutil.ToFloat64(metrics.AuditLogFailures))
+}
+
+func TestRecordFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateAuditLog(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.AuditLog{}, errors.New("connection refused"))
+
+	failures := testutil.ToFloat64(metrics.AuditLogFailures)
+	Record(context.Background(), store, Entry{Actor: "alice", Action: ActionLoginFailed})
+	require.Equal(t, failures+1, testutil.ToFloat64(metrics.AuditLogFailures))
+}
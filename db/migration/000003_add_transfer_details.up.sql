@@ -0,0 +1,13 @@
+ALTER TABLE "transfers" ADD COLUMN "description" varchar(140) NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "reference" varchar(35) NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "category" varchar(32) NOT NULL DEFAULT '';
+
+CREATE INDEX ON "transfers" ("reference");
+
+CREATE INDEX ON "transfers" ("category");
+
+COMMENT ON COLUMN "transfers"."description" IS 'free-text memo shown on statements';
+
+COMMENT ON COLUMN "transfers"."reference" IS 'end-to-end reference supplied by the payer';
@@ -0,0 +1,20 @@
+CREATE TABLE "payees" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "nickname" varchar(50) NOT NULL,
+  "account_id" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "payees" ("owner");
+
+COMMENT ON COLUMN "payees"."created_at" IS 'start of the cooling-off period for large transfers';
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "payees" ADD CONSTRAINT "owner_nickname_key" UNIQUE ("owner", "nickname");
+
+ALTER TABLE "payees" ADD CONSTRAINT "owner_account_key" UNIQUE ("owner", "account_id");
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "account" DROP CONSTRAINT IF EXISTS "account_number_key";
+
+ALTER TABLE IF EXISTS "account" DROP COLUMN IF EXISTS "account_number";
@@ -0,0 +1,17 @@
+ALTER TABLE "account" ADD COLUMN "account_number" varchar;
+
+-- backfill existing accounts with random numbers and ISO 7064 mod-97 check
+-- digits computed over BBAN || 'SB' (S=28, B=11) || '00'
+UPDATE "account"
+SET "account_number" = 'SB' || lpad((98 - ((numbers.bban || '281100')::numeric % 97))::text, 2, '0') || numbers.bban
+FROM (
+  SELECT "id", lpad(floor(random() * 1000000000000)::bigint::text, 12, '0') AS bban
+  FROM "account"
+) AS numbers
+WHERE "account"."id" = numbers."id";
+
+ALTER TABLE "account" ALTER COLUMN "account_number" SET NOT NULL;
+
+ALTER TABLE "account" ADD CONSTRAINT "account_number_key" UNIQUE ("account_number");
+
+COMMENT ON COLUMN "account"."account_number" IS 'externally visible identifier with mod-97 check digits';
@@ -0,0 +1,21 @@
+CREATE TABLE "account_holders" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+CREATE INDEX ON "account_holders" ("username");
+
+COMMENT ON COLUMN "account_holders"."role" IS 'primary, joint or view_only';
+
+ALTER TABLE "account_holders" ADD FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_holders" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_holders" ADD CONSTRAINT "account_holders_role_check" CHECK ("role" IN ('primary', 'joint', 'view_only'));
+
+-- every existing account is held by its owner
+INSERT INTO "account_holders" ("account_id", "username", "role")
+SELECT "id", "owner", 'primary' FROM "account";
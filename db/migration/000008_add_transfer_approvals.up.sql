@@ -0,0 +1,46 @@
+CREATE TABLE "transfer_approvals" (
+  "id" bigserial PRIMARY KEY,
+  "maker" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "description" varchar(140) NOT NULL DEFAULT '',
+  "reference" varchar(35) NOT NULL DEFAULT '',
+  "category" varchar(32) NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'pending_approval',
+  "checker" varchar NOT NULL DEFAULT '',
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "decided_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "transfer_approvals_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "transfer_approvals_status_check" CHECK ("status" IN ('pending_approval', 'approved', 'rejected', 'expired'))
+);
+
+CREATE TABLE "notifications" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "kind" varchar NOT NULL,
+  "message" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "transfer_approvals" ("from_account_id", "status");
+
+CREATE INDEX ON "transfer_approvals" ("expires_at") WHERE "status" = 'pending_approval';
+
+CREATE INDEX ON "notifications" ("username");
+
+COMMENT ON COLUMN "transfer_approvals"."checker" IS 'user who approved or rejected the transfer';
+
+COMMENT ON COLUMN "transfer_approvals"."transfer_id" IS 'set once the transfer is approved and executed';
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("maker") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("from_account_id") REFERENCES "account" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("to_account_id") REFERENCES "account" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "notifications" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS "outbox_events_notify_account_event" ON "outbox_events";
+DROP FUNCTION IF EXISTS notify_account_event();
+DROP INDEX IF EXISTS "outbox_events_aggregate_id_id_idx";
@@ -0,0 +1,19 @@
+CREATE INDEX ON "outbox_events" ("aggregate_id", "id") WHERE "event_type" = 'account.balance_changed';
+
+-- Notifications are only delivered once the inserting transaction commits, so
+-- listeners never see an event for a transfer that was rolled back.
+CREATE FUNCTION notify_account_event() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify(
+    'account_events',
+    json_build_object('event_id', NEW.id, 'account_id', NEW.aggregate_id)::text
+  );
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "outbox_events_notify_account_event"
+AFTER INSERT ON "outbox_events"
+FOR EACH ROW
+WHEN (NEW.event_type = 'account.balance_changed')
+EXECUTE FUNCTION notify_account_event();
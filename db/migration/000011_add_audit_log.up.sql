@@ -0,0 +1,43 @@
+CREATE TABLE "audit_log" (
+  "id" bigserial PRIMARY KEY,
+  "actor" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "resource_type" varchar NOT NULL,
+  "resource_id" varchar NOT NULL,
+  "client_ip" varchar NOT NULL DEFAULT '',
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "request_id" varchar NOT NULL DEFAULT '',
+  "before" jsonb,
+  "after" jsonb,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_log" ("actor", "created_at");
+
+CREATE INDEX ON "audit_log" ("resource_type", "resource_id", "created_at");
+
+CREATE INDEX ON "audit_log" ("created_at");
+
+COMMENT ON COLUMN "audit_log"."actor" IS 'username of the user who acted, which need not exist for failed logins';
+
+COMMENT ON COLUMN "audit_log"."before" IS 'the resource before the action, null if the action created it';
+
+COMMENT ON COLUMN "audit_log"."after" IS 'the resource after the action, null if the action deleted it';
+
+-- The audit log is append-only: rows can be inserted but never changed, even
+-- by a user with write access to the table.
+CREATE FUNCTION reject_audit_log_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_log_append_only"
+BEFORE UPDATE OR DELETE ON "audit_log"
+FOR EACH ROW
+EXECUTE FUNCTION reject_audit_log_change();
+
+CREATE TRIGGER "audit_log_no_truncate"
+BEFORE TRUNCATE ON "audit_log"
+FOR EACH STATEMENT
+EXECUTE FUNCTION reject_audit_log_change();
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "ledger_checkpoints";
+
+DROP FUNCTION IF EXISTS reject_ledger_checkpoint_change();
+
+DROP INDEX IF EXISTS "entries_account_id_id_idx";
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "hash";
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "prev_hash";
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "transfer_id";
@@ -0,0 +1,45 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint REFERENCES "transfers" ("id");
+
+ALTER TABLE "entries" ADD COLUMN "prev_hash" bytea;
+
+ALTER TABLE "entries" ADD COLUMN "hash" bytea;
+
+CREATE INDEX ON "entries" ("account_id", "id");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'the transfer that wrote the entry';
+
+COMMENT ON COLUMN "entries"."prev_hash" IS 'hash of the previous entry of the account, null for the first entry of its chain';
+
+COMMENT ON COLUMN "entries"."hash" IS 'SHA-256 of prev_hash, the entry and its transfer; null for entries written before the chain began';
+
+CREATE TABLE "ledger_checkpoints" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "entry_id" bigint NOT NULL,
+  "hash" bytea NOT NULL,
+  "signature" bytea NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "ledger_checkpoints" ("account_id", "entry_id");
+
+COMMENT ON COLUMN "ledger_checkpoints"."hash" IS 'hash of the entry, the head of the chain of the account when the checkpoint was taken';
+
+COMMENT ON COLUMN "ledger_checkpoints"."signature" IS 'HMAC-SHA256 of the account, the entry and its hash';
+
+-- Checkpoints are append-only like the audit log.
+CREATE FUNCTION reject_ledger_checkpoint_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'ledger_checkpoints is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "ledger_checkpoints_append_only"
+BEFORE UPDATE OR DELETE ON "ledger_checkpoints"
+FOR EACH ROW
+EXECUTE FUNCTION reject_ledger_checkpoint_change();
+
+CREATE TRIGGER "ledger_checkpoints_no_truncate"
+BEFORE TRUNCATE ON "ledger_checkpoints"
+FOR EACH STATEMENT
+EXECUTE FUNCTION reject_ledger_checkpoint_change();
@@ -0,0 +1,4 @@
+-- pending invitees would become holders without the status column
+DELETE FROM "account_holders" WHERE "status" = 'pending';
+
+ALTER TABLE IF EXISTS "account_holders" DROP COLUMN IF EXISTS "status";
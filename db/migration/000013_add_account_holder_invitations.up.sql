@@ -0,0 +1,5 @@
+ALTER TABLE "account_holders" ADD COLUMN "status" varchar NOT NULL DEFAULT 'accepted';
+
+COMMENT ON COLUMN "account_holders"."status" IS 'pending until the invited user accepts, then accepted';
+
+ALTER TABLE "account_holders" ADD CONSTRAINT "account_holders_status_check" CHECK ("status" IN ('pending', 'accepted'));
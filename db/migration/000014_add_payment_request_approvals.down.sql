@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "transfer_approvals" DROP COLUMN IF EXISTS "payment_request_id";
@@ -0,0 +1,7 @@
+ALTER TABLE "transfer_approvals" ADD COLUMN "payment_request_id" bigint;
+
+COMMENT ON COLUMN "transfer_approvals"."payment_request_id" IS 'set when the transfer pays a payment request';
+
+ALTER TABLE "transfer_approvals" ADD FOREIGN KEY ("payment_request_id") REFERENCES "payment_requests" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX ON "transfer_approvals" ("payment_request_id") WHERE "status" = 'pending_approval';
@@ -0,0 +1,205 @@
+// Package migration embeds the SQL migrations of the database schema and
+// applies them with golang-migrate, so that the server binary can migrate
+// the database it runs against without an external tool.
+package migration
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"simple_bank/util"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	sqlitemigrate "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// The Postgres migrations are at the top level and the equivalent SQLite
+// migrations, with the same versions, are in sqlite/.
+//
+//go:embed *.sql sqlite/*.sql
+var files embed.FS
+
+// lockTimeout is how long a migration waits for the advisory lock held by
+// another replica that is migrating the same database.
+const lockTimeout = 5 * time.Minute
+
+// Migrator applies the embedded migrations to a database. On Postgres every
+// operation holds an advisory lock for its duration, so replicas that start
+// at the same time migrate the database one after another: the first
+// applies the pending migrations and the others find nothing left to do.
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+// New connects to the database at dbSource using dbDriver, which is one of
+// util.DBDriverPostgres or util.DBDriverSQLite.
+func New(dbDriver string, dbSource string) (*Migrator, error) {
+	driverName, driver, err := openDriver(dbDriver, dbSource)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := iofs.New(files, sourceDir(dbDriver))
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("cannot read embedded migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, driverName, driver)
+	if err != nil {
+		source.Close()
+		driver.Close()
+		return nil, fmt.Errorf("cannot create migrator: %w", err)
+	}
+	m.LockTimeout = lockTimeout
+	m.Log = migrateLogger{}
+
+	return &Migrator{m: m}, nil
+}
+
+// openDriver connects the golang-migrate driver of dbDriver to dbSource and
+// returns it with its name.
+func openDriver(dbDriver string, dbSource string) (string, database.Driver, error) {
+	var sqlDriver, driverName string
+	var withInstance func(*sql.DB) (database.Driver, error)
+	switch dbDriver {
+	case util.DBDriverPostgres:
+		sqlDriver, driverName = "pgx", "pgx5"
+		withInstance = func(db *sql.DB) (database.Driver, error) {
+			return pgxmigrate.WithInstance(db, &pgxmigrate.Config{})
+		}
+	case util.DBDriverSQLite:
+		sqlDriver, driverName = "sqlite", "sqlite"
+		withInstance = func(db *sql.DB) (database.Driver, error) {
+			return sqlitemigrate.WithInstance(db, &sqlitemigrate.Config{})
+		}
+		// foreign keys stay off while migrating, as SQLite requires for
+		// rebuilding a table that other tables reference
+		dbSource = appendSQLiteParams(dbSource, "_pragma=foreign_keys(0)&_pragma=busy_timeout(5000)")
+	default:
+		return "", nil, fmt.Errorf("unsupported db driver %q", dbDriver)
+	}
+
+	db, err := sql.Open(sqlDriver, dbSource)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot open database: %w", err)
+	}
+
+	driver, err := withInstance(db)
+	if err != nil {
+		db.Close()
+		return "", nil, fmt.Errorf("cannot create migration driver: %w", err)
+	}
+
+	return driverName, driver, nil
+}
+
+// sourceDir is the directory of the embedded migrations of dbDriver.
+func sourceDir(dbDriver string) string {
+	if dbDriver == util.DBDriverSQLite {
+		return "sqlite"
+	}
+	return "."
+}
+
+// appendSQLiteParams adds connection parameters of the sqlite driver to a
+// database path.
+func appendSQLiteParams(dbSource string, params string) string {
+	if strings.Contains(dbSource, "?") {
+		return dbSource + "&" + params
+	}
+	return dbSource + "?" + params
+}
+
+// Up applies the next n migrations, or all pending migrations if n is 0.
+// It is not an error for the database to be up to date already.
+func (migrator *Migrator) Up(n int) error {
+	var err error
+	if n == 0 {
+		err = migrator.m.Up()
+	} else {
+		err = migrator.m.Steps(n)
+	}
+	return ignoreNoChange(err)
+}
+
+// Down rolls back the last n migrations.
+func (migrator *Migrator) Down(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("cannot roll back %d migrations", n)
+	}
+	return ignoreNoChange(migrator.m.Steps(-n))
+}
+
+// Goto migrates up or down to the given version.
+func (migrator *Migrator) Goto(version uint) error {
+	return ignoreNoChange(migrator.m.Migrate(version))
+}
+
+// Status returns the version the database is at, 0 if no migration has been
+// applied, and whether the last migration failed part-way.
+func (migrator *Migrator) Status() (version uint, dirty bool, err error) {
+	version, dirty, err = migrator.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return
+}
+
+// Close releases the database connection.
+func (migrator *Migrator) Close() error {
+	sourceErr, dbErr := migrator.m.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+// LatestVersion returns the version of the newest embedded migration. The
+// migrations of every driver share their versions.
+func LatestVersion() (uint, error) {
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+
+	version, err := source.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := source.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// migrateLogger reports the progress of golang-migrate through slog.
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...any) {
+	slog.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (migrateLogger) Verbose() bool {
+	return false
+}
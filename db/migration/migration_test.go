@@ -0,0 +1,210 @@
+package migration
+
+import (
+	"database/sql"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"simple_bank/util"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testDBSource(t *testing.T) string {
+	config, err := util.LoadConfig("../..")
+	require.NoError(t, err)
+
+	if testDBSource := os.Getenv("TEST_DB_SOURCE"); testDBSource != "" {
+		config.DBSource = testDBSource
+	}
+	return config.DBSource
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	names, err := fs.Glob(files, "*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, names)
+
+	for _, dbDriver := range []string{util.DBDriverPostgres, util.DBDriverSQLite} {
+		// every migration can be rolled back, and every driver has the same
+		// migrations
+		driverNames, err := fs.Glob(files, path.Join(sourceDir(dbDriver), "*.sql"))
+		require.NoError(t, err)
+		for i := range driverNames {
+			driverNames[i] = path.Base(driverNames[i])
+		}
+		require.Equal(t, names, driverNames, dbDriver)
+	}
+
+	for _, name := range names {
+		if up, ok := strings.CutSuffix(name, ".up.sql"); ok {
+			require.Contains(t, names, up+".down.sql")
+		}
+	}
+
+	latest, err := LatestVersion()
+	require.NoError(t, err)
+	require.EqualValues(t, len(names)/2, latest)
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	dbSource := filepath.Join(t.TempDir(), "simple_bank.db")
+
+	migrator, err := New(util.DBDriverSQLite, dbSource)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	// the account table is rebuilt by later migrations, which must keep the
+	// rows and the foreign keys that reference it
+	require.NoError(t, migrator.Goto(4))
+
+	db, err := sql.Open("sqlite", dbSource+"?_pragma=foreign_keys(1)")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		INSERT INTO users (username, hashed_password, full_name, email) VALUES ('alice', 'x', 'Alice', 'alice@example.com');
+		INSERT INTO account (owner, balance, currency) VALUES ('alice', 10, 'USD');
+		INSERT INTO entries (account_id, amount) VALUES (1, 10);
+		INSERT INTO payees (owner, nickname, account_id, currency) VALUES ('alice', 'me', 1, 'USD');
+	`)
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Up(0))
+	latest, err := LatestVersion()
+	require.NoError(t, err)
+	version, dirty, err := migrator.Status()
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, latest, version)
+
+	var accountNumber, role string
+	err = db.QueryRow(`
+		SELECT account_number, role FROM account
+		JOIN account_holders ON account_holders.account_id = account.id
+	`).Scan(&accountNumber, &role)
+	require.NoError(t, err)
+	require.True(t, util.IsValidAccountNumber(accountNumber), accountNumber)
+	require.Equal(t, "primary", role)
+
+	rows, err := db.Query("PRAGMA foreign_key_check")
+	require.NoError(t, err)
+	require.False(t, rows.Next(), "foreign key violations")
+	require.NoError(t, rows.Close())
+
+	var schema string
+	err = db.QueryRow("SELECT group_concat(sql) FROM sqlite_master").Scan(&schema)
+	require.NoError(t, err)
+	require.NotContains(t, schema, "account_old")
+
+	// every migration can be rolled back
+	require.NoError(t, migrator.Down(int(latest)))
+	version, _, err = migrator.Status()
+	require.NoError(t, err)
+	require.Zero(t, version)
+}
+
+func TestSQLiteDownDropsPendingInvitations(t *testing.T) {
+	dbSource := filepath.Join(t.TempDir(), "simple_bank.db")
+
+	migrator, err := New(util.DBDriverSQLite, dbSource)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	require.NoError(t, migrator.Goto(13))
+
+	db, err := sql.Open("sqlite", dbSource+"?_pragma=foreign_keys(1)")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		INSERT INTO users (username, hashed_password, full_name, email) VALUES ('alice', 'x', 'Alice', 'alice@example.com');
+		INSERT INTO users (username, hashed_password, full_name, email) VALUES ('bob', 'x', 'Bob', 'bob@example.com');
+		INSERT INTO account (owner, balance, currency, account_number) VALUES ('alice', 10, 'USD', 'SB00000000000001');
+		INSERT INTO account_holders (account_id, username, role, status) VALUES (1, 'alice', 'primary', 'accepted');
+		INSERT INTO account_holders (account_id, username, role, status) VALUES (1, 'bob', 'joint', 'pending');
+	`)
+	require.NoError(t, err)
+
+	// an invitee must not become a holder when the invitations are rolled back
+	require.NoError(t, migrator.Goto(12))
+
+	var holders []string
+	rows, err := db.Query("SELECT username FROM account_holders ORDER BY username")
+	require.NoError(t, err)
+	for rows.Next() {
+		var username string
+		require.NoError(t, rows.Scan(&username))
+		holders = append(holders, username)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	require.Equal(t, []string{"alice"}, holders)
+}
+
+func TestUpConcurrently(t *testing.T) {
+	dbSource := testDBSource(t)
+	latest, err := LatestVersion()
+	require.NoError(t, err)
+
+	// replicas starting together must not race on the schema
+	n := 5
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			migrator, err := New(util.DBDriverPostgres, dbSource)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer migrator.Close()
+
+			errs <- migrator.Up(0)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	migrator, err := New(util.DBDriverPostgres, dbSource)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	version, dirty, err := migrator.Status()
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.Equal(t, latest, version)
+}
+
+func TestDownAndGoto(t *testing.T) {
+	migrator, err := New(util.DBDriverPostgres, testDBSource(t))
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	require.NoError(t, migrator.Up(0))
+	latest, _, err := migrator.Status()
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Down(1))
+	version, _, err := migrator.Status()
+	require.NoError(t, err)
+	require.Equal(t, latest-1, version)
+
+	require.NoError(t, migrator.Goto(latest))
+	version, _, err = migrator.Status()
+	require.NoError(t, err)
+	require.Equal(t, latest, version)
+
+	require.Error(t, migrator.Down(0))
+}
@@ -0,0 +1,34 @@
+-- The SQLite schema mirrors the Postgres one version by version. Timestamps
+-- are stored as UTC text with microseconds, which sorts chronologically.
+CREATE TABLE "account" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "owner" varchar NOT NULL,
+  "balance" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE TABLE "entries" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "account_id" bigint NOT NULL REFERENCES "account" ("id"),
+  "amount" bigint NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE TABLE "transfers" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "from_account_id" bigint NOT NULL REFERENCES "account" ("id"),
+  "to_account_id" bigint NOT NULL REFERENCES "account" ("id"),
+  "amount" bigint NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE INDEX "account_owner_idx" ON "account" ("owner");
+
+CREATE INDEX "entries_account_id_idx" ON "entries" ("account_id");
+
+CREATE INDEX "transfers_from_account_id_idx" ON "transfers" ("from_account_id");
+
+CREATE INDEX "transfers_to_account_id_idx" ON "transfers" ("to_account_id");
+
+CREATE INDEX "transfers_from_account_id_to_account_id_idx" ON "transfers" ("from_account_id", "to_account_id");
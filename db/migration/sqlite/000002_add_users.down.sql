@@ -0,0 +1,21 @@
+PRAGMA legacy_alter_table = ON;
+
+ALTER TABLE "account" RENAME TO "account_old";
+
+CREATE TABLE "account" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "owner" varchar NOT NULL,
+  "balance" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+INSERT INTO "account" SELECT * FROM "account_old";
+
+DROP TABLE "account_old";
+
+PRAGMA legacy_alter_table = OFF;
+
+CREATE INDEX "account_owner_idx" ON "account" ("owner");
+
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,34 @@
+CREATE TABLE "users" (
+  "username" varchar PRIMARY KEY,
+  "hashed_password" varchar NOT NULL,
+  "full_name" varchar NOT NULL,
+  "email" varchar UNIQUE NOT NULL,
+  "password_changed_at" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00.000000',
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+-- SQLite cannot add a foreign key to an existing table, so the account table
+-- is rebuilt. The migrator runs with foreign keys off, so with
+-- legacy_alter_table the rename leaves the foreign keys of entries and
+-- transfers pointing at the new "account" table.
+PRAGMA legacy_alter_table = ON;
+
+ALTER TABLE "account" RENAME TO "account_old";
+
+CREATE TABLE "account" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "owner" varchar NOT NULL REFERENCES "users" ("username"),
+  "balance" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+INSERT INTO "account" SELECT * FROM "account_old";
+
+DROP TABLE "account_old";
+
+PRAGMA legacy_alter_table = OFF;
+
+CREATE INDEX "account_owner_idx" ON "account" ("owner");
+
+CREATE UNIQUE INDEX "owner_currency_key" ON "account" ("owner", "currency");
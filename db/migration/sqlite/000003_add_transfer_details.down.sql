@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "transfers_category_idx";
+
+DROP INDEX IF EXISTS "transfers_reference_idx";
+
+ALTER TABLE "transfers" DROP COLUMN "category";
+
+ALTER TABLE "transfers" DROP COLUMN "reference";
+
+ALTER TABLE "transfers" DROP COLUMN "description";
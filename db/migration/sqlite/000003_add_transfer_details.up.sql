@@ -0,0 +1,9 @@
+ALTER TABLE "transfers" ADD COLUMN "description" varchar(140) NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "reference" varchar(35) NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "category" varchar(32) NOT NULL DEFAULT '';
+
+CREATE INDEX "transfers_reference_idx" ON "transfers" ("reference");
+
+CREATE INDEX "transfers_category_idx" ON "transfers" ("category");
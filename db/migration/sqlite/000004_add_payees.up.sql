@@ -0,0 +1,14 @@
+CREATE TABLE "payees" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "owner" varchar NOT NULL REFERENCES "users" ("username"),
+  "nickname" varchar(50) NOT NULL,
+  "account_id" bigint NOT NULL REFERENCES "account" ("id") ON DELETE CASCADE,
+  "currency" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE INDEX "payees_owner_idx" ON "payees" ("owner");
+
+CREATE UNIQUE INDEX "owner_nickname_key" ON "payees" ("owner", "nickname");
+
+CREATE UNIQUE INDEX "owner_account_key" ON "payees" ("owner", "account_id");
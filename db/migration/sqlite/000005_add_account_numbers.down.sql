@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "account_number_key";
+
+ALTER TABLE "account" DROP COLUMN "account_number";
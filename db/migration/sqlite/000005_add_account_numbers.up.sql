@@ -0,0 +1,36 @@
+-- the column is NOT NULL, so the account table is rebuilt with it, as in
+-- 000002_add_users
+PRAGMA legacy_alter_table = ON;
+
+ALTER TABLE "account" RENAME TO "account_old";
+
+CREATE TABLE "account" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "owner" varchar NOT NULL REFERENCES "users" ("username"),
+  "balance" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now')),
+  "account_number" varchar NOT NULL
+);
+
+-- backfill existing accounts with random numbers and ISO 7064 mod-97 check
+-- digits computed over BBAN || 'SB' (S=28, B=11) || '00'. The numbers are
+-- materialized so that random() is evaluated once per account.
+WITH numbers AS MATERIALIZED (
+  SELECT *, printf('%012d', abs(random() % 1000000000000)) AS bban
+  FROM "account_old"
+)
+INSERT INTO "account"
+SELECT "id", "owner", "balance", "currency", "created_at",
+  'SB' || printf('%02d', 98 - (CAST(bban || '281100' AS integer) % 97)) || bban
+FROM numbers;
+
+DROP TABLE "account_old";
+
+PRAGMA legacy_alter_table = OFF;
+
+CREATE INDEX "account_owner_idx" ON "account" ("owner");
+
+CREATE UNIQUE INDEX "owner_currency_key" ON "account" ("owner", "currency");
+
+CREATE UNIQUE INDEX "account_number_key" ON "account" ("account_number");
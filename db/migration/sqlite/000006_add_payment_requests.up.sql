@@ -0,0 +1,22 @@
+CREATE TABLE "payment_requests" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "requester" varchar NOT NULL REFERENCES "users" ("username"),
+  "payer" varchar NOT NULL REFERENCES "users" ("username"),
+  "to_account_id" bigint NOT NULL REFERENCES "account" ("id") ON DELETE CASCADE,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "description" varchar(140) NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint REFERENCES "transfers" ("id"),
+  "expires_at" timestamp NOT NULL,
+  "responded_at" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00.000000',
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now')),
+  CONSTRAINT "payment_requests_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "payment_requests_status_check" CHECK ("status" IN ('pending', 'accepted', 'declined', 'expired'))
+);
+
+CREATE INDEX "payment_requests_payer_status_idx" ON "payment_requests" ("payer", "status");
+
+CREATE INDEX "payment_requests_requester_idx" ON "payment_requests" ("requester");
+
+CREATE INDEX "payment_requests_expires_at_idx" ON "payment_requests" ("expires_at") WHERE "status" = 'pending';
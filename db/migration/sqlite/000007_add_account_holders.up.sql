@@ -0,0 +1,14 @@
+CREATE TABLE "account_holders" (
+  "account_id" bigint NOT NULL REFERENCES "account" ("id") ON DELETE CASCADE,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "role" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now')),
+  PRIMARY KEY ("account_id", "username"),
+  CONSTRAINT "account_holders_role_check" CHECK ("role" IN ('primary', 'joint', 'view_only'))
+);
+
+CREATE INDEX "account_holders_username_idx" ON "account_holders" ("username");
+
+-- every existing account is held by its owner
+INSERT INTO "account_holders" ("account_id", "username", "role")
+SELECT "id", "owner", 'primary' FROM "account";
@@ -0,0 +1,32 @@
+CREATE TABLE "transfer_approvals" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "maker" varchar NOT NULL REFERENCES "users" ("username"),
+  "from_account_id" bigint NOT NULL REFERENCES "account" ("id") ON DELETE CASCADE,
+  "to_account_id" bigint NOT NULL REFERENCES "account" ("id") ON DELETE CASCADE,
+  "amount" bigint NOT NULL,
+  "description" varchar(140) NOT NULL DEFAULT '',
+  "reference" varchar(35) NOT NULL DEFAULT '',
+  "category" varchar(32) NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'pending_approval',
+  "checker" varchar NOT NULL DEFAULT '',
+  "transfer_id" bigint REFERENCES "transfers" ("id"),
+  "expires_at" timestamp NOT NULL,
+  "decided_at" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00.000000',
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now')),
+  CONSTRAINT "transfer_approvals_amount_check" CHECK ("amount" > 0),
+  CONSTRAINT "transfer_approvals_status_check" CHECK ("status" IN ('pending_approval', 'approved', 'rejected', 'expired'))
+);
+
+CREATE TABLE "notifications" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "kind" varchar NOT NULL,
+  "message" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE INDEX "transfer_approvals_from_account_id_status_idx" ON "transfer_approvals" ("from_account_id", "status");
+
+CREATE INDEX "transfer_approvals_expires_at_idx" ON "transfer_approvals" ("expires_at") WHERE "status" = 'pending_approval';
+
+CREATE INDEX "notifications_username_idx" ON "notifications" ("username");
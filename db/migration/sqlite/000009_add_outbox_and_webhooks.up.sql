@@ -0,0 +1,37 @@
+CREATE TABLE "outbox_events" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "event_type" varchar NOT NULL,
+  "aggregate_id" bigint NOT NULL,
+  "payload" blob NOT NULL,
+  "fanned_out" boolean NOT NULL DEFAULT false,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE TABLE "webhooks" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "owner" varchar NOT NULL REFERENCES "users" ("username"),
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "webhook_id" bigint NOT NULL REFERENCES "webhooks" ("id") ON DELETE CASCADE,
+  "event_id" bigint NOT NULL REFERENCES "outbox_events" ("id"),
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "next_attempt_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now')),
+  "delivered_at" timestamp NOT NULL DEFAULT '0001-01-01 00:00:00.000000',
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now')),
+  CONSTRAINT "webhook_deliveries_status_check" CHECK ("status" IN ('pending', 'delivered', 'dead'))
+);
+
+CREATE INDEX "outbox_events_id_idx" ON "outbox_events" ("id") WHERE "fanned_out" = false;
+
+CREATE INDEX "webhook_deliveries_next_attempt_at_idx" ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+CREATE INDEX "webhook_deliveries_status_idx" ON "webhook_deliveries" ("status");
+
+CREATE UNIQUE INDEX "webhook_event_key" ON "webhook_deliveries" ("webhook_id", "event_id");
@@ -0,0 +1,3 @@
+-- SQLite has no NOTIFY, so the store notifies its listeners itself once the
+-- inserting transaction commits
+CREATE INDEX "outbox_events_aggregate_id_id_idx" ON "outbox_events" ("aggregate_id", "id") WHERE "event_type" = 'account.balance_changed';
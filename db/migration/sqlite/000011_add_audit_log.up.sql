@@ -0,0 +1,31 @@
+CREATE TABLE "audit_log" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "actor" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "resource_type" varchar NOT NULL,
+  "resource_id" varchar NOT NULL,
+  "client_ip" varchar NOT NULL DEFAULT '',
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "request_id" varchar NOT NULL DEFAULT '',
+  "before" blob,
+  "after" blob,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE INDEX "audit_log_actor_created_at_idx" ON "audit_log" ("actor", "created_at");
+
+CREATE INDEX "audit_log_resource_type_resource_id_created_at_idx" ON "audit_log" ("resource_type", "resource_id", "created_at");
+
+CREATE INDEX "audit_log_created_at_idx" ON "audit_log" ("created_at");
+
+CREATE TRIGGER "audit_log_no_update"
+BEFORE UPDATE ON "audit_log"
+BEGIN
+  SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER "audit_log_no_delete"
+BEFORE DELETE ON "audit_log"
+BEGIN
+  SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "ledger_checkpoints";
+
+DROP INDEX IF EXISTS "entries_account_id_id_idx";
+
+ALTER TABLE "entries" DROP COLUMN "hash";
+
+ALTER TABLE "entries" DROP COLUMN "prev_hash";
+
+ALTER TABLE "entries" DROP COLUMN "transfer_id";
@@ -0,0 +1,30 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint REFERENCES "transfers" ("id");
+
+ALTER TABLE "entries" ADD COLUMN "prev_hash" blob;
+
+ALTER TABLE "entries" ADD COLUMN "hash" blob;
+
+CREATE INDEX "entries_account_id_id_idx" ON "entries" ("account_id", "id");
+
+CREATE TABLE "ledger_checkpoints" (
+  "id" integer PRIMARY KEY AUTOINCREMENT,
+  "account_id" bigint NOT NULL,
+  "entry_id" bigint NOT NULL,
+  "hash" blob NOT NULL,
+  "signature" blob NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000', 'now'))
+);
+
+CREATE INDEX "ledger_checkpoints_account_id_entry_id_idx" ON "ledger_checkpoints" ("account_id", "entry_id");
+
+CREATE TRIGGER "ledger_checkpoints_no_update"
+BEFORE UPDATE ON "ledger_checkpoints"
+BEGIN
+  SELECT RAISE(ABORT, 'ledger_checkpoints is append-only');
+END;
+
+CREATE TRIGGER "ledger_checkpoints_no_delete"
+BEFORE DELETE ON "ledger_checkpoints"
+BEGIN
+  SELECT RAISE(ABORT, 'ledger_checkpoints is append-only');
+END;
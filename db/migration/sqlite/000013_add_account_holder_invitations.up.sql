@@ -0,0 +1,2 @@
+ALTER TABLE "account_holders" ADD COLUMN "status" varchar NOT NULL DEFAULT 'accepted'
+  CONSTRAINT "account_holders_status_check" CHECK ("status" IN ('pending', 'accepted'));
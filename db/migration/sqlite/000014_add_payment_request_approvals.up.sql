@@ -0,0 +1,3 @@
+ALTER TABLE "transfer_approvals" ADD COLUMN "payment_request_id" bigint REFERENCES "payment_requests" ("id") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX "transfer_approvals_payment_request_id_idx" ON "transfer_approvals" ("payment_request_id") WHERE "status" = 'pending_approval';
@@ -2,9 +2,10 @@
 INSERT INTO account (
   owner,
   balance,
-  currency
+  currency,
+  account_number
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4
 ) RETURNING *;
 
 
@@ -13,18 +14,28 @@ SELECT * FROM account
 WHERE id = $1 LIMIT 1;
 
 
+-- name: GetAccountByNumber :one
+SELECT * FROM account
+WHERE account_number = $1 LIMIT 1;
+
 -- name: GetAccountForUpdate :one
 SELECT * FROM account
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE;
 
 -- name: ListAccounts :many
-SELECT * FROM account
-WHERE owner = $1
-ORDER BY id
+SELECT account.* FROM account
+JOIN account_holders ON account_holders.account_id = account.id
+WHERE account_holders.username = $1
+  AND account_holders.status = 'accepted'
+ORDER BY account.id
 LIMIT $2
 OFFSET $3;
 
+-- name: ListAccountNumbers :many
+SELECT id, account_number FROM account
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
 -- name: UpdateAccount :one
 UPDATE account SET balance = $2
 WHERE id = $1
@@ -0,0 +1,32 @@
+-- name: CreateAccountHolder :one
+INSERT INTO account_holders (
+  account_id,
+  username,
+  role,
+  status
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: AcceptAccountHolder :one
+UPDATE account_holders SET status = 'accepted'
+WHERE account_id = $1 AND username = $2 AND status = 'pending'
+RETURNING *;
+
+-- name: GetAccountHolder :one
+SELECT * FROM account_holders
+WHERE account_id = $1 AND username = $2 LIMIT 1;
+
+-- name: ListAccountHolders :many
+SELECT * FROM account_holders
+WHERE account_id = $1
+ORDER BY created_at;
+
+-- name: ListAccountInvitations :many
+SELECT * FROM account_holders
+WHERE username = $1 AND status = 'pending'
+ORDER BY created_at;
+
+-- name: DeleteAccountHolder :exec
+DELETE FROM account_holders
+WHERE account_id = $1 AND username = $2;
@@ -0,0 +1,26 @@
+-- name: CreateAuditLog :one
+INSERT INTO audit_log (
+  actor,
+  action,
+  resource_type,
+  resource_id,
+  client_ip,
+  user_agent,
+  request_id,
+  before,
+  after
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: ListAuditLogs :many
+SELECT * FROM audit_log
+WHERE
+    (sqlc.arg(actor)::varchar = '' OR actor = sqlc.arg(actor))
+    AND (sqlc.arg(resource_type)::varchar = '' OR resource_type = sqlc.arg(resource_type))
+    AND (sqlc.arg(resource_id)::varchar = '' OR resource_id = sqlc.arg(resource_id))
+    AND created_at >= sqlc.arg(since)
+    AND created_at < sqlc.arg(until)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
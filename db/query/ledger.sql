@@ -0,0 +1,50 @@
+-- name: ListLedgerEntries :many
+SELECT
+  entries.id,
+  entries.account_id,
+  entries.amount,
+  entries.created_at,
+  entries.transfer_id,
+  entries.prev_hash,
+  entries.hash,
+  COALESCE(transfers.from_account_id, 0)::bigint AS transfer_from_account_id,
+  COALESCE(transfers.to_account_id, 0)::bigint AS transfer_to_account_id,
+  COALESCE(transfers.amount, 0)::bigint AS transfer_amount,
+  COALESCE(transfers.description, '')::varchar AS transfer_description,
+  COALESCE(transfers.reference, '')::varchar AS transfer_reference,
+  COALESCE(transfers.category, '')::varchar AS transfer_category,
+  COALESCE(transfers.created_at, 'epoch')::timestamptz AS transfer_created_at
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+WHERE (entries.account_id, entries.id) > (sqlc.arg(after_account_id)::bigint, sqlc.arg(after_id)::bigint)
+ORDER BY entries.account_id, entries.id
+LIMIT sqlc.arg('limit');
+
+-- name: ListUncheckpointedHeads :many
+SELECT entries.account_id, entries.id, entries.hash FROM entries
+WHERE entries.id = (
+    SELECT max(latest.id) FROM entries AS latest
+    WHERE latest.account_id = entries.account_id AND latest.hash IS NOT NULL
+  )
+  AND entries.id > COALESCE((
+    SELECT max(ledger_checkpoints.entry_id) FROM ledger_checkpoints
+    WHERE ledger_checkpoints.account_id = entries.account_id
+  ), 0)
+ORDER BY entries.account_id
+LIMIT $1;
+
+-- name: CreateLedgerCheckpoint :one
+INSERT INTO ledger_checkpoints (
+  account_id,
+  entry_id,
+  hash,
+  signature
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListLedgerCheckpoints :many
+SELECT * FROM ledger_checkpoints
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
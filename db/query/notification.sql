@@ -0,0 +1,15 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (
+  username,
+  kind,
+  message
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: ListNotifications :many
+SELECT * FROM notifications
+WHERE username = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
@@ -0,0 +1,39 @@
+-- name: CreateOutboxEvent :one
+INSERT INTO outbox_events (
+  event_type,
+  aggregate_id,
+  payload
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetOutboxEvent :one
+SELECT * FROM outbox_events
+WHERE id = $1 LIMIT 1;
+
+-- name: FanOutOutboxEvents :execrows
+WITH events AS (
+  UPDATE outbox_events
+  SET fanned_out = true
+  WHERE id IN (
+    SELECT id FROM outbox_events
+    WHERE fanned_out = false
+    ORDER BY id
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+  )
+  RETURNING id
+)
+INSERT INTO webhook_deliveries (webhook_id, event_id)
+SELECT webhooks.id, events.id FROM events CROSS JOIN webhooks;
+
+-- name: GetLastOutboxEventID :one
+SELECT COALESCE(MAX(id), 0)::bigint FROM outbox_events;
+
+-- name: ListAccountEvents :many
+SELECT * FROM outbox_events
+WHERE event_type = 'account.balance_changed'
+  AND aggregate_id = sqlc.arg(account_id)
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
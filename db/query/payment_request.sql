@@ -0,0 +1,50 @@
+-- name: CreatePaymentRequest :one
+INSERT INTO payment_requests (
+  requester,
+  payer,
+  to_account_id,
+  amount,
+  currency,
+  description,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetPaymentRequest :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPaymentRequestForUpdate :one
+SELECT * FROM payment_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListPaymentRequestsByPayer :many
+SELECT * FROM payment_requests
+WHERE payer = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListPaymentRequestsByRequester :many
+SELECT * FROM payment_requests
+WHERE requester = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: UpdatePaymentRequestStatus :one
+-- Only a pending payment request can be answered.
+UPDATE payment_requests
+SET
+  status = sqlc.arg(status),
+  transfer_id = sqlc.narg(transfer_id),
+  responded_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending'
+RETURNING *;
+
+-- name: ExpirePaymentRequests :execrows
+UPDATE payment_requests
+SET status = 'expired'
+WHERE status = 'pending' AND expires_at <= now();
@@ -2,9 +2,12 @@
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  description,
+  reference,
+  category
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetTransfer :one
@@ -18,4 +21,19 @@ WHERE
     to_account_id = $2
 ORDER BY id
 LIMIT $3
-OFFSET $4;
\ No newline at end of file
+OFFSET $4;
+
+-- name: SearchTransfers :many
+-- The query is matched literally: its LIKE wildcards are escaped.
+SELECT * FROM transfers
+WHERE 
+    (from_account_id = sqlc.arg(account_id) OR to_account_id = sqlc.arg(account_id))
+    AND (sqlc.arg(category)::varchar = '' OR category = sqlc.arg(category))
+    AND (
+        sqlc.arg(query)::varchar = '' OR
+        description ILIKE '%' || replace(replace(replace(sqlc.arg(query)::varchar, '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\' OR
+        reference ILIKE '%' || replace(replace(replace(sqlc.arg(query)::varchar, '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\'
+    )
+ORDER BY id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
@@ -0,0 +1,59 @@
+-- name: CreateTransferApproval :one
+INSERT INTO transfer_approvals (
+  maker,
+  from_account_id,
+  to_account_id,
+  amount,
+  description,
+  reference,
+  category,
+  expires_at,
+  payment_request_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetTransferApproval :one
+SELECT * FROM transfer_approvals
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferApprovalForUpdate :one
+SELECT * FROM transfer_approvals
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListPendingTransferApprovals :many
+SELECT * FROM transfer_approvals
+WHERE status = 'pending_approval'
+ORDER BY id
+LIMIT $1
+OFFSET $2;
+
+-- name: ListPendingTransferApprovalsForHolder :many
+-- The maker of a transfer cannot approve it, so it is not listed for them.
+SELECT transfer_approvals.* FROM transfer_approvals
+JOIN account_holders ON account_holders.account_id = transfer_approvals.from_account_id
+WHERE account_holders.username = $1
+  AND account_holders.role IN ('primary', 'joint')
+  AND account_holders.status = 'accepted'
+  AND transfer_approvals.status = 'pending_approval'
+  AND transfer_approvals.maker <> $1
+ORDER BY transfer_approvals.id
+LIMIT $2
+OFFSET $3;
+
+-- name: DecideTransferApproval :one
+UPDATE transfer_approvals
+SET
+  status = sqlc.arg(status),
+  checker = sqlc.arg(checker),
+  transfer_id = sqlc.narg(transfer_id),
+  decided_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ExpireTransferApprovals :many
+UPDATE transfer_approvals
+SET status = 'expired'
+WHERE status = 'pending_approval' AND expires_at <= now()
+RETURNING *;
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// AccountEventsChannel is the Postgres channel notified, on commit, of every
+// account.balance_changed event written to the outbox.
+const AccountEventsChannel = "account_events"
+
+// AccountEventNotification is the payload of a notification on
+// AccountEventsChannel. The event itself is read back from the outbox.
+type AccountEventNotification struct {
+	EventID   int64 `json:"event_id"`
+	AccountID int64 `json:"account_id"`
+}
+
+// ListenAccountEvents calls handle for every notification on
+// AccountEventsChannel until ctx is cancelled or the connection fails.
+// Notifications sent while nobody is listening are lost, so callers should
+// catch up from the outbox after every (re)connect.
+func (store *SQLStore) ListenAccountEvents(ctx context.Context, handle func(AccountEventNotification)) error {
+	poolConn, err := store.connPool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// the connection stays in LISTEN mode, so it must not go back to the pool
+	conn := poolConn.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+AccountEventsChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		var event AccountEventNotification
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			continue
+		}
+		handle(event)
+	}
+}
+
+// accountEventHub delivers account events to the ListenAccountEvents calls of
+// a store without a database to notify them, such as MemoryStore. Its zero
+// value is ready to use.
+type accountEventHub struct {
+	mu        sync.Mutex
+	listeners map[*accountEventListener]struct{}
+}
+
+// notify queues a notification for every listener without waiting for their
+// handlers.
+func (hub *accountEventHub) notify(notification AccountEventNotification) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for listener := range hub.listeners {
+		listener.push(notification)
+	}
+}
+
+// listen calls handle for every notification until ctx is cancelled.
+func (hub *accountEventHub) listen(ctx context.Context, handle func(AccountEventNotification)) error {
+	listener := &accountEventListener{wake: make(chan struct{}, 1)}
+
+	hub.mu.Lock()
+	if hub.listeners == nil {
+		hub.listeners = make(map[*accountEventListener]struct{})
+	}
+	hub.listeners[listener] = struct{}{}
+	hub.mu.Unlock()
+
+	defer func() {
+		hub.mu.Lock()
+		delete(hub.listeners, listener)
+		hub.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-listener.wake:
+			for _, notification := range listener.pop() {
+				handle(notification)
+			}
+		}
+	}
+}
+
+// accountEventListener queues the notifications of a listen call, so that
+// notifying never waits for its handler.
+type accountEventListener struct {
+	mu      sync.Mutex
+	pending []AccountEventNotification
+	wake    chan struct{}
+}
+
+func (listener *accountEventListener) push(notification AccountEventNotification) {
+	listener.mu.Lock()
+	listener.pending = append(listener.pending, notification)
+	listener.mu.Unlock()
+
+	select {
+	case listener.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (listener *accountEventListener) pop() []AccountEventNotification {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+
+	pending := listener.pending
+	listener.pending = nil
+	return pending
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAccountTxAddsPrimaryHolder(t *testing.T) {
+	account := createRandomAccount(t)
+
+	holder, err := testStore.GetAccountHolder(context.Background(), GetAccountHolderParams{
+		AccountID: account.ID,
+		Username:  account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountHolderRolePrimary, holder.Role)
+}
+
+func TestJointAccountHolder(t *testing.T) {
+	account := createRandomAccount(t)
+	user := createRandomUser(t)
+
+	holder, err := testStore.CreateAccountHolder(context.Background(), CreateAccountHolderParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+		Role:      AccountHolderRoleJoint,
+		Status:    AccountHolderStatusAccepted,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, holder.AccountID)
+	require.Equal(t, user.Username, holder.Username)
+	require.Equal(t, AccountHolderRoleJoint, holder.Role)
+	require.NotZero(t, holder.CreatedAt)
+
+	// the shared account shows up in the co-holder's account list
+	accounts, err := testStore.ListAccounts(context.Background(), ListAccountsParams{
+		Username: user.Username,
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	holders, err := testStore.ListAccountHolders(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, holders, 2)
+
+	err = testStore.DeleteAccountHolder(context.Background(), DeleteAccountHolderParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.GetAccountHolder(context.Background(), GetAccountHolderParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestAccountHolderInvalidRole(t *testing.T) {
+	account := createRandomAccount(t)
+	user := createRandomUser(t)
+
+	_, err := testStore.CreateAccountHolder(context.Background(), CreateAccountHolderParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+		Role:      "owner",
+		Status:    AccountHolderStatusAccepted,
+	})
+	require.Error(t, err)
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"math"
 	"simple_bank/util"
 	"testing"
 
@@ -25,12 +26,13 @@ func BenchmarkCreateAccount(b *testing.B) {
 	// Benchmark only account creation
 	for i := 0; i < b.N; i++ {
 		arg := CreateAccountParams{
-			Owner:    users[i].Username,
-			Balance:  util.RandomMoney(),
-			Currency: currencies[i%len(currencies)],
+			Owner:         users[i].Username,
+			Balance:       util.RandomMoney(),
+			Currency:      currencies[i%len(currencies)],
+			AccountNumber: util.RandomAccountNumber(),
 		}
 
-		account, err := testStore.CreateAccount(context.Background(), arg)
+		account, err := testStore.CreateAccountTx(context.Background(), arg)
 		require.NoError(b, err)
 		require.NotEmpty(b, account)
 	}
@@ -58,11 +60,12 @@ func BenchmarkListAccounts(b *testing.B) {
 	// Create exactly 3 accounts (one per currency)
 	for _, currency := range currencies {
 		arg := CreateAccountParams{
-			Owner:    user.Username,
-			Balance:  util.RandomMoney(),
-			Currency: currency,
+			Owner:         user.Username,
+			Balance:       util.RandomMoney(),
+			Currency:      currency,
+			AccountNumber: util.RandomAccountNumber(),
 		}
-		_, err := testStore.CreateAccount(context.Background(), arg)
+		_, err := testStore.CreateAccountTx(context.Background(), arg)
 		require.NoError(b, err)
 	}
 
@@ -71,9 +74,9 @@ func BenchmarkListAccounts(b *testing.B) {
 
 	for i := 0; i < b.N; i++ {
 		arg := ListAccountsParams{
-			Owner:  user.Username,
-			Limit:  5,
-			Offset: 0,
+			Username: user.Username,
+			Limit:    5,
+			Offset:   0,
 		}
 
 		accounts, err := testStore.ListAccounts(context.Background(), arg)
@@ -148,13 +151,15 @@ func createRandomAccountForBenchmark(t testing.TB) Account {
 	user := createRandomUserForBenchmark(t)
 
 	// Then create an account with that user as owner
+	// transfers are benchmarked from this account b.N times
 	arg := CreateAccountParams{
-		Owner:    user.Username,
-		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
+		Owner:         user.Username,
+		Balance:       math.MaxInt32,
+		Currency:      util.RandomCurrency(),
+		AccountNumber: util.RandomAccountNumber(),
 	}
 
-	account, err := testStore.CreateAccount(context.Background(), arg)
+	account, err := testStore.CreateAccountTx(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, account)
 
@@ -1,27 +1,120 @@
 package db
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 const (
-	ForeignKeyViolation = "23503"
-	UniqueViolation     = "23505"
+	ForeignKeyViolation  = "23503"
+	UniqueViolation      = "23505"
+	CheckViolation       = "23514"
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+	QueryCanceled        = "57014"
 )
 
-var ErrRecordNotFound = pgx.ErrNoRows
+// The kinds of error returned by the store. Callers test for them with
+// errors.Is; the underlying driver error, such as a *pgconn.PgError, remains
+// reachable with errors.As.
+var (
+	ErrRecordNotFound      = pgx.ErrNoRows
+	ErrUniqueViolation     = errors.New("record already exists")
+	ErrForeignKeyViolation = errors.New("referenced record does not exist")
+	ErrCheckViolation      = errors.New("record violates a check constraint")
+	// ErrSerializationFailure is returned when a transaction lost a
+	// serialization conflict or a deadlock; it can be retried as is.
+	ErrSerializationFailure = errors.New("transaction conflicted with a concurrent transaction")
+	ErrInsufficientFunds    = errors.New("insufficient funds")
+	// ErrQueryTimeout is returned when a query ran longer than the
+	// statement_timeout of its connection.
+	ErrQueryTimeout = errors.New("query timed out")
+)
+
+// Error is a database error of one of the kinds above.
+type Error struct {
+	Kind error
+	// Constraint is the name of the violated constraint, if any. SQLite does
+	// not report which foreign key was violated, so it is empty for the
+	// foreign key violations of SQLiteStore.
+	Constraint string
+	Err        error
+}
 
-var ErrUniqueViolation = &pgconn.PgError{
-	Code: UniqueViolation,
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v", e.Kind, e.Err)
 }
 
-func ErrorCode(err error) string {
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// translateError returns err as an *Error if it is a Postgres error of a
+// known kind, and err unchanged otherwise.
+func translateError(err error) error {
 	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) {
-		return pgErr.Code
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	var kind error
+	switch pgErr.Code {
+	case UniqueViolation:
+		kind = ErrUniqueViolation
+	case ForeignKeyViolation:
+		kind = ErrForeignKeyViolation
+	case CheckViolation:
+		kind = ErrCheckViolation
+	case SerializationFailure, DeadlockDetected:
+		kind = ErrSerializationFailure
+	case QueryCanceled:
+		kind = ErrQueryTimeout
+	default:
+		return err
 	}
-	return ""
+
+	return &Error{Kind: kind, Constraint: pgErr.ConstraintName, Err: err}
+}
+
+// errorTranslatingDBTX translates the errors of every query run through the
+// generated Queries.
+type errorTranslatingDBTX struct {
+	DBTX
+}
+
+func (db errorTranslatingDBTX) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := db.DBTX.Exec(ctx, sql, args...)
+	return tag, translateError(err)
+}
+
+func (db errorTranslatingDBTX) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := db.DBTX.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return errorTranslatingRows{rows}, nil
+}
+
+func (db errorTranslatingDBTX) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return errorTranslatingRow{db.DBTX.QueryRow(ctx, sql, args...)}
+}
+
+type errorTranslatingRow struct {
+	pgx.Row
+}
+
+func (row errorTranslatingRow) Scan(dest ...any) error {
+	return translateError(row.Row.Scan(dest...))
+}
+
+type errorTranslatingRows struct {
+	pgx.Rows
+}
+
+func (rows errorTranslatingRows) Err() error {
+	return translateError(rows.Rows.Err())
 }
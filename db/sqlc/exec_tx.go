@@ -2,25 +2,127 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"simple_bank/logger"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// defaultMaxTxRetries is how many times a transaction that lost a
+	// serialization conflict or a deadlock is retried before giving up.
+	defaultMaxTxRetries = 5
+	// txRetryBaseDelay and txRetryMaxDelay bound the exponential backoff
+	// between retries, which is jittered so that the transactions that
+	// conflicted do not conflict again.
+	txRetryBaseDelay = 5 * time.Millisecond
+	txRetryMaxDelay  = 200 * time.Millisecond
 )
 
-// execTx executes a function within a database transaction.
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.connPool.Begin(ctx)
+// readCommitted is the isolation of the transactions that serialize their
+// writes with row locks, such as transfers.
+var readCommitted = pgx.TxOptions{IsoLevel: pgx.ReadCommitted}
+
+// TxStats counts the retries of transactions since the store was created.
+type TxStats struct {
+	SerializationRetries int64
+	DeadlockRetries      int64
+	// Exhausted counts the transactions that still failed after the last
+	// retry.
+	Exhausted int64
+}
+
+func (store *SQLStore) TxStats() TxStats {
+	return TxStats{
+		SerializationRetries: store.serializationRetries.Load(),
+		DeadlockRetries:      store.deadlockRetries.Load(),
+		Exhausted:            store.exhaustedRetries.Load(),
+	}
+}
+
+// execTx executes a function within a database transaction with the given
+// options. A transaction that fails with a serialization failure or a
+// deadlock is rolled back and run again, so fn must not have side effects
+// outside of q. The transaction is recorded as a span enclosing the spans of
+// its queries.
+func (store *SQLStore) execTx(ctx context.Context, opts pgx.TxOptions, fn func(*Queries) error) (err error) {
+	ctx, span := tracer.Start(ctx, "execTx", trace.WithAttributes(
+		attribute.String("db.transaction.isolation", string(opts.IsoLevel)),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	for attempt := 0; ; attempt++ {
+		err = store.runTx(ctx, opts, fn)
+		if !errors.Is(err, ErrSerializationFailure) {
+			return err
+		}
+
+		if attempt == store.maxTxRetries {
+			store.exhaustedRetries.Add(1)
+			return err
+		}
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == DeadlockDetected {
+			store.deadlockRetries.Add(1)
+		} else {
+			store.serializationRetries.Add(1)
+		}
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt+1)))
+		logger.FromContext(ctx).DebugContext(ctx, "retrying transaction", "attempt", attempt+1, "error", err)
+
+		timer := time.NewTimer(txRetryDelay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// runTx runs fn in a single transaction.
+func (store *SQLStore) runTx(ctx context.Context, opts pgx.TxOptions, fn func(*Queries) error) error {
+	tx, err := store.connPool.BeginTx(ctx, opts)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 
-	q := New(tx)
+	q := New(errorTranslatingDBTX{tx})
 
 	err = fn(q)
 	if err != nil {
+		logger.FromContext(ctx).DebugContext(ctx, "transaction rolled back", "error", err)
 		if rbErr := tx.Rollback(ctx); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
 		}
 		return err
 	}
 
-	return tx.Commit(ctx)
+	// serialization failures may only be detected at commit
+	return translateError(tx.Commit(ctx))
+}
+
+// txRetryDelay returns a random delay of up to the exponential backoff of
+// the given attempt.
+func txRetryDelay(attempt int) time.Duration {
+	backoff := txRetryMaxDelay
+	// larger shifts would overflow, and the cap is reached long before
+	if attempt < 16 {
+		backoff = min(txRetryBaseDelay<<attempt, txRetryMaxDelay)
+	}
+	return rand.N(backoff) + 1
 }
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExecTxRetriesConflicts runs opposite-direction transfers between the
+// same accounts at serializable isolation, where most of them conflict, and
+// checks that every one of them eventually commits.
+func TestExecTxRetriesConflicts(t *testing.T) {
+	base := testStore.(*SQLStore)
+	n := 20
+	store := &SQLStore{
+		Queries:      base.Queries,
+		connPool:     base.connPool,
+		maxTxRetries: n,
+	}
+	serializable := pgx.TxOptions{IsoLevel: pgx.Serializable}
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	amount := int64(10)
+
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		arg := TransferTXParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		}
+		if i%2 == 1 {
+			arg.FromAccountID, arg.ToAccountID = arg.ToAccountID, arg.FromAccountID
+		}
+
+		go func() {
+			ctx := context.Background()
+			errs <- store.execTx(ctx, serializable, func(q *Queries) error {
+				_, err := transfer(ctx, q, arg)
+				return err
+			})
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	stats := store.TxStats()
+	require.Positive(t, stats.SerializationRetries+stats.DeadlockRetries)
+	require.Zero(t, stats.Exhausted)
+
+	// every transfer was applied exactly once, so the balances are unchanged
+	updatedAccount1, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := testStore.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestExecTxGivesUp(t *testing.T) {
+	base := testStore.(*SQLStore)
+	store := &SQLStore{
+		Queries:      base.Queries,
+		connPool:     base.connPool,
+		maxTxRetries: 2,
+	}
+
+	attempts := 0
+	err := store.execTx(context.Background(), readCommitted, func(q *Queries) error {
+		attempts++
+		return translateError(&pgconn.PgError{Code: DeadlockDetected})
+	})
+	require.ErrorIs(t, err, ErrSerializationFailure)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, TxStats{DeadlockRetries: 2, Exhausted: 1}, store.TxStats())
+}
+
+func TestExecTxDoesNotRetryOtherErrors(t *testing.T) {
+	attempts := 0
+	err := testStore.(*SQLStore).execTx(context.Background(), readCommitted, func(q *Queries) error {
+		attempts++
+		return ErrInsufficientFunds
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Equal(t, 1, attempts)
+}
+
+func TestTxRetryDelay(t *testing.T) {
+	for attempt := 0; attempt < 100; attempt++ {
+		delay := txRetryDelay(attempt)
+		require.Positive(t, delay)
+		require.LessOrEqual(t, delay, txRetryMaxDelay)
+		require.LessOrEqual(t, delay, txRetryBaseDelay<<min(attempt, 16))
+	}
+}
@@ -0,0 +1,22 @@
+package db
+
+import (
+	"context"
+)
+
+// SchemaVersion is the version of the latest migration in db/migration. The
+// service only reports ready once the database has been migrated to it or a
+// later version.
+const SchemaVersion = 14
+
+// Ping checks that a pooled connection can reach the database.
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.connPool.Ping(ctx)
+}
+
+// MigrationVersion returns the schema version recorded by golang-migrate and
+// whether the last migration failed part-way, leaving the schema dirty.
+func (store *SQLStore) MigrationVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	err = store.connPool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	return
+}
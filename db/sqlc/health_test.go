@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+	"simple_bank/db/migration"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+	require.NoError(t, testStore.Ping(context.Background()))
+}
+
+func TestMigrationVersion(t *testing.T) {
+	version, dirty, err := testStore.MigrationVersion(context.Background())
+	require.NoError(t, err)
+	require.False(t, dirty)
+	require.EqualValues(t, SchemaVersion, version)
+}
+
+func TestSchemaVersionIsLatestMigration(t *testing.T) {
+	latest, err := migration.LatestVersion()
+	require.NoError(t, err)
+
+	require.EqualValues(t, latest, SchemaVersion)
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// EntryHash returns the hash that chains entry, which was written by
+// transfer, to prevHash, the hash of the previous entry of its account. It
+// covers every column of the entry and of its transfer, so changing either
+// breaks the chain of the account from that entry on.
+func EntryHash(prevHash []byte, entry Entry, transfer Transfer) []byte {
+	var b []byte
+	b = appendHashBytes(b, prevHash)
+	b = binary.BigEndian.AppendUint64(b, uint64(entry.ID))
+	b = binary.BigEndian.AppendUint64(b, uint64(entry.AccountID))
+	b = binary.BigEndian.AppendUint64(b, uint64(entry.Amount))
+	b = binary.BigEndian.AppendUint64(b, uint64(entry.CreatedAt.UnixMicro()))
+	b = binary.BigEndian.AppendUint64(b, uint64(transfer.ID))
+	b = binary.BigEndian.AppendUint64(b, uint64(transfer.FromAccountID))
+	b = binary.BigEndian.AppendUint64(b, uint64(transfer.ToAccountID))
+	b = binary.BigEndian.AppendUint64(b, uint64(transfer.Amount))
+	b = binary.BigEndian.AppendUint64(b, uint64(transfer.CreatedAt.UnixMicro()))
+	b = appendHashBytes(b, []byte(transfer.Description))
+	b = appendHashBytes(b, []byte(transfer.Reference))
+	b = appendHashBytes(b, []byte(transfer.Category))
+
+	hash := sha256.Sum256(b)
+	return hash[:]
+}
+
+// appendHashBytes appends value prefixed with its length, so that the
+// boundaries between values are part of the hash.
+func appendHashBytes(b []byte, value []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(value)))
+	return append(b, value...)
+}
+
+// addEntry writes the entry of transfer for an account and chains it to
+// the previous entry of the account, using q, which must be bound to a
+// transaction holding the row lock of the account. The lock orders the
+// entries of an account by id as well as by commit, so each is chained to
+// the one written before it.
+func addEntry(ctx context.Context, q Querier, transfer Transfer, accountID int64, amount int64) (Entry, error) {
+	entry, err := q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  accountID,
+		Amount:     amount,
+		TransferID: pgtype.Int8{Int64: transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return entry, err
+	}
+
+	// the first entry of an account, or the first since the chain began,
+	// starts a new chain
+	prevHash, err := q.GetPreviousEntryHash(ctx, GetPreviousEntryHashParams{
+		AccountID: accountID,
+		ID:        entry.ID,
+	})
+	if err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return entry, err
+	}
+
+	return q.ChainEntry(ctx, ChainEntryParams{
+		ID:       entry.ID,
+		PrevHash: prevHash,
+		Hash:     EntryHash(prevHash, entry, transfer),
+	})
+}
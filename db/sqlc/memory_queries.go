@@ -0,0 +1,1216 @@
+package db
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// The queries of MemoryStore follow the ones in db/query.
+
+func (store *MemoryStore) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[arg.Username]; ok {
+		return User{}, uniqueViolation("users", "users_pkey")
+	}
+	if _, ok := store.emails[arg.Email]; ok {
+		return User{}, uniqueViolation("users", "users_email_key")
+	}
+
+	user := User{
+		Username:       arg.Username,
+		HashedPassword: arg.HashedPassword,
+		FullName:       arg.FullName,
+		Email:          arg.Email,
+		CreatedAt:      now(),
+	}
+	store.users[user.Username] = user
+	store.emails[user.Email] = user.Username
+
+	return user, nil
+}
+
+func (store *MemoryStore) GetUser(ctx context.Context, username string) (User, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	user, ok := store.users[username]
+	if !ok {
+		return user, ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (store *MemoryStore) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createAccount(arg)
+}
+
+func (store *MemoryStore) createAccount(arg CreateAccountParams) (Account, error) {
+	if _, ok := store.users[arg.Owner]; !ok {
+		return Account{}, foreignKeyViolation("account", "account_owner_fkey")
+	}
+	for account := range store.accounts.all() {
+		if account.Owner == arg.Owner && account.Currency == arg.Currency {
+			return Account{}, uniqueViolation("account", "owner_currency_key")
+		}
+	}
+	if _, ok := store.accountNumbers[arg.AccountNumber]; ok {
+		return Account{}, uniqueViolation("account", "account_number_key")
+	}
+
+	account := store.accounts.insert(func(id int64) Account {
+		return Account{
+			ID:            id,
+			Owner:         arg.Owner,
+			Balance:       arg.Balance,
+			Currency:      arg.Currency,
+			CreatedAt:     now(),
+			AccountNumber: arg.AccountNumber,
+		}
+	})
+	store.accountNumbers[account.AccountNumber] = account.ID
+
+	return account, nil
+}
+
+func (store *MemoryStore) GetAccount(ctx context.Context, id int64) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	account, ok := store.accounts.get(id)
+	if !ok {
+		return account, ErrRecordNotFound
+	}
+	return account, nil
+}
+
+// GetAccountForUpdate cannot lock the account beyond the call; transactions
+// that need the lock are methods of the store.
+func (store *MemoryStore) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
+	return store.GetAccount(ctx, id)
+}
+
+func (store *MemoryStore) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	id, ok := store.accountNumbers[accountNumber]
+	if !ok {
+		return Account{}, ErrRecordNotFound
+	}
+	account, _ := store.accounts.get(id)
+	return account, nil
+}
+
+func (store *MemoryStore) ListAccountNumbers(ctx context.Context, ids []int64) ([]ListAccountNumbersRow, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// each account is returned once, however often its id is given
+	rows := []ListAccountNumbersRow{}
+	seen := make(map[int64]bool)
+	for _, id := range ids {
+		account, ok := store.accounts.get(id)
+		if ok && !seen[id] {
+			seen[id] = true
+			rows = append(rows, ListAccountNumbersRow{ID: account.ID, AccountNumber: account.AccountNumber})
+		}
+	}
+	return rows, nil
+}
+
+func (store *MemoryStore) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return page(store.accounts.all(), func(account Account) bool {
+		holder, ok := store.accountHolder(account.ID, arg.Username)
+		return ok && holder.Status == AccountHolderStatusAccepted
+	}, arg.Limit, arg.Offset), nil
+}
+
+func (store *MemoryStore) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	account, ok := store.accounts.get(arg.ID)
+	if !ok {
+		return account, ErrRecordNotFound
+	}
+
+	account.Balance = arg.Balance
+	store.accounts.update(account.ID, account)
+	return account, nil
+}
+
+func (store *MemoryStore) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.accounts.get(arg.ID); !ok {
+		return Account{}, ErrRecordNotFound
+	}
+	return store.addBalance(arg.ID, arg.Amount), nil
+}
+
+// DeleteAccount deletes an account along with its holders, payees, payment
+// requests and transfer approvals, unless money has moved through it.
+func (store *MemoryStore) DeleteAccount(ctx context.Context, id int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	account, ok := store.accounts.get(id)
+	if !ok {
+		return nil
+	}
+
+	for entry := range store.entries.all() {
+		if entry.AccountID == id {
+			return referencedRowViolation("account", "entries", "entries_account_id_fkey")
+		}
+	}
+	for transfer := range store.transfers.all() {
+		if transfer.FromAccountID == id {
+			return referencedRowViolation("account", "transfers", "transfers_from_account_id_fkey")
+		}
+		if transfer.ToAccountID == id {
+			return referencedRowViolation("account", "transfers", "transfers_to_account_id_fkey")
+		}
+	}
+
+	deleteWhere(store.payees, func(payee Payee) bool {
+		return payee.AccountID == id
+	})
+	deleteWhere(store.paymentRequests, func(paymentRequest PaymentRequest) bool {
+		return paymentRequest.ToAccountID == id
+	})
+	deleteWhere(store.transferApprovals, func(approval TransferApproval) bool {
+		return approval.FromAccountID == id || approval.ToAccountID == id
+	})
+	delete(store.accountHolders, id)
+	delete(store.accountNumbers, account.AccountNumber)
+	store.accounts.delete(id)
+
+	return nil
+}
+
+// deleteWhere deletes the rows of table that match, like ON DELETE CASCADE.
+func deleteWhere[T any](table *memoryTable[T], match func(T) bool) {
+	var ids []int64
+	for _, id := range table.ids {
+		if match(table.rows[id]) {
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ids {
+		table.delete(id)
+	}
+}
+
+func (store *MemoryStore) CreateAccountHolder(ctx context.Context, arg CreateAccountHolderParams) (AccountHolder, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	switch arg.Role {
+	case AccountHolderRolePrimary, AccountHolderRoleJoint, AccountHolderRoleViewOnly:
+	default:
+		return AccountHolder{}, checkViolation("account_holders", "account_holders_role_check")
+	}
+	switch arg.Status {
+	case AccountHolderStatusPending, AccountHolderStatusAccepted:
+	default:
+		return AccountHolder{}, checkViolation("account_holders", "account_holders_status_check")
+	}
+	if _, ok := store.accountHolder(arg.AccountID, arg.Username); ok {
+		return AccountHolder{}, uniqueViolation("account_holders", "account_holders_pkey")
+	}
+	if _, ok := store.accounts.get(arg.AccountID); !ok {
+		return AccountHolder{}, foreignKeyViolation("account_holders", "account_holders_account_id_fkey")
+	}
+	if _, ok := store.users[arg.Username]; !ok {
+		return AccountHolder{}, foreignKeyViolation("account_holders", "account_holders_username_fkey")
+	}
+
+	holder := AccountHolder{
+		AccountID: arg.AccountID,
+		Username:  arg.Username,
+		Role:      arg.Role,
+		CreatedAt: now(),
+		Status:    arg.Status,
+	}
+	store.accountHolders[holder.AccountID] = append(store.accountHolders[holder.AccountID], holder)
+
+	return holder, nil
+}
+
+func (store *MemoryStore) accountHolder(accountID int64, username string) (AccountHolder, bool) {
+	for _, holder := range store.accountHolders[accountID] {
+		if holder.Username == username {
+			return holder, true
+		}
+	}
+	return AccountHolder{}, false
+}
+
+func (store *MemoryStore) GetAccountHolder(ctx context.Context, arg GetAccountHolderParams) (AccountHolder, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	holder, ok := store.accountHolder(arg.AccountID, arg.Username)
+	if !ok {
+		return holder, ErrRecordNotFound
+	}
+	return holder, nil
+}
+
+func (store *MemoryStore) AcceptAccountHolder(ctx context.Context, arg AcceptAccountHolderParams) (AccountHolder, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	holders := store.accountHolders[arg.AccountID]
+	for i := range holders {
+		if holders[i].Username == arg.Username && holders[i].Status == AccountHolderStatusPending {
+			holders[i].Status = AccountHolderStatusAccepted
+			return holders[i], nil
+		}
+	}
+	return AccountHolder{}, ErrRecordNotFound
+}
+
+func (store *MemoryStore) ListAccountHolders(ctx context.Context, accountID int64) ([]AccountHolder, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return append([]AccountHolder{}, store.accountHolders[accountID]...), nil
+}
+
+func (store *MemoryStore) ListAccountInvitations(ctx context.Context, username string) ([]AccountHolder, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	invitations := []AccountHolder{}
+	for _, holders := range store.accountHolders {
+		for _, holder := range holders {
+			if holder.Username == username && holder.Status == AccountHolderStatusPending {
+				invitations = append(invitations, holder)
+			}
+		}
+	}
+	slices.SortFunc(invitations, func(a, b AccountHolder) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return invitations, nil
+}
+
+func (store *MemoryStore) DeleteAccountHolder(ctx context.Context, arg DeleteAccountHolderParams) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.accountHolders[arg.AccountID] = slices.DeleteFunc(store.accountHolders[arg.AccountID], func(holder AccountHolder) bool {
+		return holder.Username == arg.Username
+	})
+	return nil
+}
+
+func (store *MemoryStore) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return cloneAuditLog(store.auditLog.insert(func(id int64) AuditLog {
+		return AuditLog{
+			ID:           id,
+			Actor:        arg.Actor,
+			Action:       arg.Action,
+			ResourceType: arg.ResourceType,
+			ResourceID:   arg.ResourceID,
+			ClientIp:     arg.ClientIp,
+			UserAgent:    arg.UserAgent,
+			RequestID:    arg.RequestID,
+			Before:       slices.Clone(arg.Before),
+			After:        slices.Clone(arg.After),
+			CreatedAt:    now(),
+		}
+	})), nil
+}
+
+// ListAuditLogs lists the matching entries, the most recent first.
+func (store *MemoryStore) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	logs := page(store.auditLog.allReversed(), func(entry AuditLog) bool {
+		return (arg.Actor == "" || entry.Actor == arg.Actor) &&
+			(arg.ResourceType == "" || entry.ResourceType == arg.ResourceType) &&
+			(arg.ResourceID == "" || entry.ResourceID == arg.ResourceID) &&
+			!entry.CreatedAt.Before(arg.Since) && entry.CreatedAt.Before(arg.Until)
+	}, arg.Limit, arg.Offset)
+	for i, entry := range logs {
+		logs[i] = cloneAuditLog(entry)
+	}
+	return logs, nil
+}
+
+// cloneAuditLog copies the values of an entry, so that callers cannot change
+// the ones in the store.
+func cloneAuditLog(entry AuditLog) AuditLog {
+	entry.Before = slices.Clone(entry.Before)
+	entry.After = slices.Clone(entry.After)
+	return entry
+}
+
+func (store *MemoryStore) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.accounts.get(arg.AccountID); !ok {
+		return Entry{}, foreignKeyViolation("entries", "entries_account_id_fkey")
+	}
+	if _, ok := store.transfers.get(arg.TransferID.Int64); arg.TransferID.Valid && !ok {
+		return Entry{}, foreignKeyViolation("entries", "entries_transfer_id_fkey")
+	}
+	return store.insertEntry(arg.AccountID, arg.Amount, arg.TransferID, now()), nil
+}
+
+func (store *MemoryStore) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entry, ok := store.entries.get(id)
+	if !ok {
+		return entry, ErrRecordNotFound
+	}
+	return cloneEntry(entry), nil
+}
+
+func (store *MemoryStore) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entries := page(store.entries.all(), func(entry Entry) bool {
+		return entry.AccountID == arg.AccountID
+	}, arg.Limit, arg.Offset)
+	for i, entry := range entries {
+		entries[i] = cloneEntry(entry)
+	}
+	return entries, nil
+}
+
+func (store *MemoryStore) GetPreviousEntryHash(ctx context.Context, arg GetPreviousEntryHashParams) ([]byte, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	hash, ok := store.previousEntryHash(arg.AccountID, arg.ID)
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+	return slices.Clone(hash), nil
+}
+
+func (store *MemoryStore) ChainEntry(ctx context.Context, arg ChainEntryParams) (Entry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	entry, ok := store.entries.get(arg.ID)
+	if !ok {
+		return entry, ErrRecordNotFound
+	}
+	entry.PrevHash = slices.Clone(arg.PrevHash)
+	entry.Hash = slices.Clone(arg.Hash)
+	store.entries.update(entry.ID, entry)
+	return cloneEntry(entry), nil
+}
+
+// cloneEntry copies the hashes of an entry, so that callers cannot change
+// the ones in the store.
+func cloneEntry(entry Entry) Entry {
+	entry.PrevHash = slices.Clone(entry.PrevHash)
+	entry.Hash = slices.Clone(entry.Hash)
+	return entry
+}
+
+// ListLedgerEntries lists the entries after the given one in the order of
+// their accounts and ids, along with their transfers.
+func (store *MemoryStore) ListLedgerEntries(ctx context.Context, arg ListLedgerEntriesParams) ([]ListLedgerEntriesRow, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	accountIDs := slices.Sorted(maps.Keys(store.accountEntries))
+	items := []ListLedgerEntriesRow{}
+	for _, accountID := range accountIDs {
+		if accountID < arg.AfterAccountID {
+			continue
+		}
+		for _, id := range store.accountEntries[accountID] {
+			if accountID == arg.AfterAccountID && id <= arg.AfterID {
+				continue
+			}
+			if len(items) == int(arg.Limit) {
+				return items, nil
+			}
+
+			entry, _ := store.entries.get(id)
+			row := ListLedgerEntriesRow{
+				ID:                id,
+				AccountID:         entry.AccountID,
+				Amount:            entry.Amount,
+				CreatedAt:         entry.CreatedAt,
+				TransferID:        entry.TransferID,
+				PrevHash:          slices.Clone(entry.PrevHash),
+				Hash:              slices.Clone(entry.Hash),
+				TransferCreatedAt: time.Unix(0, 0),
+			}
+			if transfer, ok := store.transfers.get(entry.TransferID.Int64); entry.TransferID.Valid && ok {
+				row.TransferFromAccountID = transfer.FromAccountID
+				row.TransferToAccountID = transfer.ToAccountID
+				row.TransferAmount = transfer.Amount
+				row.TransferDescription = transfer.Description
+				row.TransferReference = transfer.Reference
+				row.TransferCategory = transfer.Category
+				row.TransferCreatedAt = transfer.CreatedAt
+			}
+			items = append(items, row)
+		}
+	}
+	return items, nil
+}
+
+// ListUncheckpointedHeads lists the latest chained entry of each account
+// whose chain has grown since its last checkpoint.
+func (store *MemoryStore) ListUncheckpointedHeads(ctx context.Context, limit int32) ([]ListUncheckpointedHeadsRow, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	checkpointed := make(map[int64]int64)
+	for checkpoint := range store.ledgerCheckpoints.all() {
+		checkpointed[checkpoint.AccountID] = max(checkpointed[checkpoint.AccountID], checkpoint.EntryID)
+	}
+
+	items := []ListUncheckpointedHeadsRow{}
+	for _, accountID := range slices.Sorted(maps.Keys(store.accountEntries)) {
+		if len(items) == int(limit) {
+			break
+		}
+
+		ids := store.accountEntries[accountID]
+		for i := len(ids) - 1; i >= 0; i-- {
+			entry, _ := store.entries.get(ids[i])
+			if entry.Hash == nil {
+				continue
+			}
+			if entry.ID > checkpointed[accountID] {
+				items = append(items, ListUncheckpointedHeadsRow{
+					AccountID: accountID,
+					ID:        entry.ID,
+					Hash:      slices.Clone(entry.Hash),
+				})
+			}
+			break
+		}
+	}
+	return items, nil
+}
+
+func (store *MemoryStore) CreateLedgerCheckpoint(ctx context.Context, arg CreateLedgerCheckpointParams) (LedgerCheckpoint, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	checkpoint := store.ledgerCheckpoints.insert(func(id int64) LedgerCheckpoint {
+		return LedgerCheckpoint{
+			ID:        id,
+			AccountID: arg.AccountID,
+			EntryID:   arg.EntryID,
+			Hash:      slices.Clone(arg.Hash),
+			Signature: slices.Clone(arg.Signature),
+			CreatedAt: now(),
+		}
+	})
+	return cloneLedgerCheckpoint(checkpoint), nil
+}
+
+func (store *MemoryStore) ListLedgerCheckpoints(ctx context.Context, arg ListLedgerCheckpointsParams) ([]LedgerCheckpoint, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	checkpoints := page(store.ledgerCheckpoints.all(), func(checkpoint LedgerCheckpoint) bool {
+		return checkpoint.ID > arg.AfterID
+	}, arg.Limit, 0)
+	for i, checkpoint := range checkpoints {
+		checkpoints[i] = cloneLedgerCheckpoint(checkpoint)
+	}
+	return checkpoints, nil
+}
+
+// cloneLedgerCheckpoint copies the hash and signature of a checkpoint, so
+// that callers cannot change the ones in the store.
+func cloneLedgerCheckpoint(checkpoint LedgerCheckpoint) LedgerCheckpoint {
+	checkpoint.Hash = slices.Clone(checkpoint.Hash)
+	checkpoint.Signature = slices.Clone(checkpoint.Signature)
+	return checkpoint
+}
+
+func (store *MemoryStore) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.accounts.get(arg.FromAccountID); !ok {
+		return Transfer{}, foreignKeyViolation("transfers", "transfers_from_account_id_fkey")
+	}
+	if _, ok := store.accounts.get(arg.ToAccountID); !ok {
+		return Transfer{}, foreignKeyViolation("transfers", "transfers_to_account_id_fkey")
+	}
+
+	return store.transfers.insert(func(id int64) Transfer {
+		return Transfer{
+			ID:            id,
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			CreatedAt:     now(),
+			Description:   arg.Description,
+			Reference:     arg.Reference,
+			Category:      arg.Category,
+		}
+	}), nil
+}
+
+func (store *MemoryStore) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	transfer, ok := store.transfers.get(id)
+	if !ok {
+		return transfer, ErrRecordNotFound
+	}
+	return transfer, nil
+}
+
+func (store *MemoryStore) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return page(store.transfers.all(), func(transfer Transfer) bool {
+		return transfer.FromAccountID == arg.FromAccountID || transfer.ToAccountID == arg.ToAccountID
+	}, arg.Limit, arg.Offset), nil
+}
+
+// SearchTransfers matches the query as a case-insensitive substring, like
+// ILIKE without wildcards.
+func (store *MemoryStore) SearchTransfers(ctx context.Context, arg SearchTransfersParams) ([]Transfer, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	query := strings.ToLower(arg.Query)
+	return page(store.transfers.all(), func(transfer Transfer) bool {
+		return (transfer.FromAccountID == arg.AccountID || transfer.ToAccountID == arg.AccountID) &&
+			(arg.Category == "" || transfer.Category == arg.Category) &&
+			(query == "" ||
+				strings.Contains(strings.ToLower(transfer.Description), query) ||
+				strings.Contains(strings.ToLower(transfer.Reference), query))
+	}, arg.Limit, arg.Offset), nil
+}
+
+func (store *MemoryStore) CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for payee := range store.payees.all() {
+		if payee.Owner != arg.Owner {
+			continue
+		}
+		if payee.Nickname == arg.Nickname {
+			return Payee{}, uniqueViolation("payees", "owner_nickname_key")
+		}
+		if payee.AccountID == arg.AccountID {
+			return Payee{}, uniqueViolation("payees", "owner_account_key")
+		}
+	}
+	if _, ok := store.users[arg.Owner]; !ok {
+		return Payee{}, foreignKeyViolation("payees", "payees_owner_fkey")
+	}
+	if _, ok := store.accounts.get(arg.AccountID); !ok {
+		return Payee{}, foreignKeyViolation("payees", "payees_account_id_fkey")
+	}
+
+	return store.payees.insert(func(id int64) Payee {
+		return Payee{
+			ID:        id,
+			Owner:     arg.Owner,
+			Nickname:  arg.Nickname,
+			AccountID: arg.AccountID,
+			Currency:  arg.Currency,
+			CreatedAt: now(),
+		}
+	}), nil
+}
+
+func (store *MemoryStore) GetPayee(ctx context.Context, id int64) (Payee, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	payee, ok := store.payees.get(id)
+	if !ok {
+		return payee, ErrRecordNotFound
+	}
+	return payee, nil
+}
+
+func (store *MemoryStore) GetPayeeByAccount(ctx context.Context, arg GetPayeeByAccountParams) (Payee, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	for payee := range store.payees.all() {
+		if payee.Owner == arg.Owner && payee.AccountID == arg.AccountID {
+			return payee, nil
+		}
+	}
+	return Payee{}, ErrRecordNotFound
+}
+
+func (store *MemoryStore) ListPayees(ctx context.Context, arg ListPayeesParams) ([]Payee, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return page(store.payees.all(), func(payee Payee) bool {
+		return payee.Owner == arg.Owner
+	}, arg.Limit, arg.Offset), nil
+}
+
+func (store *MemoryStore) UpdatePayee(ctx context.Context, arg UpdatePayeeParams) (Payee, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	payee, ok := store.payees.get(arg.ID)
+	if !ok {
+		return payee, ErrRecordNotFound
+	}
+	for other := range store.payees.all() {
+		if other.ID != payee.ID && other.Owner == payee.Owner && other.Nickname == arg.Nickname {
+			return Payee{}, uniqueViolation("payees", "owner_nickname_key")
+		}
+	}
+
+	payee.Nickname = arg.Nickname
+	store.payees.update(payee.ID, payee)
+	return payee, nil
+}
+
+func (store *MemoryStore) DeletePayee(ctx context.Context, id int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.payees.delete(id)
+	return nil
+}
+
+func (store *MemoryStore) CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if arg.Amount <= 0 {
+		return PaymentRequest{}, checkViolation("payment_requests", "payment_requests_amount_check")
+	}
+	if _, ok := store.users[arg.Requester]; !ok {
+		return PaymentRequest{}, foreignKeyViolation("payment_requests", "payment_requests_requester_fkey")
+	}
+	if _, ok := store.users[arg.Payer]; !ok {
+		return PaymentRequest{}, foreignKeyViolation("payment_requests", "payment_requests_payer_fkey")
+	}
+	if _, ok := store.accounts.get(arg.ToAccountID); !ok {
+		return PaymentRequest{}, foreignKeyViolation("payment_requests", "payment_requests_to_account_id_fkey")
+	}
+
+	return store.paymentRequests.insert(func(id int64) PaymentRequest {
+		return PaymentRequest{
+			ID:          id,
+			Requester:   arg.Requester,
+			Payer:       arg.Payer,
+			ToAccountID: arg.ToAccountID,
+			Amount:      arg.Amount,
+			Currency:    arg.Currency,
+			Description: arg.Description,
+			Status:      PaymentRequestStatusPending,
+			ExpiresAt:   arg.ExpiresAt,
+			CreatedAt:   now(),
+		}
+	}), nil
+}
+
+func (store *MemoryStore) GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	paymentRequest, ok := store.paymentRequests.get(id)
+	if !ok {
+		return paymentRequest, ErrRecordNotFound
+	}
+	return paymentRequest, nil
+}
+
+func (store *MemoryStore) GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error) {
+	return store.GetPaymentRequest(ctx, id)
+}
+
+func (store *MemoryStore) ListPaymentRequestsByPayer(ctx context.Context, arg ListPaymentRequestsByPayerParams) ([]PaymentRequest, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return page(store.paymentRequests.all(), func(paymentRequest PaymentRequest) bool {
+		return paymentRequest.Payer == arg.Payer
+	}, arg.Limit, arg.Offset), nil
+}
+
+func (store *MemoryStore) ListPaymentRequestsByRequester(ctx context.Context, arg ListPaymentRequestsByRequesterParams) ([]PaymentRequest, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return page(store.paymentRequests.all(), func(paymentRequest PaymentRequest) bool {
+		return paymentRequest.Requester == arg.Requester
+	}, arg.Limit, arg.Offset), nil
+}
+
+func (store *MemoryStore) UpdatePaymentRequestStatus(ctx context.Context, arg UpdatePaymentRequestStatusParams) (PaymentRequest, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	paymentRequest, ok := store.paymentRequests.get(arg.ID)
+	if !ok || paymentRequest.Status != PaymentRequestStatusPending {
+		return PaymentRequest{}, ErrRecordNotFound
+	}
+	if !validPaymentRequestStatus(arg.Status) {
+		return PaymentRequest{}, checkViolation("payment_requests", "payment_requests_status_check")
+	}
+	if _, ok := store.transfers.get(arg.TransferID.Int64); arg.TransferID.Valid && !ok {
+		return PaymentRequest{}, foreignKeyViolation("payment_requests", "payment_requests_transfer_id_fkey")
+	}
+
+	paymentRequest.Status = arg.Status
+	paymentRequest.TransferID = arg.TransferID
+	paymentRequest.RespondedAt = now()
+	store.paymentRequests.update(paymentRequest.ID, paymentRequest)
+	return paymentRequest, nil
+}
+
+func validPaymentRequestStatus(status string) bool {
+	switch status {
+	case PaymentRequestStatusPending, PaymentRequestStatusAccepted, PaymentRequestStatusDeclined, PaymentRequestStatusExpired:
+		return true
+	}
+	return false
+}
+
+func (store *MemoryStore) ExpirePaymentRequests(ctx context.Context) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var expired int64
+	for paymentRequest := range store.paymentRequests.all() {
+		if paymentRequest.Status == PaymentRequestStatusPending && !paymentRequest.ExpiresAt.After(time.Now()) {
+			paymentRequest.Status = PaymentRequestStatusExpired
+			store.paymentRequests.update(paymentRequest.ID, paymentRequest)
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func (store *MemoryStore) CreateTransferApproval(ctx context.Context, arg CreateTransferApprovalParams) (TransferApproval, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.createTransferApproval(arg)
+}
+
+func (store *MemoryStore) createTransferApproval(arg CreateTransferApprovalParams) (TransferApproval, error) {
+	if arg.Amount <= 0 {
+		return TransferApproval{}, checkViolation("transfer_approvals", "transfer_approvals_amount_check")
+	}
+	if _, ok := store.users[arg.Maker]; !ok {
+		return TransferApproval{}, foreignKeyViolation("transfer_approvals", "transfer_approvals_maker_fkey")
+	}
+	if _, ok := store.accounts.get(arg.FromAccountID); !ok {
+		return TransferApproval{}, foreignKeyViolation("transfer_approvals", "transfer_approvals_from_account_id_fkey")
+	}
+	if _, ok := store.accounts.get(arg.ToAccountID); !ok {
+		return TransferApproval{}, foreignKeyViolation("transfer_approvals", "transfer_approvals_to_account_id_fkey")
+	}
+	if arg.PaymentRequestID.Valid {
+		if _, ok := store.paymentRequests.get(arg.PaymentRequestID.Int64); !ok {
+			return TransferApproval{}, foreignKeyViolation("transfer_approvals", "transfer_approvals_payment_request_id_fkey")
+		}
+		for approval := range store.transferApprovals.all() {
+			if approval.PaymentRequestID == arg.PaymentRequestID && approval.Status == TransferApprovalStatusPending {
+				return TransferApproval{}, uniqueViolation("transfer_approvals", "transfer_approvals_payment_request_id_idx")
+			}
+		}
+	}
+
+	return store.transferApprovals.insert(func(id int64) TransferApproval {
+		return TransferApproval{
+			ID:               id,
+			Maker:            arg.Maker,
+			FromAccountID:    arg.FromAccountID,
+			ToAccountID:      arg.ToAccountID,
+			Amount:           arg.Amount,
+			Description:      arg.Description,
+			Reference:        arg.Reference,
+			Category:         arg.Category,
+			Status:           TransferApprovalStatusPending,
+			ExpiresAt:        arg.ExpiresAt,
+			CreatedAt:        now(),
+			PaymentRequestID: arg.PaymentRequestID,
+		}
+	}), nil
+}
+
+func (store *MemoryStore) GetTransferApproval(ctx context.Context, id int64) (TransferApproval, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	approval, ok := store.transferApprovals.get(id)
+	if !ok {
+		return approval, ErrRecordNotFound
+	}
+	return approval, nil
+}
+
+func (store *MemoryStore) GetTransferApprovalForUpdate(ctx context.Context, id int64) (TransferApproval, error) {
+	return store.GetTransferApproval(ctx, id)
+}
+
+func (store *MemoryStore) ListPendingTransferApprovals(ctx context.Context, arg ListPendingTransferApprovalsParams) ([]TransferApproval, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return page(store.transferApprovals.all(), func(approval TransferApproval) bool {
+		return approval.Status == TransferApprovalStatusPending
+	}, arg.Limit, arg.Offset), nil
+}
+
+func (store *MemoryStore) ListPendingTransferApprovalsForHolder(ctx context.Context, arg ListPendingTransferApprovalsForHolderParams) ([]TransferApproval, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return page(store.transferApprovals.all(), func(approval TransferApproval) bool {
+		holder, ok := store.accountHolder(approval.FromAccountID, arg.Username)
+		return ok && holder.Role != AccountHolderRoleViewOnly && holder.Status == AccountHolderStatusAccepted &&
+			approval.Status == TransferApprovalStatusPending && approval.Maker != arg.Username
+	}, arg.Limit, arg.Offset), nil
+}
+
+func (store *MemoryStore) DecideTransferApproval(ctx context.Context, arg DecideTransferApprovalParams) (TransferApproval, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	approval, ok := store.transferApprovals.get(arg.ID)
+	if !ok {
+		return approval, ErrRecordNotFound
+	}
+	switch arg.Status {
+	case TransferApprovalStatusPending, TransferApprovalStatusApproved, TransferApprovalStatusRejected, TransferApprovalStatusExpired:
+	default:
+		return TransferApproval{}, checkViolation("transfer_approvals", "transfer_approvals_status_check")
+	}
+	if _, ok := store.transfers.get(arg.TransferID.Int64); arg.TransferID.Valid && !ok {
+		return TransferApproval{}, foreignKeyViolation("transfer_approvals", "transfer_approvals_transfer_id_fkey")
+	}
+
+	return store.decideTransferApproval(approval, arg.Status, arg.Checker, arg.TransferID), nil
+}
+
+func (store *MemoryStore) decideTransferApproval(approval TransferApproval, status string, checker string, transferID pgtype.Int8) TransferApproval {
+	approval.Status = status
+	approval.Checker = checker
+	approval.TransferID = transferID
+	approval.DecidedAt = now()
+	store.transferApprovals.update(approval.ID, approval)
+	return approval
+}
+
+func (store *MemoryStore) ExpireTransferApprovals(ctx context.Context) ([]TransferApproval, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.expireTransferApprovals(), nil
+}
+
+func (store *MemoryStore) expireTransferApprovals() []TransferApproval {
+	approvals := []TransferApproval{}
+	for approval := range store.transferApprovals.all() {
+		if approval.Status == TransferApprovalStatusPending && !approval.ExpiresAt.After(time.Now()) {
+			approval.Status = TransferApprovalStatusExpired
+			store.transferApprovals.update(approval.ID, approval)
+			approvals = append(approvals, approval)
+		}
+	}
+	return approvals
+}
+
+func (store *MemoryStore) CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[arg.Username]; !ok {
+		return Notification{}, foreignKeyViolation("notifications", "notifications_username_fkey")
+	}
+
+	return store.notify(arg.Username, arg.Kind, arg.Message), nil
+}
+
+func (store *MemoryStore) ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return page(store.notifications.allReversed(), func(notification Notification) bool {
+		return notification.Username == arg.Username
+	}, arg.Limit, arg.Offset), nil
+}
+
+func (store *MemoryStore) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return cloneOutboxEvent(store.createOutboxEvent(arg)), nil
+}
+
+func (store *MemoryStore) GetOutboxEvent(ctx context.Context, id int64) (OutboxEvent, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	event, ok := store.outboxEvents.get(id)
+	if !ok {
+		return event, ErrRecordNotFound
+	}
+	return cloneOutboxEvent(event), nil
+}
+
+// cloneOutboxEvent copies the payload of an event, so that callers cannot
+// change the one in the store.
+func cloneOutboxEvent(event OutboxEvent) OutboxEvent {
+	event.Payload = slices.Clone(event.Payload)
+	return event
+}
+
+func (store *MemoryStore) GetLastOutboxEventID(ctx context.Context) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if len(store.outboxEvents.ids) == 0 {
+		return 0, nil
+	}
+	return store.outboxEvents.ids[len(store.outboxEvents.ids)-1], nil
+}
+
+func (store *MemoryStore) ListAccountEvents(ctx context.Context, arg ListAccountEventsParams) ([]OutboxEvent, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	events := page(store.outboxEvents.all(), func(event OutboxEvent) bool {
+		return event.EventType == EventBalanceChanged && event.AggregateID == arg.AccountID && event.ID > arg.AfterID
+	}, arg.BatchSize, 0)
+	for i := range events {
+		events[i] = cloneOutboxEvent(events[i])
+	}
+	return events, nil
+}
+
+// FanOutOutboxEvents queues a delivery of up to limit events that have not
+// been fanned out yet to every webhook, and returns the number of deliveries.
+func (store *MemoryStore) FanOutOutboxEvents(ctx context.Context, limit int32) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	events := page(store.outboxEvents.all(), func(event OutboxEvent) bool {
+		return !event.FannedOut
+	}, limit, 0)
+
+	var deliveries int64
+	for _, event := range events {
+		event.FannedOut = true
+		store.outboxEvents.update(event.ID, event)
+
+		for webhook := range store.webhooks.all() {
+			createdAt := now()
+			store.webhookDeliveries.insert(func(id int64) WebhookDelivery {
+				return WebhookDelivery{
+					ID:            id,
+					WebhookID:     webhook.ID,
+					EventID:       event.ID,
+					Status:        WebhookDeliveryStatusPending,
+					NextAttemptAt: createdAt,
+					CreatedAt:     createdAt,
+				}
+			})
+			deliveries++
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (store *MemoryStore) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.users[arg.Owner]; !ok {
+		return Webhook{}, foreignKeyViolation("webhooks", "webhooks_owner_fkey")
+	}
+
+	return store.webhooks.insert(func(id int64) Webhook {
+		return Webhook{
+			ID:        id,
+			Owner:     arg.Owner,
+			Url:       arg.Url,
+			Secret:    arg.Secret,
+			CreatedAt: now(),
+		}
+	}), nil
+}
+
+func (store *MemoryStore) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	webhook, ok := store.webhooks.get(id)
+	if !ok {
+		return webhook, ErrRecordNotFound
+	}
+	return webhook, nil
+}
+
+func (store *MemoryStore) ListWebhooks(ctx context.Context, arg ListWebhooksParams) ([]Webhook, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return page(store.webhooks.all(), func(Webhook) bool {
+		return true
+	}, arg.Limit, arg.Offset), nil
+}
+
+// DeleteWebhook deletes a webhook along with its deliveries.
+func (store *MemoryStore) DeleteWebhook(ctx context.Context, id int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	deleteWhere(store.webhookDeliveries, func(delivery WebhookDelivery) bool {
+		return delivery.WebhookID == id
+	})
+	store.webhooks.delete(id)
+	return nil
+}
+
+// ClaimWebhookDeliveries leases up to BatchSize deliveries that are due until
+// LeaseUntil, the ones that have been due the longest first.
+func (store *MemoryStore) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var due []WebhookDelivery
+	for delivery := range store.webhookDeliveries.all() {
+		if delivery.Status == WebhookDeliveryStatusPending && !delivery.NextAttemptAt.After(time.Now()) {
+			due = append(due, delivery)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b WebhookDelivery) int {
+		return a.NextAttemptAt.Compare(b.NextAttemptAt)
+	})
+
+	deliveries := page(slices.Values(due), func(WebhookDelivery) bool {
+		return true
+	}, arg.BatchSize, 0)
+	for i := range deliveries {
+		deliveries[i].NextAttemptAt = arg.LeaseUntil
+		store.webhookDeliveries.update(deliveries[i].ID, deliveries[i])
+	}
+	return deliveries, nil
+}
+
+func (store *MemoryStore) MarkWebhookDelivered(ctx context.Context, id int64) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delivery, ok := store.webhookDeliveries.get(id)
+	if !ok {
+		return nil
+	}
+
+	delivery.Status = WebhookDeliveryStatusDelivered
+	delivery.Attempts++
+	delivery.LastError = ""
+	delivery.DeliveredAt = now()
+	store.webhookDeliveries.update(id, delivery)
+	return nil
+}
+
+func (store *MemoryStore) MarkWebhookDeliveryFailed(ctx context.Context, arg MarkWebhookDeliveryFailedParams) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delivery, ok := store.webhookDeliveries.get(arg.ID)
+	if !ok {
+		return nil
+	}
+	if !validWebhookDeliveryStatus(arg.Status) {
+		return checkViolation("webhook_deliveries", "webhook_deliveries_status_check")
+	}
+
+	delivery.Status = arg.Status
+	delivery.Attempts++
+	delivery.LastError = arg.LastError
+	delivery.NextAttemptAt = arg.NextAttemptAt
+	store.webhookDeliveries.update(arg.ID, delivery)
+	return nil
+}
+
+func validWebhookDeliveryStatus(status string) bool {
+	switch status {
+	case WebhookDeliveryStatusPending, WebhookDeliveryStatusDelivered, WebhookDeliveryStatusDead:
+		return true
+	}
+	return false
+}
+
+func (store *MemoryStore) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delivery, ok := store.webhookDeliveries.get(id)
+	if !ok {
+		return delivery, ErrRecordNotFound
+	}
+	return delivery, nil
+}
+
+func (store *MemoryStore) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return page(store.webhookDeliveries.all(), func(delivery WebhookDelivery) bool {
+		return delivery.Status == arg.Status
+	}, arg.Limit, arg.Offset), nil
+}
+
+func (store *MemoryStore) ReplayWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delivery, ok := store.webhookDeliveries.get(id)
+	if !ok {
+		return delivery, ErrRecordNotFound
+	}
+
+	delivery.Status = WebhookDeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.LastError = ""
+	delivery.NextAttemptAt = now()
+	store.webhookDeliveries.update(id, delivery)
+	return delivery, nil
+}
@@ -0,0 +1,610 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MemoryStore is a Store that keeps its data in memory, for development,
+// demos and load tests that should not depend on Postgres. It enforces the
+// constraints of the schema in db/migration and returns the same errors as
+// SQLStore.
+//
+// Every call holds a single lock. Transactions check everything that can fail
+// before they change anything, so they are atomic and serializable and never
+// need to be retried.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	users map[string]User
+	// emails indexes users by their unique email.
+	emails         map[string]string
+	accounts       *memoryTable[Account]
+	accountNumbers map[string]int64
+	// accountEntries are the ids of the entries of each account in ascending
+	// order.
+	accountEntries map[int64][]int64
+	// accountHolders are the holders of each account, in the order they were
+	// added.
+	accountHolders    map[int64][]AccountHolder
+	auditLog          *memoryTable[AuditLog]
+	entries           *memoryTable[Entry]
+	ledgerCheckpoints *memoryTable[LedgerCheckpoint]
+	transfers         *memoryTable[Transfer]
+	payees            *memoryTable[Payee]
+	paymentRequests   *memoryTable[PaymentRequest]
+	transferApprovals *memoryTable[TransferApproval]
+	notifications     *memoryTable[Notification]
+	outboxEvents      *memoryTable[OutboxEvent]
+	webhooks          *memoryTable[Webhook]
+	webhookDeliveries *memoryTable[WebhookDelivery]
+
+	events accountEventHub
+}
+
+func NewMemoryStore() Store {
+	return &MemoryStore{
+		users:             make(map[string]User),
+		emails:            make(map[string]string),
+		accounts:          newMemoryTable[Account](),
+		accountNumbers:    make(map[string]int64),
+		accountEntries:    make(map[int64][]int64),
+		accountHolders:    make(map[int64][]AccountHolder),
+		auditLog:          newMemoryTable[AuditLog](),
+		entries:           newMemoryTable[Entry](),
+		ledgerCheckpoints: newMemoryTable[LedgerCheckpoint](),
+		transfers:         newMemoryTable[Transfer](),
+		payees:            newMemoryTable[Payee](),
+		paymentRequests:   newMemoryTable[PaymentRequest](),
+		transferApprovals: newMemoryTable[TransferApproval](),
+		notifications:     newMemoryTable[Notification](),
+		outboxEvents:      newMemoryTable[OutboxEvent](),
+		webhooks:          newMemoryTable[Webhook](),
+		webhookDeliveries: newMemoryTable[WebhookDelivery](),
+	}
+}
+
+// memoryTable holds the rows of a table with a bigserial primary key.
+type memoryTable[T any] struct {
+	rows map[int64]T
+	// ids are the ids of the rows in ascending order.
+	ids    []int64
+	lastID int64
+}
+
+func newMemoryTable[T any]() *memoryTable[T] {
+	return &memoryTable[T]{rows: make(map[int64]T)}
+}
+
+// insert adds the row built by newRow from the next id of the sequence.
+func (table *memoryTable[T]) insert(newRow func(id int64) T) T {
+	table.lastID++
+	row := newRow(table.lastID)
+	table.rows[table.lastID] = row
+	table.ids = append(table.ids, table.lastID)
+	return row
+}
+
+func (table *memoryTable[T]) get(id int64) (T, bool) {
+	row, ok := table.rows[id]
+	return row, ok
+}
+
+func (table *memoryTable[T]) update(id int64, row T) {
+	table.rows[id] = row
+}
+
+func (table *memoryTable[T]) delete(id int64) {
+	if _, ok := table.rows[id]; !ok {
+		return
+	}
+	delete(table.rows, id)
+	i, _ := slices.BinarySearch(table.ids, id)
+	table.ids = slices.Delete(table.ids, i, i+1)
+}
+
+// all returns the rows in the order of their ids.
+func (table *memoryTable[T]) all() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, id := range table.ids {
+			if !yield(table.rows[id]) {
+				return
+			}
+		}
+	}
+}
+
+// allReversed returns the rows in the reverse order of their ids.
+func (table *memoryTable[T]) allReversed() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := len(table.ids) - 1; i >= 0; i-- {
+			if !yield(table.rows[table.ids[i]]) {
+				return
+			}
+		}
+	}
+}
+
+// page returns the rows that match, skipping the first offset and returning
+// at most limit of them, like the LIMIT and OFFSET of a query.
+func page[T any](rows iter.Seq[T], match func(T) bool, limit int32, offset int32) []T {
+	items := []T{}
+	if limit <= 0 {
+		return items
+	}
+
+	for row := range rows {
+		if !match(row) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		items = append(items, row)
+		if len(items) == int(limit) {
+			break
+		}
+	}
+
+	return items
+}
+
+// now returns the current time at the precision of a timestamptz column.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// The constraint errors below are the ones Postgres would return, translated
+// the same way as those of SQLStore.
+
+func uniqueViolation(table string, constraint string) error {
+	return translateError(&pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           UniqueViolation,
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	})
+}
+
+func foreignKeyViolation(table string, constraint string) error {
+	return translateError(&pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           ForeignKeyViolation,
+		Message:        fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	})
+}
+
+// referencedRowViolation is returned when a row that is still referenced is
+// deleted.
+func referencedRowViolation(table string, referencingTable string, constraint string) error {
+	return translateError(&pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           ForeignKeyViolation,
+		Message:        fmt.Sprintf("update or delete on table %q violates foreign key constraint %q on table %q", table, constraint, referencingTable),
+		TableName:      referencingTable,
+		ConstraintName: constraint,
+	})
+}
+
+func checkViolation(table string, constraint string) error {
+	return translateError(&pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           CheckViolation,
+		Message:        fmt.Sprintf("new row for relation %q violates check constraint %q", table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	})
+}
+
+func (store *MemoryStore) TransferTx(ctx context.Context, arg TransferTXParams) (TransferTXResult, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.transfer(arg)
+}
+
+// transfer moves money between two accounts, or changes nothing if it fails.
+func (store *MemoryStore) transfer(arg TransferTXParams) (TransferTXResult, error) {
+	var result TransferTXResult
+
+	fromAccount, ok := store.accounts.get(arg.FromAccountID)
+	if !ok {
+		return result, foreignKeyViolation("transfers", "transfers_from_account_id_fkey")
+	}
+	if _, ok := store.accounts.get(arg.ToAccountID); !ok {
+		return result, foreignKeyViolation("transfers", "transfers_to_account_id_fkey")
+	}
+	fromBalance := fromAccount.Balance - arg.Amount
+	if arg.FromAccountID == arg.ToAccountID {
+		fromBalance = fromAccount.Balance
+	}
+	if fromBalance < 0 {
+		return result, ErrInsufficientFunds
+	}
+
+	createdAt := now()
+	result.Transfer = store.transfers.insert(func(id int64) Transfer {
+		return Transfer{
+			ID:            id,
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			CreatedAt:     createdAt,
+			Description:   arg.Description,
+			Reference:     arg.Reference,
+			Category:      arg.Category,
+		}
+	})
+	transferID := pgtype.Int8{Int64: result.Transfer.ID, Valid: true}
+	result.FromEntry = store.chainEntry(store.insertEntry(arg.FromAccountID, -arg.Amount, transferID, createdAt), result.Transfer)
+	result.ToEntry = store.chainEntry(store.insertEntry(arg.ToAccountID, arg.Amount, transferID, createdAt), result.Transfer)
+
+	result.FromAccount = store.addBalance(arg.FromAccountID, -arg.Amount)
+	result.ToAccount = store.addBalance(arg.ToAccountID, arg.Amount)
+	if arg.FromAccountID == arg.ToAccountID {
+		result.FromAccount = result.ToAccount
+	}
+
+	store.publish(EventTransferCompleted, result.Transfer.ID, newTransferCompletedEvent(result))
+	store.publish(EventBalanceChanged, result.FromAccount.ID, BalanceChangedEvent{
+		Account: result.FromAccount,
+		Entry:   result.FromEntry,
+	})
+	store.publish(EventBalanceChanged, result.ToAccount.ID, BalanceChangedEvent{
+		Account: result.ToAccount,
+		Entry:   result.ToEntry,
+	})
+
+	return result, nil
+}
+
+func (store *MemoryStore) insertEntry(accountID int64, amount int64, transferID pgtype.Int8, createdAt time.Time) Entry {
+	entry := store.entries.insert(func(id int64) Entry {
+		return Entry{
+			ID:         id,
+			AccountID:  accountID,
+			Amount:     amount,
+			CreatedAt:  createdAt,
+			TransferID: transferID,
+		}
+	})
+	store.accountEntries[accountID] = append(store.accountEntries[accountID], entry.ID)
+	return entry
+}
+
+// chainEntry chains an entry written by transfer to the previous entry of its
+// account, like addEntry.
+func (store *MemoryStore) chainEntry(entry Entry, transfer Transfer) Entry {
+	entry.PrevHash, _ = store.previousEntryHash(entry.AccountID, entry.ID)
+	entry.Hash = EntryHash(entry.PrevHash, entry, transfer)
+	store.entries.update(entry.ID, entry)
+	return cloneEntry(entry)
+}
+
+// previousEntryHash returns the hash of the entry of an account before the
+// one with the given id, and whether there is one.
+func (store *MemoryStore) previousEntryHash(accountID int64, id int64) ([]byte, bool) {
+	ids := store.accountEntries[accountID]
+	i, _ := slices.BinarySearch(ids, id)
+	if i == 0 {
+		return nil, false
+	}
+	prev, _ := store.entries.get(ids[i-1])
+	return prev.Hash, true
+}
+
+// addBalance adds amount to the balance of an account that must exist.
+func (store *MemoryStore) addBalance(accountID int64, amount int64) Account {
+	account, _ := store.accounts.get(accountID)
+	account.Balance += amount
+	store.accounts.update(accountID, account)
+	return account
+}
+
+func (store *MemoryStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// the owner is checked by the account, so the holder cannot fail
+	account, err := store.createAccount(arg)
+	if err != nil {
+		return account, err
+	}
+
+	store.accountHolders[account.ID] = append(store.accountHolders[account.ID], AccountHolder{
+		AccountID: account.ID,
+		Username:  account.Owner,
+		Role:      AccountHolderRolePrimary,
+		CreatedAt: account.CreatedAt,
+		Status:    AccountHolderStatusAccepted,
+	})
+	store.publish(EventAccountCreated, account.ID, account)
+
+	return account, nil
+}
+
+func (store *MemoryStore) AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var result AcceptPaymentRequestTxResult
+
+	paymentRequest, ok := store.paymentRequests.get(arg.PaymentRequestID)
+	if !ok {
+		return result, ErrRecordNotFound
+	}
+	if paymentRequest.Status != PaymentRequestStatusPending {
+		return result, ErrPaymentRequestNotPending
+	}
+	if !time.Now().Before(paymentRequest.ExpiresAt) {
+		return result, ErrPaymentRequestExpired
+	}
+
+	var err error
+	result.TransferTXResult, err = store.transfer(TransferTXParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   paymentRequest.ToAccountID,
+		Amount:        paymentRequest.Amount,
+		Description:   paymentRequest.Description,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	paymentRequest.Status = PaymentRequestStatusAccepted
+	paymentRequest.TransferID = pgtype.Int8{Int64: result.Transfer.ID, Valid: true}
+	paymentRequest.RespondedAt = now()
+	store.paymentRequests.update(paymentRequest.ID, paymentRequest)
+	result.PaymentRequest = paymentRequest
+
+	return result, nil
+}
+
+func (store *MemoryStore) RequestPaymentApprovalTx(ctx context.Context, arg RequestPaymentApprovalTxParams) (TransferApproval, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	paymentRequest, ok := store.paymentRequests.get(arg.PaymentRequestID)
+	if !ok {
+		return TransferApproval{}, ErrRecordNotFound
+	}
+	if paymentRequest.Status != PaymentRequestStatusPending {
+		return TransferApproval{}, ErrPaymentRequestNotPending
+	}
+	if !time.Now().Before(paymentRequest.ExpiresAt) {
+		return TransferApproval{}, ErrPaymentRequestExpired
+	}
+
+	approval, err := store.requestTransferApproval(CreateTransferApprovalParams{
+		Maker:            arg.Maker,
+		FromAccountID:    arg.FromAccountID,
+		ToAccountID:      paymentRequest.ToAccountID,
+		Amount:           paymentRequest.Amount,
+		Description:      paymentRequest.Description,
+		ExpiresAt:        arg.ExpiresAt,
+		PaymentRequestID: pgtype.Int8{Int64: paymentRequest.ID, Valid: true},
+	})
+	if errors.Is(err, ErrUniqueViolation) {
+		return approval, ErrPaymentRequestAwaitingApproval
+	}
+	return approval, err
+}
+
+func (store *MemoryStore) CreateTransferApprovalTx(ctx context.Context, arg CreateTransferApprovalParams) (TransferApproval, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	return store.requestTransferApproval(arg)
+}
+
+// requestTransferApproval creates a transfer approval and notifies the other
+// holders who are allowed to approve it.
+func (store *MemoryStore) requestTransferApproval(arg CreateTransferApprovalParams) (TransferApproval, error) {
+	approval, err := store.createTransferApproval(arg)
+	if err != nil {
+		return approval, err
+	}
+
+	message := fmt.Sprintf("%s is waiting for approval of a transfer of %d (approval %d)", arg.Maker, arg.Amount, approval.ID)
+	for _, holder := range store.accountHolders[arg.FromAccountID] {
+		if holder.Username == arg.Maker || holder.Role == AccountHolderRoleViewOnly || holder.Status != AccountHolderStatusAccepted {
+			continue
+		}
+		store.notify(holder.Username, NotificationTransferApprovalRequested, message)
+	}
+
+	return approval, nil
+}
+
+func (store *MemoryStore) ApproveTransferTx(ctx context.Context, arg DecideTransferTxParams) (ApproveTransferTxResult, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	var result ApproveTransferTxResult
+
+	approval, err := store.pendingTransferApproval(arg.TransferApprovalID)
+	if err != nil {
+		return result, err
+	}
+
+	var paymentRequest PaymentRequest
+	if approval.PaymentRequestID.Valid {
+		var ok bool
+		paymentRequest, ok = store.paymentRequests.get(approval.PaymentRequestID.Int64)
+		if !ok || paymentRequest.Status != PaymentRequestStatusPending {
+			// the payer declined the request while it waited for approval
+			return result, ErrPaymentRequestNotPending
+		}
+	}
+
+	result.TransferTXResult, err = store.transfer(TransferTXParams{
+		FromAccountID: approval.FromAccountID,
+		ToAccountID:   approval.ToAccountID,
+		Amount:        approval.Amount,
+		Description:   approval.Description,
+		Reference:     approval.Reference,
+		Category:      approval.Category,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if approval.PaymentRequestID.Valid {
+		paymentRequest.Status = PaymentRequestStatusAccepted
+		paymentRequest.TransferID = pgtype.Int8{Int64: result.Transfer.ID, Valid: true}
+		paymentRequest.RespondedAt = now()
+		store.paymentRequests.update(paymentRequest.ID, paymentRequest)
+	}
+
+	result.TransferApproval = store.decideTransferApproval(approval, TransferApprovalStatusApproved, arg.Checker, pgtype.Int8{Int64: result.Transfer.ID, Valid: true})
+
+	message := fmt.Sprintf("%s approved transfer approval %d", arg.Checker, approval.ID)
+	store.notify(approval.Maker, NotificationTransferApproved, message)
+
+	return result, nil
+}
+
+func (store *MemoryStore) RejectTransferTx(ctx context.Context, arg DecideTransferTxParams) (TransferApproval, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	approval, err := store.pendingTransferApproval(arg.TransferApprovalID)
+	if err != nil {
+		return approval, err
+	}
+
+	approval = store.decideTransferApproval(approval, TransferApprovalStatusRejected, arg.Checker, pgtype.Int8{})
+
+	message := fmt.Sprintf("%s rejected transfer approval %d", arg.Checker, approval.ID)
+	store.notify(approval.Maker, NotificationTransferRejected, message)
+
+	return approval, nil
+}
+
+func (store *MemoryStore) ExpireTransferApprovalsTx(ctx context.Context) (int64, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	approvals := store.expireTransferApprovals()
+	for _, approval := range approvals {
+		message := fmt.Sprintf("transfer approval %d expired without a decision", approval.ID)
+		store.notify(approval.Maker, NotificationTransferApprovalExpired, message)
+	}
+
+	return int64(len(approvals)), nil
+}
+
+// pendingTransferApproval returns a transfer approval that can still be
+// decided.
+func (store *MemoryStore) pendingTransferApproval(id int64) (TransferApproval, error) {
+	approval, ok := store.transferApprovals.get(id)
+	if !ok {
+		return approval, ErrRecordNotFound
+	}
+
+	if approval.Status != TransferApprovalStatusPending {
+		return approval, ErrTransferApprovalNotPending
+	}
+	if !time.Now().Before(approval.ExpiresAt) {
+		return approval, ErrTransferApprovalExpired
+	}
+
+	return approval, nil
+}
+
+// notify notifies a user, who must exist.
+func (store *MemoryStore) notify(username string, kind string, message string) Notification {
+	return store.notifications.insert(func(id int64) Notification {
+		return Notification{
+			ID:        id,
+			Username:  username,
+			Kind:      kind,
+			Message:   message,
+			CreatedAt: now(),
+		}
+	})
+}
+
+// publish records an event in the outbox. Like the trigger of the outbox
+// table, it notifies the listeners of every account.balance_changed event.
+func (store *MemoryStore) publish(eventType string, aggregateID int64, payload any) OutboxEvent {
+	// the payloads are rows of the store, which always marshal
+	data, _ := json.Marshal(payload)
+
+	return store.createOutboxEvent(CreateOutboxEventParams{
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+	})
+}
+
+func (store *MemoryStore) createOutboxEvent(arg CreateOutboxEventParams) OutboxEvent {
+	event := store.outboxEvents.insert(func(id int64) OutboxEvent {
+		return OutboxEvent{
+			ID:          id,
+			EventType:   arg.EventType,
+			AggregateID: arg.AggregateID,
+			Payload:     slices.Clone(arg.Payload),
+			CreatedAt:   now(),
+		}
+	})
+
+	if event.EventType == EventBalanceChanged {
+		store.events.notify(AccountEventNotification{EventID: event.ID, AccountID: event.AggregateID})
+	}
+
+	return event
+}
+
+// ListenAccountEvents calls handle for every account.balance_changed event
+// published until ctx is cancelled.
+func (store *MemoryStore) ListenAccountEvents(ctx context.Context, handle func(AccountEventNotification)) error {
+	return store.events.listen(ctx, handle)
+}
+
+// The FromReplica reads are served like any other, since a MemoryStore has no
+// replica.
+
+func (store *MemoryStore) ListAccountsFromReplica(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	return store.ListAccounts(ctx, arg)
+}
+
+func (store *MemoryStore) SearchTransfersFromReplica(ctx context.Context, arg SearchTransfersParams) ([]Transfer, error) {
+	return store.SearchTransfers(ctx, arg)
+}
+
+func (store *MemoryStore) ListNotificationsFromReplica(ctx context.Context, arg ListNotificationsParams) ([]Notification, error) {
+	return store.ListNotifications(ctx, arg)
+}
+
+func (store *MemoryStore) ListAuditLogsFromReplica(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
+	return store.ListAuditLogs(ctx, arg)
+}
+
+func (store *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// MigrationVersion reports the latest schema version, whose constraints the
+// store enforces.
+func (store *MemoryStore) MigrationVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	return SchemaVersion, false, nil
+}
+
+// TxStats is always zero, since transactions of a MemoryStore never conflict.
+func (store *MemoryStore) TxStats() TxStats {
+	return TxStats{}
+}
@@ -5,32 +5,153 @@
 package db
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Account struct {
-	ID        int64     `json:"id"`
+	ID        int64     `json:"-"`
 	Owner     string    `json:"owner"`
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// externally visible identifier with mod-97 check digits
+	AccountNumber string `json:"account_number"`
+}
+
+type AccountHolder struct {
+	AccountID int64  `json:"-"`
+	Username  string `json:"username"`
+	// primary, joint or view_only
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	// pending until the invited user accepts, then accepted
+	Status string `json:"status"`
+}
+
+type AuditLog struct {
+	ID int64 `json:"id"`
+	// username of the user who acted, which need not exist for failed logins
+	Actor        string `json:"actor"`
+	Action       string `json:"action"`
+	ResourceType string `json:"resource_type"`
+	ResourceID   string `json:"resource_id"`
+	ClientIp     string `json:"client_ip"`
+	UserAgent    string `json:"user_agent"`
+	RequestID    string `json:"request_id"`
+	// the resource before the action, null if the action created it
+	Before json.RawMessage `json:"before"`
+	// the resource after the action, null if the action deleted it
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
 }
 
 type Entry struct {
 	ID        int64 `json:"id"`
-	AccountID int64 `json:"account_id"`
+	AccountID int64 `json:"-"`
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// the transfer that wrote the entry
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	// hash of the previous entry of the account, null for the first entry of its chain
+	PrevHash []byte `json:"prev_hash"`
+	// SHA-256 of prev_hash, the entry and its transfer; null for entries written before the chain began
+	Hash []byte `json:"hash"`
+}
+
+type LedgerCheckpoint struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	EntryID   int64 `json:"entry_id"`
+	// hash of the entry, the head of the chain of the account when the checkpoint was taken
+	Hash []byte `json:"hash"`
+	// HMAC-SHA256 of the account, the entry and its hash
+	Signature []byte    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Notification struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	Kind      string    `json:"kind"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OutboxEvent struct {
+	ID        int64  `json:"id"`
+	EventType string `json:"event_type"`
+	// id of the account or transfer the event is about
+	AggregateID int64  `json:"aggregate_id"`
+	Payload     []byte `json:"payload"`
+	// set once a delivery has been queued for every webhook
+	FannedOut bool      `json:"fanned_out"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Payee struct {
+	ID        int64  `json:"id"`
+	Owner     string `json:"owner"`
+	Nickname  string `json:"nickname"`
+	AccountID int64  `json:"-"`
+	Currency  string `json:"currency"`
+	// start of the cooling-off period for large transfers
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PaymentRequest struct {
+	ID          int64  `json:"id"`
+	Requester   string `json:"requester"`
+	Payer       string `json:"payer"`
+	ToAccountID int64  `json:"-"`
+	// must be positive
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	// set once the payer accepts
+	TransferID  pgtype.Int8 `json:"transfer_id"`
+	ExpiresAt   time.Time   `json:"expires_at"`
+	RespondedAt time.Time   `json:"responded_at"`
+	CreatedAt   time.Time   `json:"created_at"`
 }
 
 type Transfer struct {
 	ID            int64 `json:"id"`
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
+	FromAccountID int64 `json:"-"`
+	ToAccountID   int64 `json:"-"`
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// free-text memo shown on statements
+	Description string `json:"description"`
+	// end-to-end reference supplied by the payer
+	Reference string `json:"reference"`
+	Category  string `json:"category"`
+}
+
+type TransferApproval struct {
+	ID            int64  `json:"id"`
+	Maker         string `json:"maker"`
+	FromAccountID int64  `json:"-"`
+	ToAccountID   int64  `json:"-"`
+	Amount        int64  `json:"amount"`
+	Description   string `json:"description"`
+	Reference     string `json:"reference"`
+	Category      string `json:"category"`
+	Status        string `json:"status"`
+	// user who approved or rejected the transfer
+	Checker string `json:"checker"`
+	// set once the transfer is approved and executed
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+	DecidedAt  time.Time   `json:"decided_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+	// set when the transfer pays a payment request
+	PaymentRequestID pgtype.Int8 `json:"payment_request_id"`
 }
 
 type User struct {
@@ -41,3 +162,24 @@ type User struct {
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
+
+type Webhook struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	Url   string `json:"url"`
+	// key for the HMAC-SHA256 signature of each delivery
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID            int64     `json:"id"`
+	WebhookID     int64     `json:"webhook_id"`
+	EventID       int64     `json:"event_id"`
+	Status        string    `json:"status"`
+	Attempts      int32     `json:"attempts"`
+	LastError     string    `json:"last_error"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	DeliveredAt   time.Time `json:"delivered_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const (
+	EventAccountCreated    = "account.created"
+	EventTransferCompleted = "transfer.completed"
+	EventBalanceChanged    = "account.balance_changed"
+)
+
+// BalanceChangedEvent is the payload of an account.balance_changed event.
+type BalanceChangedEvent struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+}
+
+// TransferCompletedEvent is the payload of a transfer.completed event. The
+// accounts are identified by account number, since the ids of the transfer
+// are internal.
+type TransferCompletedEvent struct {
+	Transfer
+	FromAccountNumber string `json:"from_account_number"`
+	ToAccountNumber   string `json:"to_account_number"`
+}
+
+func newTransferCompletedEvent(result TransferTXResult) TransferCompletedEvent {
+	return TransferCompletedEvent{
+		Transfer:          result.Transfer,
+		FromAccountNumber: result.FromAccount.AccountNumber,
+		ToAccountNumber:   result.ToAccount.AccountNumber,
+	}
+}
+
+// publish records an event in the outbox using q, which must be bound to the
+// transaction that made the change, so the event is stored if and only if
+// the change is committed.
+func publish(ctx context.Context, q Querier, eventType string, aggregateID int64, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateOutboxEvent(ctx, CreateOutboxEventParams{
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+	})
+	return err
+}
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusDead      = "dead"
+)
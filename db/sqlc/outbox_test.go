@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTxPublishesEvents(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := testStore.TransferTx(context.Background(), TransferTXParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// the three events are the last ones written by the transaction
+	event, err := testStore.GetOutboxEvent(context.Background(), lastOutboxEventID(t)-2)
+	require.NoError(t, err)
+	require.Equal(t, EventTransferCompleted, event.EventType)
+	require.Equal(t, result.Transfer.ID, event.AggregateID)
+
+	var completed TransferCompletedEvent
+	require.NoError(t, json.Unmarshal(event.Payload, &completed))
+	require.Equal(t, result.Transfer.Amount, completed.Amount)
+	require.Equal(t, account1.AccountNumber, completed.FromAccountNumber)
+	require.Equal(t, account2.AccountNumber, completed.ToAccountNumber)
+	// the internal account ids are not published
+	require.NotContains(t, string(event.Payload), "account_id")
+
+	for _, offset := range []int64{1, 0} {
+		event, err := testStore.GetOutboxEvent(context.Background(), lastOutboxEventID(t)-offset)
+		require.NoError(t, err)
+		require.Equal(t, EventBalanceChanged, event.EventType)
+
+		var changed BalanceChangedEvent
+		require.NoError(t, json.Unmarshal(event.Payload, &changed))
+		require.NotZero(t, changed.Entry.Amount)
+	}
+}
+
+func TestFanOutAndReplayWebhookDeliveries(t *testing.T) {
+	user := createRandomUser(t)
+	webhook, err := testStore.CreateWebhook(context.Background(), CreateWebhookParams{
+		Owner:  user.Username,
+		Url:    "https://example.com/hooks",
+		Secret: "0123456789abcdef",
+	})
+	require.NoError(t, err)
+	defer testStore.DeleteWebhook(context.Background(), webhook.ID)
+
+	createRandomAccount(t)
+
+	n, err := testStore.FanOutOutboxEvents(context.Background(), 1000)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, n, int64(1))
+
+	// events are only fanned out once
+	n, err = testStore.FanOutOutboxEvents(context.Background(), 1000)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	deliveries, err := testStore.ListWebhookDeliveries(context.Background(), ListWebhookDeliveriesParams{
+		Status: WebhookDeliveryStatusPending,
+		Limit:  1,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+
+	err = testStore.MarkWebhookDeliveryFailed(context.Background(), MarkWebhookDeliveryFailedParams{
+		ID:            delivery.ID,
+		Status:        WebhookDeliveryStatusDead,
+		LastError:     "webhook responded with status 500",
+		NextAttemptAt: delivery.NextAttemptAt,
+	})
+	require.NoError(t, err)
+
+	dead, err := testStore.GetWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryStatusDead, dead.Status)
+	require.Equal(t, delivery.Attempts+1, dead.Attempts)
+
+	replayed, err := testStore.ReplayWebhookDelivery(context.Background(), delivery.ID)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryStatusPending, replayed.Status)
+	require.Zero(t, replayed.Attempts)
+	require.Empty(t, replayed.LastError)
+}
+
+func lastOutboxEventID(t *testing.T) int64 {
+	var id int64
+	err := testStore.(*SQLStore).connPool.QueryRow(context.Background(), "SELECT max(id) FROM outbox_events").Scan(&id)
+	require.NoError(t, err)
+	return id
+}
+
+func TestListAccountEvents(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	lastID, err := testStore.GetLastOutboxEventID(context.Background())
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := testStore.TransferTx(context.Background(), TransferTXParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+	}
+
+	events, err := testStore.ListAccountEvents(context.Background(), ListAccountEventsParams{
+		AccountID: account1.ID,
+		AfterID:   lastID,
+		BatchSize: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	for _, event := range events {
+		require.Equal(t, EventBalanceChanged, event.EventType)
+		require.Equal(t, account1.ID, event.AggregateID)
+	}
+
+	// resuming from an event only returns the ones after it
+	events, err = testStore.ListAccountEvents(context.Background(), ListAccountEventsParams{
+		AccountID: account1.ID,
+		AfterID:   events[0].ID,
+		BatchSize: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+}
+
+func TestListenAccountEvents(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	notifications := make(chan AccountEventNotification, 10)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- testStore.ListenAccountEvents(ctx, func(n AccountEventNotification) {
+			notifications <- n
+		})
+	}()
+
+	// give the listener time to LISTEN before the transfer commits
+	time.Sleep(200 * time.Millisecond)
+
+	result, err := testStore.TransferTx(context.Background(), TransferTXParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	accounts := map[int64]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case n := <-notifications:
+			require.NotZero(t, n.EventID)
+			accounts[n.AccountID] = true
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for notifications")
+		}
+	}
+	require.True(t, accounts[result.FromAccount.ID])
+	require.True(t, accounts[result.ToAccount.ID])
+
+	cancel()
+	require.NoError(t, <-errs)
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"simple_bank/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPayee(t *testing.T, owner User, account Account) Payee {
+	arg := CreatePayeeParams{
+		Owner:     owner.Username,
+		Nickname:  util.RandomOwner(),
+		AccountID: account.ID,
+		Currency:  account.Currency,
+	}
+
+	payee, err := testStore.CreatePayee(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, payee)
+
+	require.Equal(t, arg.Owner, payee.Owner)
+	require.Equal(t, arg.Nickname, payee.Nickname)
+	require.Equal(t, arg.AccountID, payee.AccountID)
+	require.Equal(t, arg.Currency, payee.Currency)
+
+	require.NotZero(t, payee.ID)
+	require.NotZero(t, payee.CreatedAt)
+
+	return payee
+}
+
+func TestCreatePayee(t *testing.T) {
+	createRandomPayee(t, createRandomUser(t), createRandomAccount(t))
+}
+
+func TestCreatePayeeDuplicateAccount(t *testing.T) {
+	user := createRandomUser(t)
+	account := createRandomAccount(t)
+	createRandomPayee(t, user, account)
+
+	_, err := testStore.CreatePayee(context.Background(), CreatePayeeParams{
+		Owner:     user.Username,
+		Nickname:  util.RandomOwner(),
+		AccountID: account.ID,
+		Currency:  account.Currency,
+	})
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrUniqueViolation)
+}
+
+func TestGetPayee(t *testing.T) {
+	payee1 := createRandomPayee(t, createRandomUser(t), createRandomAccount(t))
+
+	payee2, err := testStore.GetPayee(context.Background(), payee1.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, payee2)
+
+	require.Equal(t, payee1.ID, payee2.ID)
+	require.Equal(t, payee1.Owner, payee2.Owner)
+	require.Equal(t, payee1.Nickname, payee2.Nickname)
+	require.Equal(t, payee1.AccountID, payee2.AccountID)
+	require.WithinDuration(t, payee1.CreatedAt, payee2.CreatedAt, time.Second)
+}
+
+func TestUpdatePayee(t *testing.T) {
+	payee1 := createRandomPayee(t, createRandomUser(t), createRandomAccount(t))
+
+	arg := UpdatePayeeParams{
+		ID:       payee1.ID,
+		Nickname: util.RandomOwner(),
+	}
+
+	payee2, err := testStore.UpdatePayee(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, payee1.ID, payee2.ID)
+	require.Equal(t, arg.Nickname, payee2.Nickname)
+	require.Equal(t, payee1.AccountID, payee2.AccountID)
+}
+
+func TestDeletePayee(t *testing.T) {
+	payee1 := createRandomPayee(t, createRandomUser(t), createRandomAccount(t))
+
+	err := testStore.DeletePayee(context.Background(), payee1.ID)
+	require.NoError(t, err)
+
+	_, err = testStore.GetPayee(context.Background(), payee1.ID)
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListPayees(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 6; i++ {
+		createRandomPayee(t, user, createRandomAccount(t))
+	}
+
+	arg := ListPayeesParams{
+		Owner:  user.Username,
+		Limit:  5,
+		Offset: 1,
+	}
+
+	payees, err := testStore.ListPayees(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, payees, 5)
+
+	for _, payee := range payees {
+		require.NotEmpty(t, payee)
+		require.Equal(t, user.Username, payee.Owner)
+	}
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"simple_bank/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPaymentRequest(t *testing.T, payer User, toAccount Account, expiresAt time.Time) PaymentRequest {
+	arg := CreatePaymentRequestParams{
+		Requester:   toAccount.Owner,
+		Payer:       payer.Username,
+		ToAccountID: toAccount.ID,
+		Amount:      util.RandomMoney(),
+		Currency:    toAccount.Currency,
+		Description: "dinner",
+		ExpiresAt:   expiresAt,
+	}
+
+	paymentRequest, err := testStore.CreatePaymentRequest(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, paymentRequest)
+
+	require.Equal(t, arg.Requester, paymentRequest.Requester)
+	require.Equal(t, arg.Payer, paymentRequest.Payer)
+	require.Equal(t, arg.ToAccountID, paymentRequest.ToAccountID)
+	require.Equal(t, arg.Amount, paymentRequest.Amount)
+	require.Equal(t, arg.Currency, paymentRequest.Currency)
+	require.Equal(t, arg.Description, paymentRequest.Description)
+	require.Equal(t, PaymentRequestStatusPending, paymentRequest.Status)
+	require.False(t, paymentRequest.TransferID.Valid)
+	require.WithinDuration(t, arg.ExpiresAt, paymentRequest.ExpiresAt, time.Second)
+
+	require.NotZero(t, paymentRequest.ID)
+	require.NotZero(t, paymentRequest.CreatedAt)
+
+	return paymentRequest
+}
+
+func TestCreatePaymentRequest(t *testing.T) {
+	createRandomPaymentRequest(t, createRandomUser(t), createRandomAccount(t), time.Now().Add(time.Hour))
+}
+
+func TestListPaymentRequestsByPayer(t *testing.T) {
+	payer := createRandomUser(t)
+	for i := 0; i < 5; i++ {
+		createRandomPaymentRequest(t, payer, createRandomAccount(t), time.Now().Add(time.Hour))
+	}
+
+	paymentRequests, err := testStore.ListPaymentRequestsByPayer(context.Background(), ListPaymentRequestsByPayerParams{
+		Payer:  payer.Username,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, paymentRequests, 5)
+
+	for _, paymentRequest := range paymentRequests {
+		require.Equal(t, payer.Username, paymentRequest.Payer)
+	}
+}
+
+func TestExpirePaymentRequests(t *testing.T) {
+	payer := createRandomUser(t)
+	expired := createRandomPaymentRequest(t, payer, createRandomAccount(t), time.Now().Add(-time.Minute))
+	pending := createRandomPaymentRequest(t, payer, createRandomAccount(t), time.Now().Add(time.Hour))
+
+	n, err := testStore.ExpirePaymentRequests(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, n, int64(1))
+
+	paymentRequest, err := testStore.GetPaymentRequest(context.Background(), expired.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestStatusExpired, paymentRequest.Status)
+
+	paymentRequest, err = testStore.GetPaymentRequest(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestStatusPending, paymentRequest.Status)
+}
+
+func TestAcceptPaymentRequestTx(t *testing.T) {
+	payer := createRandomUser(t)
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+	paymentRequest := createRandomPaymentRequest(t, payer, toAccount, time.Now().Add(time.Hour))
+
+	arg := AcceptPaymentRequestTxParams{
+		PaymentRequestID: paymentRequest.ID,
+		FromAccountID:    fromAccount.ID,
+	}
+
+	result, err := testStore.AcceptPaymentRequestTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, PaymentRequestStatusAccepted, result.PaymentRequest.Status)
+	require.True(t, result.PaymentRequest.TransferID.Valid)
+	require.Equal(t, result.Transfer.ID, result.PaymentRequest.TransferID.Int64)
+	require.NotZero(t, result.PaymentRequest.RespondedAt)
+
+	require.Equal(t, fromAccount.ID, result.Transfer.FromAccountID)
+	require.Equal(t, toAccount.ID, result.Transfer.ToAccountID)
+	require.Equal(t, paymentRequest.Amount, result.Transfer.Amount)
+	require.Equal(t, fromAccount.Balance-paymentRequest.Amount, result.FromAccount.Balance)
+	require.Equal(t, toAccount.Balance+paymentRequest.Amount, result.ToAccount.Balance)
+
+	// a request can only be paid once
+	_, err = testStore.AcceptPaymentRequestTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+}
+
+func TestAcceptExpiredPaymentRequestTx(t *testing.T) {
+	paymentRequest := createRandomPaymentRequest(t, createRandomUser(t), createRandomAccount(t), time.Now().Add(-time.Minute))
+
+	_, err := testStore.AcceptPaymentRequestTx(context.Background(), AcceptPaymentRequestTxParams{
+		PaymentRequestID: paymentRequest.ID,
+		FromAccountID:    createRandomAccount(t).ID,
+	})
+	require.ErrorIs(t, err, ErrPaymentRequestExpired)
+}
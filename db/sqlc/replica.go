@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"simple_bank/logger"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// replicaCheckInterval is how often the lag of the replica is measured.
+	replicaCheckInterval = time.Second
+	replicaCheckTimeout  = time.Second
+)
+
+// replicaLagQuery returns how far behind the primary the replica is, in
+// seconds. A replica that has replayed everything it received is not
+// behind, however long ago its last transaction was, and a server that is
+// not a replica at all reports no lag.
+const replicaLagQuery = `
+SELECT COALESCE(
+  CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+  ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) END,
+  0)::float8`
+
+// StoreOption configures an SQLStore.
+type StoreOption func(store *SQLStore)
+
+// WithReplica routes the reads that tolerate stale data to a read replica
+// while it lags less than maxLag behind the primary. Reads fall back to the
+// primary while the replica is lagging or unreachable.
+func WithReplica(pool *pgxpool.Pool, maxLag time.Duration) StoreOption {
+	return func(store *SQLStore) {
+		store.replica = &replica{
+			Queries: New(errorTranslatingDBTX{pool}),
+			pool:    pool,
+			maxLag:  maxLag,
+		}
+	}
+}
+
+// replica is a read replica of the primary database. Its health is measured
+// in the background, at most every replicaCheckInterval, when it is used.
+type replica struct {
+	*Queries
+	pool   *pgxpool.Pool
+	maxLag time.Duration
+
+	healthy   atomic.Bool
+	checking  atomic.Bool
+	checkedAt atomic.Int64
+}
+
+// ready reports whether reads can be routed to the replica, starting a new
+// health check if the last one is out of date.
+func (replica *replica) ready() bool {
+	if time.Since(time.Unix(0, replica.checkedAt.Load())) > replicaCheckInterval &&
+		replica.checking.CompareAndSwap(false, true) {
+		// the check outlives the read that started it
+		go replica.check(context.Background())
+	}
+
+	return replica.healthy.Load()
+}
+
+func (replica *replica) check(ctx context.Context) {
+	defer replica.checking.Store(false)
+	defer replica.checkedAt.Store(time.Now().UnixNano())
+
+	ctx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+	defer cancel()
+
+	var lag float64
+	err := replica.pool.QueryRow(ctx, replicaLagQuery).Scan(&lag)
+	healthy := err == nil && time.Duration(lag*float64(time.Second)) <= replica.maxLag
+
+	if replica.healthy.Swap(healthy) != healthy {
+		logger.FromContext(ctx).InfoContext(ctx, "replica health changed", "healthy", healthy, "lag_seconds", lag, "error", err)
+	}
+}
+
+// read runs query on the replica if it is ready, and on the primary
+// otherwise. Reads that fail because the replica cannot be reached are run
+// again on the primary.
+func read[T any](ctx context.Context, store *SQLStore, query func(q *Queries) (T, error)) (T, error) {
+	replica := store.replica
+	if replica == nil || !replica.ready() {
+		return query(store.Queries)
+	}
+
+	result, err := query(replica.Queries)
+	if err != nil && ctx.Err() == nil && isConnectionError(err) {
+		replica.healthy.Store(false)
+		logger.FromContext(ctx).WarnContext(ctx, "replica read failed, falling back to the primary", "error", err)
+		return query(store.Queries)
+	}
+
+	return result, err
+}
+
+// isConnectionError reports whether err was caused by the connection rather
+// than by the query, which the server would have answered with an error.
+func isConnectionError(err error) bool {
+	var pgErr *pgconn.PgError
+	return !errors.Is(err, ErrRecordNotFound) && !errors.As(err, &pgErr)
+}
+
+// The reads below serve the account list, transfer history, notification
+// and audit log endpoints, which only display data and tolerate data that is
+// maxLag old. They are named after the replica so that callers opt in to
+// stale data; every other read, including GetAccount and GetAccountByNumber,
+// which authorize requests and precede writes, stays on the primary, as do
+// all reads inside a transaction.
+
+func (store *SQLStore) ListAccountsFromReplica(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	return read(ctx, store, func(q *Queries) ([]Account, error) {
+		return q.ListAccounts(ctx, arg)
+	})
+}
+
+func (store *SQLStore) SearchTransfersFromReplica(ctx context.Context, arg SearchTransfersParams) ([]Transfer, error) {
+	return read(ctx, store, func(q *Queries) ([]Transfer, error) {
+		return q.SearchTransfers(ctx, arg)
+	})
+}
+
+func (store *SQLStore) ListNotificationsFromReplica(ctx context.Context, arg ListNotificationsParams) ([]Notification, error) {
+	return read(ctx, store, func(q *Queries) ([]Notification, error) {
+		return q.ListNotifications(ctx, arg)
+	})
+}
+
+func (store *SQLStore) ListAuditLogsFromReplica(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
+	return read(ctx, store, func(q *Queries) ([]AuditLog, error) {
+		return q.ListAuditLogs(ctx, arg)
+	})
+}
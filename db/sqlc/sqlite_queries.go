@@ -0,0 +1,1060 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// sqliteDBTX is implemented by *sql.DB and *sql.Tx.
+type sqliteDBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sqliteQueries runs the queries of Querier, translated to SQLite, against
+// a database or a transaction. Timestamps written by a query are taken from
+// the clock of the server, like now() in Postgres.
+type sqliteQueries struct {
+	db sqliteDBTX
+	// notify is called for every account.balance_changed event written.
+	notify func(AccountEventNotification)
+}
+
+var _ Querier = (*sqliteQueries)(nil)
+
+// sqliteRow is implemented by *sql.Row and *sql.Rows.
+type sqliteRow interface {
+	Scan(dest ...any) error
+}
+
+func sqliteQueryOne[T any](ctx context.Context, db sqliteDBTX, scan func(sqliteRow) (T, error), query string, args ...any) (T, error) {
+	item, err := scan(db.QueryRowContext(ctx, query, args...))
+	return item, translateSQLiteError(err)
+}
+
+func sqliteQueryMany[T any](ctx context.Context, db sqliteDBTX, scan func(sqliteRow) (T, error), query string, args ...any) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, translateSQLiteError(err)
+	}
+	defer rows.Close()
+
+	items := []T{}
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, translateSQLiteError(err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, translateSQLiteError(err)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, translateSQLiteError(err)
+	}
+	return items, nil
+}
+
+func sqliteExec(ctx context.Context, db sqliteDBTX, query string, args ...any) (int64, error) {
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, translateSQLiteError(err)
+	}
+	return result.RowsAffected()
+}
+
+func scanAccount(row sqliteRow) (Account, error) {
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		sqliteTimestamp{&i.CreatedAt},
+		&i.AccountNumber,
+	)
+	return i, err
+}
+
+func scanAccountHolder(row sqliteRow) (AccountHolder, error) {
+	var i AccountHolder
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Role,
+		sqliteTimestamp{&i.CreatedAt},
+		&i.Status,
+	)
+	return i, err
+}
+
+func scanAuditLog(row sqliteRow) (AuditLog, error) {
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.Actor,
+		&i.Action,
+		&i.ResourceType,
+		&i.ResourceID,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.RequestID,
+		(*[]byte)(&i.Before),
+		(*[]byte)(&i.After),
+		sqliteTimestamp{&i.CreatedAt},
+	)
+	return i, err
+}
+
+func scanEntry(row sqliteRow) (Entry, error) {
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		sqliteTimestamp{&i.CreatedAt},
+		&i.TransferID,
+		&i.PrevHash,
+		&i.Hash,
+	)
+	return i, err
+}
+
+func scanLedgerCheckpoint(row sqliteRow) (LedgerCheckpoint, error) {
+	var i LedgerCheckpoint
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.EntryID,
+		&i.Hash,
+		&i.Signature,
+		sqliteTimestamp{&i.CreatedAt},
+	)
+	return i, err
+}
+
+func scanNotification(row sqliteRow) (Notification, error) {
+	var i Notification
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Kind,
+		&i.Message,
+		sqliteTimestamp{&i.CreatedAt},
+	)
+	return i, err
+}
+
+func scanOutboxEvent(row sqliteRow) (OutboxEvent, error) {
+	var i OutboxEvent
+	err := row.Scan(
+		&i.ID,
+		&i.EventType,
+		&i.AggregateID,
+		&i.Payload,
+		&i.FannedOut,
+		sqliteTimestamp{&i.CreatedAt},
+	)
+	return i, err
+}
+
+func scanPayee(row sqliteRow) (Payee, error) {
+	var i Payee
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Nickname,
+		&i.AccountID,
+		&i.Currency,
+		sqliteTimestamp{&i.CreatedAt},
+	)
+	return i, err
+}
+
+func scanPaymentRequest(row sqliteRow) (PaymentRequest, error) {
+	var i PaymentRequest
+	err := row.Scan(
+		&i.ID,
+		&i.Requester,
+		&i.Payer,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Description,
+		&i.Status,
+		&i.TransferID,
+		sqliteTimestamp{&i.ExpiresAt},
+		sqliteTimestamp{&i.RespondedAt},
+		sqliteTimestamp{&i.CreatedAt},
+	)
+	return i, err
+}
+
+func scanTransfer(row sqliteRow) (Transfer, error) {
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		sqliteTimestamp{&i.CreatedAt},
+		&i.Description,
+		&i.Reference,
+		&i.Category,
+	)
+	return i, err
+}
+
+func scanTransferApproval(row sqliteRow) (TransferApproval, error) {
+	var i TransferApproval
+	err := row.Scan(
+		&i.ID,
+		&i.Maker,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Description,
+		&i.Reference,
+		&i.Category,
+		&i.Status,
+		&i.Checker,
+		&i.TransferID,
+		sqliteTimestamp{&i.ExpiresAt},
+		sqliteTimestamp{&i.DecidedAt},
+		sqliteTimestamp{&i.CreatedAt},
+		&i.PaymentRequestID,
+	)
+	return i, err
+}
+
+func scanUser(row sqliteRow) (User, error) {
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		sqliteTimestamp{&i.PasswordChangedAt},
+		sqliteTimestamp{&i.CreatedAt},
+	)
+	return i, err
+}
+
+func scanWebhook(row sqliteRow) (Webhook, error) {
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Url,
+		&i.Secret,
+		sqliteTimestamp{&i.CreatedAt},
+	)
+	return i, err
+}
+
+func scanWebhookDelivery(row sqliteRow) (WebhookDelivery, error) {
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.EventID,
+		&i.Status,
+		&i.Attempts,
+		&i.LastError,
+		sqliteTimestamp{&i.NextAttemptAt},
+		sqliteTimestamp{&i.DeliveredAt},
+		sqliteTimestamp{&i.CreatedAt},
+	)
+	return i, err
+}
+
+func (q *sqliteQueries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	return sqliteQueryOne(ctx, q.db, scanAccount, `
+UPDATE account
+SET balance = balance + ?1
+WHERE id = ?2
+RETURNING id, owner, balance, currency, created_at, account_number
+`, arg.Amount, arg.ID)
+}
+
+func (q *sqliteQueries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	return sqliteQueryOne(ctx, q.db, scanAccount, `
+INSERT INTO account (
+  owner,
+  balance,
+  currency,
+  account_number
+) VALUES (
+  ?1, ?2, ?3, ?4
+) RETURNING id, owner, balance, currency, created_at, account_number
+`, arg.Owner, arg.Balance, arg.Currency, arg.AccountNumber)
+}
+
+func (q *sqliteQueries) DeleteAccount(ctx context.Context, id int64) error {
+	_, err := sqliteExec(ctx, q.db, `
+DELETE FROM account WHERE id = ?1
+`, id)
+	return err
+}
+
+func (q *sqliteQueries) GetAccount(ctx context.Context, id int64) (Account, error) {
+	return sqliteQueryOne(ctx, q.db, scanAccount, `
+SELECT id, owner, balance, currency, created_at, account_number FROM account
+WHERE id = ?1 LIMIT 1
+`, id)
+}
+
+func (q *sqliteQueries) GetAccountByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	return sqliteQueryOne(ctx, q.db, scanAccount, `
+SELECT id, owner, balance, currency, created_at, account_number FROM account
+WHERE account_number = ?1 LIMIT 1
+`, accountNumber)
+}
+
+// GetAccountForUpdate needs no lock, since the transaction holds the write
+// lock of the database. The same goes for the other ForUpdate queries.
+func (q *sqliteQueries) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
+	return q.GetAccount(ctx, id)
+}
+
+// ListAccountNumbers passes the ids as a JSON array, since SQLite has no
+// array parameters.
+func (q *sqliteQueries) ListAccountNumbers(ctx context.Context, ids []int64) ([]ListAccountNumbersRow, error) {
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqliteQueryMany(ctx, q.db, func(row sqliteRow) (ListAccountNumbersRow, error) {
+		var i ListAccountNumbersRow
+		err := row.Scan(&i.ID, &i.AccountNumber)
+		return i, err
+	}, `
+SELECT id, account_number FROM account
+WHERE id IN (SELECT value FROM json_each(?1))
+`, string(data))
+}
+
+func (q *sqliteQueries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	return sqliteQueryMany(ctx, q.db, scanAccount, `
+SELECT account.id, account.owner, account.balance, account.currency, account.created_at, account.account_number FROM account
+JOIN account_holders ON account_holders.account_id = account.id
+WHERE account_holders.username = ?1
+  AND account_holders.status = 'accepted'
+ORDER BY account.id
+LIMIT ?2
+OFFSET ?3
+`, arg.Username, arg.Limit, arg.Offset)
+}
+
+func (q *sqliteQueries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	return sqliteQueryOne(ctx, q.db, scanAccount, `
+UPDATE account SET balance = ?2
+WHERE id = ?1
+RETURNING id, owner, balance, currency, created_at, account_number
+`, arg.ID, arg.Balance)
+}
+
+// CreateAccountHolder writes created_at, which orders the holders of an
+// account, with the microseconds of Postgres rather than the milliseconds of
+// the column default.
+func (q *sqliteQueries) CreateAccountHolder(ctx context.Context, arg CreateAccountHolderParams) (AccountHolder, error) {
+	return sqliteQueryOne(ctx, q.db, scanAccountHolder, `
+INSERT INTO account_holders (
+  account_id,
+  username,
+  role,
+  status,
+  created_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5
+) RETURNING account_id, username, role, created_at, status
+`, arg.AccountID, arg.Username, arg.Role, arg.Status, sqliteTime(time.Now()))
+}
+
+func (q *sqliteQueries) AcceptAccountHolder(ctx context.Context, arg AcceptAccountHolderParams) (AccountHolder, error) {
+	return sqliteQueryOne(ctx, q.db, scanAccountHolder, `
+UPDATE account_holders SET status = 'accepted'
+WHERE account_id = ?1 AND username = ?2 AND status = 'pending'
+RETURNING account_id, username, role, created_at, status
+`, arg.AccountID, arg.Username)
+}
+
+func (q *sqliteQueries) DeleteAccountHolder(ctx context.Context, arg DeleteAccountHolderParams) error {
+	_, err := sqliteExec(ctx, q.db, `
+DELETE FROM account_holders
+WHERE account_id = ?1 AND username = ?2
+`, arg.AccountID, arg.Username)
+	return err
+}
+
+func (q *sqliteQueries) GetAccountHolder(ctx context.Context, arg GetAccountHolderParams) (AccountHolder, error) {
+	return sqliteQueryOne(ctx, q.db, scanAccountHolder, `
+SELECT account_id, username, role, created_at, status FROM account_holders
+WHERE account_id = ?1 AND username = ?2 LIMIT 1
+`, arg.AccountID, arg.Username)
+}
+
+func (q *sqliteQueries) ListAccountHolders(ctx context.Context, accountID int64) ([]AccountHolder, error) {
+	return sqliteQueryMany(ctx, q.db, scanAccountHolder, `
+SELECT account_id, username, role, created_at, status FROM account_holders
+WHERE account_id = ?1
+ORDER BY created_at
+`, accountID)
+}
+
+func (q *sqliteQueries) ListAccountInvitations(ctx context.Context, username string) ([]AccountHolder, error) {
+	return sqliteQueryMany(ctx, q.db, scanAccountHolder, `
+SELECT account_id, username, role, created_at, status FROM account_holders
+WHERE username = ?1 AND status = 'pending'
+ORDER BY created_at
+`, username)
+}
+
+func (q *sqliteQueries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error) {
+	return sqliteQueryOne(ctx, q.db, scanAuditLog, `
+INSERT INTO audit_log (
+  actor,
+  action,
+  resource_type,
+  resource_id,
+  client_ip,
+  user_agent,
+  request_id,
+  "before",
+  "after",
+  created_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10
+) RETURNING id, actor, action, resource_type, resource_id, client_ip, user_agent, request_id, "before", "after", created_at
+`, arg.Actor, arg.Action, arg.ResourceType, arg.ResourceID, arg.ClientIp, arg.UserAgent, arg.RequestID,
+		[]byte(arg.Before), []byte(arg.After), sqliteTime(time.Now()))
+}
+
+func (q *sqliteQueries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
+	return sqliteQueryMany(ctx, q.db, scanAuditLog, `
+SELECT id, actor, action, resource_type, resource_id, client_ip, user_agent, request_id, "before", "after", created_at FROM audit_log
+WHERE
+    (?1 = '' OR actor = ?1)
+    AND (?2 = '' OR resource_type = ?2)
+    AND (?3 = '' OR resource_id = ?3)
+    AND created_at >= ?4
+    AND created_at < ?5
+ORDER BY id DESC
+LIMIT ?6
+OFFSET ?7
+`, arg.Actor, arg.ResourceType, arg.ResourceID, sqliteTime(arg.Since), sqliteTime(arg.Until), arg.Limit, arg.Offset)
+}
+
+func (q *sqliteQueries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	return sqliteQueryOne(ctx, q.db, scanEntry, `
+INSERT INTO entries (
+  account_id,
+  amount,
+  transfer_id
+) VALUES (
+  ?1, ?2, ?3
+) RETURNING id, account_id, amount, created_at, transfer_id, prev_hash, hash
+`, arg.AccountID, arg.Amount, arg.TransferID)
+}
+
+func (q *sqliteQueries) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	return sqliteQueryOne(ctx, q.db, scanEntry, `
+SELECT id, account_id, amount, created_at, transfer_id, prev_hash, hash FROM entries
+WHERE id = ?1 LIMIT 1
+`, id)
+}
+
+func (q *sqliteQueries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	return sqliteQueryMany(ctx, q.db, scanEntry, `
+SELECT id, account_id, amount, created_at, transfer_id, prev_hash, hash FROM entries
+WHERE account_id = ?1
+ORDER BY id
+LIMIT ?2
+OFFSET ?3
+`, arg.AccountID, arg.Limit, arg.Offset)
+}
+
+func (q *sqliteQueries) GetPreviousEntryHash(ctx context.Context, arg GetPreviousEntryHashParams) ([]byte, error) {
+	return sqliteQueryOne(ctx, q.db, func(row sqliteRow) ([]byte, error) {
+		var hash []byte
+		err := row.Scan(&hash)
+		return hash, err
+	}, `
+SELECT hash FROM entries
+WHERE account_id = ?1 AND id < ?2
+ORDER BY id DESC
+LIMIT 1
+`, arg.AccountID, arg.ID)
+}
+
+func (q *sqliteQueries) ChainEntry(ctx context.Context, arg ChainEntryParams) (Entry, error) {
+	return sqliteQueryOne(ctx, q.db, scanEntry, `
+UPDATE entries
+SET prev_hash = ?2, hash = ?3
+WHERE id = ?1
+RETURNING id, account_id, amount, created_at, transfer_id, prev_hash, hash
+`, arg.ID, arg.PrevHash, arg.Hash)
+}
+
+func (q *sqliteQueries) ListLedgerEntries(ctx context.Context, arg ListLedgerEntriesParams) ([]ListLedgerEntriesRow, error) {
+	return sqliteQueryMany(ctx, q.db, func(row sqliteRow) (ListLedgerEntriesRow, error) {
+		var i ListLedgerEntriesRow
+		err := row.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			sqliteTimestamp{&i.CreatedAt},
+			&i.TransferID,
+			&i.PrevHash,
+			&i.Hash,
+			&i.TransferFromAccountID,
+			&i.TransferToAccountID,
+			&i.TransferAmount,
+			&i.TransferDescription,
+			&i.TransferReference,
+			&i.TransferCategory,
+			sqliteTimestamp{&i.TransferCreatedAt},
+		)
+		return i, err
+	}, `
+SELECT
+  entries.id,
+  entries.account_id,
+  entries.amount,
+  entries.created_at,
+  entries.transfer_id,
+  entries.prev_hash,
+  entries.hash,
+  COALESCE(transfers.from_account_id, 0),
+  COALESCE(transfers.to_account_id, 0),
+  COALESCE(transfers.amount, 0),
+  COALESCE(transfers.description, ''),
+  COALESCE(transfers.reference, ''),
+  COALESCE(transfers.category, ''),
+  COALESCE(transfers.created_at, '1970-01-01 00:00:00.000000')
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+WHERE (entries.account_id, entries.id) > (?1, ?2)
+ORDER BY entries.account_id, entries.id
+LIMIT ?3
+`, arg.AfterAccountID, arg.AfterID, arg.Limit)
+}
+
+func (q *sqliteQueries) ListUncheckpointedHeads(ctx context.Context, limit int32) ([]ListUncheckpointedHeadsRow, error) {
+	return sqliteQueryMany(ctx, q.db, func(row sqliteRow) (ListUncheckpointedHeadsRow, error) {
+		var i ListUncheckpointedHeadsRow
+		err := row.Scan(&i.AccountID, &i.ID, &i.Hash)
+		return i, err
+	}, `
+SELECT entries.account_id, entries.id, entries.hash FROM entries
+WHERE entries.id = (
+    SELECT max(latest.id) FROM entries AS latest
+    WHERE latest.account_id = entries.account_id AND latest.hash IS NOT NULL
+  )
+  AND entries.id > COALESCE((
+    SELECT max(ledger_checkpoints.entry_id) FROM ledger_checkpoints
+    WHERE ledger_checkpoints.account_id = entries.account_id
+  ), 0)
+ORDER BY entries.account_id
+LIMIT ?1
+`, limit)
+}
+
+func (q *sqliteQueries) CreateLedgerCheckpoint(ctx context.Context, arg CreateLedgerCheckpointParams) (LedgerCheckpoint, error) {
+	return sqliteQueryOne(ctx, q.db, scanLedgerCheckpoint, `
+INSERT INTO ledger_checkpoints (
+  account_id,
+  entry_id,
+  hash,
+  signature
+) VALUES (
+  ?1, ?2, ?3, ?4
+) RETURNING id, account_id, entry_id, hash, signature, created_at
+`, arg.AccountID, arg.EntryID, arg.Hash, arg.Signature)
+}
+
+func (q *sqliteQueries) ListLedgerCheckpoints(ctx context.Context, arg ListLedgerCheckpointsParams) ([]LedgerCheckpoint, error) {
+	return sqliteQueryMany(ctx, q.db, scanLedgerCheckpoint, `
+SELECT id, account_id, entry_id, hash, signature, created_at FROM ledger_checkpoints
+WHERE id > ?1
+ORDER BY id
+LIMIT ?2
+`, arg.AfterID, arg.Limit)
+}
+
+func (q *sqliteQueries) CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error) {
+	return sqliteQueryOne(ctx, q.db, scanNotification, `
+INSERT INTO notifications (
+  username,
+  kind,
+  message
+) VALUES (
+  ?1, ?2, ?3
+) RETURNING id, username, kind, message, created_at
+`, arg.Username, arg.Kind, arg.Message)
+}
+
+func (q *sqliteQueries) ListNotifications(ctx context.Context, arg ListNotificationsParams) ([]Notification, error) {
+	return sqliteQueryMany(ctx, q.db, scanNotification, `
+SELECT id, username, kind, message, created_at FROM notifications
+WHERE username = ?1
+ORDER BY id DESC
+LIMIT ?2
+OFFSET ?3
+`, arg.Username, arg.Limit, arg.Offset)
+}
+
+// CreateOutboxEvent notifies the account.balance_changed events it writes,
+// as the trigger of the Postgres outbox table does.
+func (q *sqliteQueries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (OutboxEvent, error) {
+	event, err := sqliteQueryOne(ctx, q.db, scanOutboxEvent, `
+INSERT INTO outbox_events (
+  event_type,
+  aggregate_id,
+  payload
+) VALUES (
+  ?1, ?2, ?3
+) RETURNING id, event_type, aggregate_id, payload, fanned_out, created_at
+`, arg.EventType, arg.AggregateID, arg.Payload)
+	if err != nil {
+		return event, err
+	}
+
+	if event.EventType == EventBalanceChanged {
+		q.notify(AccountEventNotification{EventID: event.ID, AccountID: event.AggregateID})
+	}
+	return event, nil
+}
+
+// FanOutOutboxEvents must run in a transaction, so that the events it marks
+// as fanned out are the ones it queued deliveries for.
+func (q *sqliteQueries) FanOutOutboxEvents(ctx context.Context, limit int32) (int64, error) {
+	queued, err := sqliteExec(ctx, q.db, `
+INSERT INTO webhook_deliveries (webhook_id, event_id)
+SELECT webhooks.id, events.id FROM (
+  SELECT id FROM outbox_events
+  WHERE fanned_out = false
+  ORDER BY id
+  LIMIT ?1
+) AS events CROSS JOIN webhooks
+`, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = sqliteExec(ctx, q.db, `
+UPDATE outbox_events
+SET fanned_out = true
+WHERE id IN (
+  SELECT id FROM outbox_events
+  WHERE fanned_out = false
+  ORDER BY id
+  LIMIT ?1
+)
+`, limit)
+	return queued, err
+}
+
+func (q *sqliteQueries) GetLastOutboxEventID(ctx context.Context) (int64, error) {
+	var id int64
+	err := q.db.QueryRowContext(ctx, `
+SELECT COALESCE(MAX(id), 0) FROM outbox_events
+`).Scan(&id)
+	return id, translateSQLiteError(err)
+}
+
+func (q *sqliteQueries) GetOutboxEvent(ctx context.Context, id int64) (OutboxEvent, error) {
+	return sqliteQueryOne(ctx, q.db, scanOutboxEvent, `
+SELECT id, event_type, aggregate_id, payload, fanned_out, created_at FROM outbox_events
+WHERE id = ?1 LIMIT 1
+`, id)
+}
+
+func (q *sqliteQueries) ListAccountEvents(ctx context.Context, arg ListAccountEventsParams) ([]OutboxEvent, error) {
+	return sqliteQueryMany(ctx, q.db, scanOutboxEvent, `
+SELECT id, event_type, aggregate_id, payload, fanned_out, created_at FROM outbox_events
+WHERE event_type = 'account.balance_changed'
+  AND aggregate_id = ?1
+  AND id > ?2
+ORDER BY id
+LIMIT ?3
+`, arg.AccountID, arg.AfterID, arg.BatchSize)
+}
+
+func (q *sqliteQueries) CreatePayee(ctx context.Context, arg CreatePayeeParams) (Payee, error) {
+	return sqliteQueryOne(ctx, q.db, scanPayee, `
+INSERT INTO payees (
+  owner,
+  nickname,
+  account_id,
+  currency
+) VALUES (
+  ?1, ?2, ?3, ?4
+) RETURNING id, owner, nickname, account_id, currency, created_at
+`, arg.Owner, arg.Nickname, arg.AccountID, arg.Currency)
+}
+
+func (q *sqliteQueries) DeletePayee(ctx context.Context, id int64) error {
+	_, err := sqliteExec(ctx, q.db, `
+DELETE FROM payees WHERE id = ?1
+`, id)
+	return err
+}
+
+func (q *sqliteQueries) GetPayee(ctx context.Context, id int64) (Payee, error) {
+	return sqliteQueryOne(ctx, q.db, scanPayee, `
+SELECT id, owner, nickname, account_id, currency, created_at FROM payees
+WHERE id = ?1 LIMIT 1
+`, id)
+}
+
+func (q *sqliteQueries) GetPayeeByAccount(ctx context.Context, arg GetPayeeByAccountParams) (Payee, error) {
+	return sqliteQueryOne(ctx, q.db, scanPayee, `
+SELECT id, owner, nickname, account_id, currency, created_at FROM payees
+WHERE owner = ?1 AND account_id = ?2 LIMIT 1
+`, arg.Owner, arg.AccountID)
+}
+
+func (q *sqliteQueries) ListPayees(ctx context.Context, arg ListPayeesParams) ([]Payee, error) {
+	return sqliteQueryMany(ctx, q.db, scanPayee, `
+SELECT id, owner, nickname, account_id, currency, created_at FROM payees
+WHERE owner = ?1
+ORDER BY id
+LIMIT ?2
+OFFSET ?3
+`, arg.Owner, arg.Limit, arg.Offset)
+}
+
+func (q *sqliteQueries) UpdatePayee(ctx context.Context, arg UpdatePayeeParams) (Payee, error) {
+	return sqliteQueryOne(ctx, q.db, scanPayee, `
+UPDATE payees SET nickname = ?2
+WHERE id = ?1
+RETURNING id, owner, nickname, account_id, currency, created_at
+`, arg.ID, arg.Nickname)
+}
+
+func (q *sqliteQueries) CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (PaymentRequest, error) {
+	return sqliteQueryOne(ctx, q.db, scanPaymentRequest, `
+INSERT INTO payment_requests (
+  requester,
+  payer,
+  to_account_id,
+  amount,
+  currency,
+  description,
+  expires_at
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5, ?6, ?7
+) RETURNING id, requester, payer, to_account_id, amount, currency, description, status, transfer_id, expires_at, responded_at, created_at
+`, arg.Requester, arg.Payer, arg.ToAccountID, arg.Amount, arg.Currency, arg.Description, sqliteTime(arg.ExpiresAt))
+}
+
+func (q *sqliteQueries) ExpirePaymentRequests(ctx context.Context) (int64, error) {
+	return sqliteExec(ctx, q.db, `
+UPDATE payment_requests
+SET status = 'expired'
+WHERE status = 'pending' AND expires_at <= ?1
+`, sqliteTime(time.Now()))
+}
+
+func (q *sqliteQueries) GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error) {
+	return sqliteQueryOne(ctx, q.db, scanPaymentRequest, `
+SELECT id, requester, payer, to_account_id, amount, currency, description, status, transfer_id, expires_at, responded_at, created_at FROM payment_requests
+WHERE id = ?1 LIMIT 1
+`, id)
+}
+
+func (q *sqliteQueries) GetPaymentRequestForUpdate(ctx context.Context, id int64) (PaymentRequest, error) {
+	return q.GetPaymentRequest(ctx, id)
+}
+
+func (q *sqliteQueries) ListPaymentRequestsByPayer(ctx context.Context, arg ListPaymentRequestsByPayerParams) ([]PaymentRequest, error) {
+	return sqliteQueryMany(ctx, q.db, scanPaymentRequest, `
+SELECT id, requester, payer, to_account_id, amount, currency, description, status, transfer_id, expires_at, responded_at, created_at FROM payment_requests
+WHERE payer = ?1
+ORDER BY id
+LIMIT ?2
+OFFSET ?3
+`, arg.Payer, arg.Limit, arg.Offset)
+}
+
+func (q *sqliteQueries) ListPaymentRequestsByRequester(ctx context.Context, arg ListPaymentRequestsByRequesterParams) ([]PaymentRequest, error) {
+	return sqliteQueryMany(ctx, q.db, scanPaymentRequest, `
+SELECT id, requester, payer, to_account_id, amount, currency, description, status, transfer_id, expires_at, responded_at, created_at FROM payment_requests
+WHERE requester = ?1
+ORDER BY id
+LIMIT ?2
+OFFSET ?3
+`, arg.Requester, arg.Limit, arg.Offset)
+}
+
+func (q *sqliteQueries) UpdatePaymentRequestStatus(ctx context.Context, arg UpdatePaymentRequestStatusParams) (PaymentRequest, error) {
+	return sqliteQueryOne(ctx, q.db, scanPaymentRequest, `
+UPDATE payment_requests
+SET
+  status = ?1,
+  transfer_id = ?2,
+  responded_at = ?4
+WHERE id = ?3 AND status = 'pending'
+RETURNING id, requester, payer, to_account_id, amount, currency, description, status, transfer_id, expires_at, responded_at, created_at
+`, arg.Status, arg.TransferID, arg.ID, sqliteTime(time.Now()))
+}
+
+func (q *sqliteQueries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	return sqliteQueryOne(ctx, q.db, scanTransfer, `
+INSERT INTO transfers (
+  from_account_id,
+  to_account_id,
+  amount,
+  description,
+  reference,
+  category
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5, ?6
+) RETURNING id, from_account_id, to_account_id, amount, created_at, description, reference, category
+`, arg.FromAccountID, arg.ToAccountID, arg.Amount, arg.Description, arg.Reference, arg.Category)
+}
+
+func (q *sqliteQueries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	return sqliteQueryOne(ctx, q.db, scanTransfer, `
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reference, category FROM transfers
+WHERE id = ?1 LIMIT 1
+`, id)
+}
+
+func (q *sqliteQueries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	return sqliteQueryMany(ctx, q.db, scanTransfer, `
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reference, category FROM transfers
+WHERE
+    from_account_id = ?1 OR
+    to_account_id = ?2
+ORDER BY id
+LIMIT ?3
+OFFSET ?4
+`, arg.FromAccountID, arg.ToAccountID, arg.Limit, arg.Offset)
+}
+
+// SearchTransfers matches with LIKE, which ignores the case of ASCII letters
+// in SQLite like ILIKE does in Postgres.
+func (q *sqliteQueries) SearchTransfers(ctx context.Context, arg SearchTransfersParams) ([]Transfer, error) {
+	return sqliteQueryMany(ctx, q.db, scanTransfer, `
+SELECT id, from_account_id, to_account_id, amount, created_at, description, reference, category FROM transfers
+WHERE
+    (from_account_id = ?1 OR to_account_id = ?1)
+    AND (?2 = '' OR category = ?2)
+    AND (
+        ?3 = '' OR
+        description LIKE '%' || replace(replace(replace(?3, '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\' OR
+        reference LIKE '%' || replace(replace(replace(?3, '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\'
+    )
+ORDER BY id
+LIMIT ?4
+OFFSET ?5
+`, arg.AccountID, arg.Category, arg.Query, arg.Limit, arg.Offset)
+}
+
+func (q *sqliteQueries) CreateTransferApproval(ctx context.Context, arg CreateTransferApprovalParams) (TransferApproval, error) {
+	return sqliteQueryOne(ctx, q.db, scanTransferApproval, `
+INSERT INTO transfer_approvals (
+  maker,
+  from_account_id,
+  to_account_id,
+  amount,
+  description,
+  reference,
+  category,
+  expires_at,
+  payment_request_id
+) VALUES (
+  ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9
+) RETURNING id, maker, from_account_id, to_account_id, amount, description, reference, category, status, checker, transfer_id, expires_at, decided_at, created_at, payment_request_id
+`, arg.Maker, arg.FromAccountID, arg.ToAccountID, arg.Amount, arg.Description, arg.Reference, arg.Category, sqliteTime(arg.ExpiresAt), arg.PaymentRequestID)
+}
+
+func (q *sqliteQueries) DecideTransferApproval(ctx context.Context, arg DecideTransferApprovalParams) (TransferApproval, error) {
+	return sqliteQueryOne(ctx, q.db, scanTransferApproval, `
+UPDATE transfer_approvals
+SET
+  status = ?1,
+  checker = ?2,
+  transfer_id = ?3,
+  decided_at = ?5
+WHERE id = ?4
+RETURNING id, maker, from_account_id, to_account_id, amount, description, reference, category, status, checker, transfer_id, expires_at, decided_at, created_at, payment_request_id
+`, arg.Status, arg.Checker, arg.TransferID, arg.ID, sqliteTime(time.Now()))
+}
+
+func (q *sqliteQueries) ExpireTransferApprovals(ctx context.Context) ([]TransferApproval, error) {
+	return sqliteQueryMany(ctx, q.db, scanTransferApproval, `
+UPDATE transfer_approvals
+SET status = 'expired'
+WHERE status = 'pending_approval' AND expires_at <= ?1
+RETURNING id, maker, from_account_id, to_account_id, amount, description, reference, category, status, checker, transfer_id, expires_at, decided_at, created_at, payment_request_id
+`, sqliteTime(time.Now()))
+}
+
+func (q *sqliteQueries) GetTransferApproval(ctx context.Context, id int64) (TransferApproval, error) {
+	return sqliteQueryOne(ctx, q.db, scanTransferApproval, `
+SELECT id, maker, from_account_id, to_account_id, amount, description, reference, category, status, checker, transfer_id, expires_at, decided_at, created_at, payment_request_id FROM transfer_approvals
+WHERE id = ?1 LIMIT 1
+`, id)
+}
+
+func (q *sqliteQueries) GetTransferApprovalForUpdate(ctx context.Context, id int64) (TransferApproval, error) {
+	return q.GetTransferApproval(ctx, id)
+}
+
+func (q *sqliteQueries) ListPendingTransferApprovals(ctx context.Context, arg ListPendingTransferApprovalsParams) ([]TransferApproval, error) {
+	return sqliteQueryMany(ctx, q.db, scanTransferApproval, `
+SELECT id, maker, from_account_id, to_account_id, amount, description, reference, category, status, checker, transfer_id, expires_at, decided_at, created_at, payment_request_id FROM transfer_approvals
+WHERE status = 'pending_approval'
+ORDER BY id
+LIMIT ?1
+OFFSET ?2
+`, arg.Limit, arg.Offset)
+}
+
+func (q *sqliteQueries) ListPendingTransferApprovalsForHolder(ctx context.Context, arg ListPendingTransferApprovalsForHolderParams) ([]TransferApproval, error) {
+	return sqliteQueryMany(ctx, q.db, scanTransferApproval, `
+SELECT transfer_approvals.id, transfer_approvals.maker, transfer_approvals.from_account_id, transfer_approvals.to_account_id, transfer_approvals.amount, transfer_approvals.description, transfer_approvals.reference, transfer_approvals.category, transfer_approvals.status, transfer_approvals.checker, transfer_approvals.transfer_id, transfer_approvals.expires_at, transfer_approvals.decided_at, transfer_approvals.created_at, transfer_approvals.payment_request_id FROM transfer_approvals
+JOIN account_holders ON account_holders.account_id = transfer_approvals.from_account_id
+WHERE account_holders.username = ?1
+  AND account_holders.role IN ('primary', 'joint')
+  AND account_holders.status = 'accepted'
+  AND transfer_approvals.status = 'pending_approval'
+  AND transfer_approvals.maker <> ?1
+ORDER BY transfer_approvals.id
+LIMIT ?2
+OFFSET ?3
+`, arg.Username, arg.Limit, arg.Offset)
+}
+
+func (q *sqliteQueries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	return sqliteQueryOne(ctx, q.db, scanUser, `
+INSERT INTO users (
+  username,
+  hashed_password,
+  full_name,
+  email
+) VALUES (
+  ?1, ?2, ?3, ?4
+) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+`, arg.Username, arg.HashedPassword, arg.FullName, arg.Email)
+}
+
+func (q *sqliteQueries) GetUser(ctx context.Context, username string) (User, error) {
+	return sqliteQueryOne(ctx, q.db, scanUser, `
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at FROM users
+WHERE username = ?1 LIMIT 1
+`, username)
+}
+
+func (q *sqliteQueries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	return sqliteQueryMany(ctx, q.db, scanWebhookDelivery, `
+UPDATE webhook_deliveries
+SET next_attempt_at = ?1
+WHERE id IN (
+  SELECT id FROM webhook_deliveries
+  WHERE status = 'pending' AND next_attempt_at <= ?3
+  ORDER BY next_attempt_at
+  LIMIT ?2
+)
+RETURNING id, webhook_id, event_id, status, attempts, last_error, next_attempt_at, delivered_at, created_at
+`, sqliteTime(arg.LeaseUntil), arg.BatchSize, sqliteTime(time.Now()))
+}
+
+func (q *sqliteQueries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	return sqliteQueryOne(ctx, q.db, scanWebhook, `
+INSERT INTO webhooks (
+  owner,
+  url,
+  secret
+) VALUES (
+  ?1, ?2, ?3
+) RETURNING id, owner, url, secret, created_at
+`, arg.Owner, arg.Url, arg.Secret)
+}
+
+func (q *sqliteQueries) DeleteWebhook(ctx context.Context, id int64) error {
+	_, err := sqliteExec(ctx, q.db, `
+DELETE FROM webhooks WHERE id = ?1
+`, id)
+	return err
+}
+
+func (q *sqliteQueries) GetWebhook(ctx context.Context, id int64) (Webhook, error) {
+	return sqliteQueryOne(ctx, q.db, scanWebhook, `
+SELECT id, owner, url, secret, created_at FROM webhooks
+WHERE id = ?1 LIMIT 1
+`, id)
+}
+
+func (q *sqliteQueries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	return sqliteQueryOne(ctx, q.db, scanWebhookDelivery, `
+SELECT id, webhook_id, event_id, status, attempts, last_error, next_attempt_at, delivered_at, created_at FROM webhook_deliveries
+WHERE id = ?1 LIMIT 1
+`, id)
+}
+
+func (q *sqliteQueries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	return sqliteQueryMany(ctx, q.db, scanWebhookDelivery, `
+SELECT id, webhook_id, event_id, status, attempts, last_error, next_attempt_at, delivered_at, created_at FROM webhook_deliveries
+WHERE status = ?1
+ORDER BY id
+LIMIT ?2
+OFFSET ?3
+`, arg.Status, arg.Limit, arg.Offset)
+}
+
+func (q *sqliteQueries) ListWebhooks(ctx context.Context, arg ListWebhooksParams) ([]Webhook, error) {
+	return sqliteQueryMany(ctx, q.db, scanWebhook, `
+SELECT id, owner, url, secret, created_at FROM webhooks
+ORDER BY id
+LIMIT ?1
+OFFSET ?2
+`, arg.Limit, arg.Offset)
+}
+
+func (q *sqliteQueries) MarkWebhookDelivered(ctx context.Context, id int64) error {
+	_, err := sqliteExec(ctx, q.db, `
+UPDATE webhook_deliveries
+SET
+  status = 'delivered',
+  attempts = attempts + 1,
+  last_error = '',
+  delivered_at = ?2
+WHERE id = ?1
+`, id, sqliteTime(time.Now()))
+	return err
+}
+
+func (q *sqliteQueries) MarkWebhookDeliveryFailed(ctx context.Context, arg MarkWebhookDeliveryFailedParams) error {
+	_, err := sqliteExec(ctx, q.db, `
+UPDATE webhook_deliveries
+SET
+  status = ?1,
+  attempts = attempts + 1,
+  last_error = ?2,
+  next_attempt_at = ?3
+WHERE id = ?4
+`, arg.Status, arg.LastError, sqliteTime(arg.NextAttemptAt), arg.ID)
+	return err
+}
+
+func (q *sqliteQueries) ReplayWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	return sqliteQueryOne(ctx, q.db, scanWebhookDelivery, `
+UPDATE webhook_deliveries
+SET
+  status = 'pending',
+  attempts = 0,
+  last_error = '',
+  next_attempt_at = ?2
+WHERE id = ?1
+RETURNING id, webhook_id, event_id, status, attempts, last_error, next_attempt_at, delivered_at, created_at
+`, id, sqliteTime(time.Now()))
+}
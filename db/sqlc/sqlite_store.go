@@ -0,0 +1,317 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLiteStore is a Store backed by a SQLite database file, for single-binary
+// local deployments. Its schema is migrated by the SQLite migrations in
+// db/migration/sqlite, which mirror the Postgres ones, and it returns the
+// same errors as SQLStore.
+//
+// The database is used through a single connection, so transactions run one
+// after another and never conflict.
+type SQLiteStore struct {
+	*sqliteQueries
+	db *sql.DB
+
+	events accountEventHub
+}
+
+// OpenSQLite opens the SQLite database file at dbSource with the settings
+// that SQLiteStore relies on.
+func OpenSQLite(dbSource string) (*sql.DB, error) {
+	// transactions take the write lock when they begin rather than when they
+	// first write, so that they cannot deadlock upgrading their lock
+	params := "_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	if strings.Contains(dbSource, "?") {
+		dbSource += "&" + params
+	} else {
+		dbSource += "?" + params
+	}
+
+	db, err := sql.Open("sqlite", dbSource)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer at a time, and a single connection
+	// serializes the transactions instead of failing them with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	return db, nil
+}
+
+func NewSQLiteStore(db *sql.DB) Store {
+	store := &SQLiteStore{db: db}
+	// outside of a transaction every query commits as soon as it returns
+	store.sqliteQueries = &sqliteQueries{db: db, notify: store.events.notify}
+	return store
+}
+
+// execTx executes a function within a database transaction. The account
+// events published by the transaction are only notified once it commits, as
+// Postgres does with NOTIFY.
+func (store *SQLiteStore) execTx(ctx context.Context, fn func(*sqliteQueries) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return translateSQLiteError(err)
+	}
+
+	var notifications []AccountEventNotification
+	q := &sqliteQueries{db: tx, notify: func(notification AccountEventNotification) {
+		notifications = append(notifications, notification)
+	}}
+
+	err = fn(q)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %w, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return translateSQLiteError(err)
+	}
+
+	for _, notification := range notifications {
+		store.events.notify(notification)
+	}
+	return nil
+}
+
+func (store *SQLiteStore) TransferTx(ctx context.Context, arg TransferTXParams) (TransferTXResult, error) {
+	var result TransferTXResult
+
+	err := store.execTx(ctx, func(q *sqliteQueries) error {
+		var err error
+		result, err = transfer(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+func (store *SQLiteStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *sqliteQueries) error {
+		var err error
+		account, err = openAccount(ctx, q, arg)
+		return err
+	})
+
+	return account, err
+}
+
+func (store *SQLiteStore) AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error) {
+	var result AcceptPaymentRequestTxResult
+
+	err := store.execTx(ctx, func(q *sqliteQueries) error {
+		var err error
+		result, err = acceptPaymentRequest(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+func (store *SQLiteStore) RequestPaymentApprovalTx(ctx context.Context, arg RequestPaymentApprovalTxParams) (TransferApproval, error) {
+	var approval TransferApproval
+
+	err := store.execTx(ctx, func(q *sqliteQueries) error {
+		var err error
+		approval, err = requestPaymentApproval(ctx, q, arg)
+		return err
+	})
+
+	return approval, err
+}
+
+func (store *SQLiteStore) CreateTransferApprovalTx(ctx context.Context, arg CreateTransferApprovalParams) (TransferApproval, error) {
+	var approval TransferApproval
+
+	err := store.execTx(ctx, func(q *sqliteQueries) error {
+		var err error
+		approval, err = requestTransferApproval(ctx, q, arg)
+		return err
+	})
+
+	return approval, err
+}
+
+func (store *SQLiteStore) ApproveTransferTx(ctx context.Context, arg DecideTransferTxParams) (ApproveTransferTxResult, error) {
+	var result ApproveTransferTxResult
+
+	err := store.execTx(ctx, func(q *sqliteQueries) error {
+		var err error
+		result, err = approveTransfer(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+func (store *SQLiteStore) RejectTransferTx(ctx context.Context, arg DecideTransferTxParams) (TransferApproval, error) {
+	var result TransferApproval
+
+	err := store.execTx(ctx, func(q *sqliteQueries) error {
+		var err error
+		result, err = rejectTransfer(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+func (store *SQLiteStore) ExpireTransferApprovalsTx(ctx context.Context) (int64, error) {
+	var expired int64
+
+	err := store.execTx(ctx, func(q *sqliteQueries) error {
+		var err error
+		expired, err = expirePendingTransferApprovals(ctx, q)
+		return err
+	})
+
+	return expired, err
+}
+
+// FanOutOutboxEvents takes two statements in SQLite, which must run in the
+// same transaction.
+func (store *SQLiteStore) FanOutOutboxEvents(ctx context.Context, limit int32) (int64, error) {
+	var queued int64
+
+	err := store.execTx(ctx, func(q *sqliteQueries) error {
+		var err error
+		queued, err = q.FanOutOutboxEvents(ctx, limit)
+		return err
+	})
+
+	return queued, err
+}
+
+// ListenAccountEvents calls handle for every account.balance_changed event
+// committed until ctx is cancelled. Only the events written through this
+// store are notified.
+func (store *SQLiteStore) ListenAccountEvents(ctx context.Context, handle func(AccountEventNotification)) error {
+	return store.events.listen(ctx, handle)
+}
+
+// The FromReplica reads are served like any other, since a SQLiteStore has no
+// replica.
+
+func (store *SQLiteStore) ListAccountsFromReplica(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	return store.ListAccounts(ctx, arg)
+}
+
+func (store *SQLiteStore) SearchTransfersFromReplica(ctx context.Context, arg SearchTransfersParams) ([]Transfer, error) {
+	return store.SearchTransfers(ctx, arg)
+}
+
+func (store *SQLiteStore) ListNotificationsFromReplica(ctx context.Context, arg ListNotificationsParams) ([]Notification, error) {
+	return store.ListNotifications(ctx, arg)
+}
+
+func (store *SQLiteStore) ListAuditLogsFromReplica(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error) {
+	return store.ListAuditLogs(ctx, arg)
+}
+
+func (store *SQLiteStore) Ping(ctx context.Context) error {
+	return store.db.PingContext(ctx)
+}
+
+func (store *SQLiteStore) MigrationVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	err = store.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	return version, dirty, translateSQLiteError(err)
+}
+
+// TxStats is always zero, since transactions of a SQLiteStore never conflict.
+func (store *SQLiteStore) TxStats() TxStats {
+	return TxStats{}
+}
+
+// sqliteUniqueConstraints names the unique constraints of the Postgres
+// schema after the columns that SQLite reports when one is violated.
+var sqliteUniqueConstraints = map[string]string{
+	"users.username":                  "users_pkey",
+	"users.email":                     "users_email_key",
+	"account.owner, account.currency": "owner_currency_key",
+	"account.account_number":          "account_number_key",
+	"account_holders.account_id, account_holders.username":       "account_holders_pkey",
+	"payees.owner, payees.nickname":                              "owner_nickname_key",
+	"payees.owner, payees.account_id":                            "owner_account_key",
+	"webhook_deliveries.webhook_id, webhook_deliveries.event_id": "webhook_event_key",
+}
+
+// translateSQLiteError returns err as the error SQLStore would return for
+// the same failure.
+func translateSQLiteError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRecordNotFound
+	}
+
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	// the messages are "constraint failed: UNIQUE constraint failed:
+	// account.owner, account.currency (2067)" and "constraint failed: CHECK
+	// constraint failed: name (275)"
+	detail := sqliteErr.Error()
+	if i := strings.LastIndex(detail, "constraint failed: "); i >= 0 {
+		detail = detail[i+len("constraint failed: "):]
+	}
+	detail, _, _ = strings.Cut(detail, " (")
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return &Error{Kind: ErrUniqueViolation, Constraint: sqliteUniqueConstraints[detail], Err: err}
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return &Error{Kind: ErrForeignKeyViolation, Err: err}
+	case sqlite3.SQLITE_CONSTRAINT_CHECK:
+		return &Error{Kind: ErrCheckViolation, Constraint: detail, Err: err}
+	default:
+		return err
+	}
+}
+
+// sqliteTimeLayout is the layout of the timestamps stored by SQLiteStore,
+// which sorts chronologically as text.
+const sqliteTimeLayout = "2006-01-02 15:04:05.000000"
+
+// sqliteTime formats t to be stored in a timestamp column.
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// sqliteTimestamp scans a timestamp column into t, whether the driver has
+// parsed it already or returns the stored text.
+type sqliteTimestamp struct {
+	t *time.Time
+}
+
+func (timestamp sqliteTimestamp) Scan(src any) error {
+	var err error
+	switch src := src.(type) {
+	case time.Time:
+		*timestamp.t = src
+	case string:
+		*timestamp.t, err = time.Parse(time.DateTime, src)
+	case []byte:
+		*timestamp.t, err = time.Parse(time.DateTime, string(src))
+	default:
+		err = fmt.Errorf("cannot scan %T into a timestamp", src)
+	}
+	return err
+}
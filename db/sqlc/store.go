@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"sync/atomic"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -10,24 +11,58 @@ import (
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTXParams) (TransferTXResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error)
+	AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error)
+	RequestPaymentApprovalTx(ctx context.Context, arg RequestPaymentApprovalTxParams) (TransferApproval, error)
+	CreateTransferApprovalTx(ctx context.Context, arg CreateTransferApprovalParams) (TransferApproval, error)
+	ApproveTransferTx(ctx context.Context, arg DecideTransferTxParams) (ApproveTransferTxResult, error)
+	RejectTransferTx(ctx context.Context, arg DecideTransferTxParams) (TransferApproval, error)
+	ExpireTransferApprovalsTx(ctx context.Context) (int64, error)
+	// The FromReplica reads may be served by a read replica, and return data
+	// up to DBReplicaMaxLag old. They are only for displaying data, never for
+	// authorizing a request or deciding a write.
+	ListAccountsFromReplica(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	SearchTransfersFromReplica(ctx context.Context, arg SearchTransfersParams) ([]Transfer, error)
+	ListNotificationsFromReplica(ctx context.Context, arg ListNotificationsParams) ([]Notification, error)
+	ListAuditLogsFromReplica(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error)
+	ListenAccountEvents(ctx context.Context, handle func(AccountEventNotification)) error
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (version int64, dirty bool, err error)
+	TxStats() TxStats
 }
 type SQLStore struct {
 	*Queries
 	connPool *pgxpool.Pool
+	// replica, if any, serves the reads that tolerate stale data.
+	replica *replica
+
+	maxTxRetries         int
+	serializationRetries atomic.Int64
+	deadlockRetries      atomic.Int64
+	exhaustedRetries     atomic.Int64
 }
 
-func NewStore(connPool *pgxpool.Pool) Store {
-	return &SQLStore{
-		Queries:  New(connPool),
-		connPool: connPool,
+func NewStore(connPool *pgxpool.Pool, opts ...StoreOption) Store {
+	store := &SQLStore{
+		Queries:      New(errorTranslatingDBTX{connPool}),
+		connPool:     connPool,
+		maxTxRetries: defaultMaxTxRetries,
+	}
+	for _, opt := range opts {
+		opt(store)
 	}
+
+	return store
 }
 
 // TransferTXResult is the result of a transfer transaction.
 type TransferTXParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Description   string `json:"description"`
+	Reference     string `json:"reference"`
+	Category      string `json:"category"`
 }
 
 // transferTXResult is the result of a transfer transaction.
@@ -41,56 +76,92 @@ type TransferTXResult struct {
 
 // TranferTx performs a money transfer from one account to another.
 // It creates a transfer record, adds an entry for the source account, and adds an entry for the destination account.
+// The entries are chained to the previous entries of their accounts, see EntryHash.
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTXParams) (TransferTXResult, error) {
 	var result TransferTXResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	err := store.execTx(ctx, readCommitted, func(q *Queries) error {
 		var err error
+		result, err = transfer(ctx, q, arg)
+		return err
+	})
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
+	return result, err
+}
 
-		if err != nil {
-			return err
-		}
+// transfer moves money between two accounts using q, which must be bound to a
+// transaction. It is shared by every transaction that ends in a transfer, in
+// every store backed by a database.
+func transfer(ctx context.Context, q Querier, arg TransferTXParams) (TransferTXResult, error) {
+	var result TransferTXResult
+	var err error
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Description:   arg.Description,
+		Reference:     arg.Reference,
+		Category:      arg.Category,
+	})
+	if err != nil {
+		return result, err
+	}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount, // Subtract from the source account
-		})
-		if err != nil {
-			return err
-		}
+	// the balances are updated before the entries are written, so that the
+	// entries are chained under the row locks of their accounts
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	}
+	if err != nil {
+		return result, err
+	}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount, // Add to the destination account
-		})
+	// the balances were updated under row locks, so the check cannot race
+	// with a concurrent transfer from the same account
+	if result.FromAccount.Balance < 0 {
+		return result, ErrInsufficientFunds
+	}
 
-		if err != nil {
-			return err
-		}
+	result.FromEntry, err = addEntry(ctx, q, result.Transfer, arg.FromAccountID, -arg.Amount) // Subtract from the source account
+	if err != nil {
+		return result, err
+	}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	result.ToEntry, err = addEntry(ctx, q, result.Transfer, arg.ToAccountID, arg.Amount) // Add to the destination account
+	if err != nil {
+		return result, err
+	}
 
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-		}
+	return result, publishTransfer(ctx, q, result)
+}
 
-		return nil
+// publishTransfer records the outbox events of a completed transfer.
+func publishTransfer(ctx context.Context, q Querier, result TransferTXResult) error {
+	err := publish(ctx, q, EventTransferCompleted, result.Transfer.ID, newTransferCompletedEvent(result))
+	if err != nil {
+		return err
+	}
 
+	err = publish(ctx, q, EventBalanceChanged, result.FromAccount.ID, BalanceChangedEvent{
+		Account: result.FromAccount,
+		Entry:   result.FromEntry,
 	})
+	if err != nil {
+		return err
+	}
 
-	return result, err
+	return publish(ctx, q, EventBalanceChanged, result.ToAccount.ID, BalanceChangedEvent{
+		Account: result.ToAccount,
+		Entry:   result.ToEntry,
+	})
 }
 
 func addMoney(
 	ctx context.Context,
-	q *Queries,
+	q Querier,
 	accountID1 int64,
 	amount1 int64,
 	accountID2 int64,
@@ -0,0 +1,966 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"simple_bank/db/migration"
+	"simple_bank/util"
+
+	"github.com/stretchr/testify/require"
+	"modernc.org/sqlite"
+)
+
+// TestStoreConformance runs the same tests against every Store
+// implementation, so that MemoryStore and SQLiteStore keep behaving like
+// SQLStore. Run only the stores that need no database server with
+// -run 'TestStoreConformance/(MemoryStore|SQLiteStore)'.
+func TestStoreConformance(t *testing.T) {
+	stores := []struct {
+		name     string
+		newStore func(t *testing.T) Store
+	}{
+		{"SQLStore", func(t *testing.T) Store { return testStore }},
+		{"MemoryStore", func(t *testing.T) Store { return NewMemoryStore() }},
+		{"SQLiteStore", newTestSQLiteStore},
+	}
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, store Store)
+	}{
+		{"Users", testConformanceUsers},
+		{"Accounts", testConformanceAccounts},
+		{"AccountHolders", testConformanceAccountHolders},
+		{"AccountInvitations", testConformanceAccountInvitations},
+		{"DeleteAccount", testConformanceDeleteAccount},
+		{"Payees", testConformancePayees},
+		{"TransferTx", testConformanceTransferTx},
+		{"TransferTxRollsBack", testConformanceTransferTxRollsBack},
+		{"SearchTransfersLiteral", testConformanceSearchTransfersLiteral},
+		{"TransferTxConcurrent", testConformanceTransferTxConcurrent},
+		{"AcceptPaymentRequestTx", testConformanceAcceptPaymentRequestTx},
+		{"RequestPaymentApprovalTx", testConformanceRequestPaymentApprovalTx},
+		{"TransferApprovals", testConformanceTransferApprovals},
+		{"WebhookDeliveries", testConformanceWebhookDeliveries},
+		{"ListenAccountEvents", testConformanceListenAccountEvents},
+		{"AuditLog", testConformanceAuditLog},
+		{"LedgerChain", testConformanceLedgerChain},
+	}
+
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			store := s.newStore(t)
+			for _, tc := range tests {
+				t.Run(tc.name, func(t *testing.T) {
+					tc.run(t, store)
+				})
+			}
+		})
+	}
+}
+
+// newTestSQLiteStore returns a SQLiteStore on a new database file, migrated
+// to the latest version.
+func newTestSQLiteStore(t *testing.T) Store {
+	dbSource := filepath.Join(t.TempDir(), "simple_bank.db")
+
+	migrator, err := migration.New(util.DBDriverSQLite, dbSource)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up(0))
+	require.NoError(t, migrator.Close())
+
+	conn, err := OpenSQLite(dbSource)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return NewSQLiteStore(conn)
+}
+
+func conformanceUser(t *testing.T, store Store) User {
+	user, err := store.CreateUser(context.Background(), CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: util.RandomString(32),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func conformanceAccount(t *testing.T, store Store, balance int64) Account {
+	account, err := store.CreateAccountTx(context.Background(), CreateAccountParams{
+		Owner:         conformanceUser(t, store).Username,
+		Balance:       balance,
+		Currency:      util.RandomCurrency(),
+		AccountNumber: util.RandomAccountNumber(),
+	})
+	require.NoError(t, err)
+	return account
+}
+
+// requireConstraint checks that err is a violation of the given kind of
+// constraint.
+func requireConstraint(t *testing.T, err error, kind error, constraint string) {
+	require.ErrorIs(t, err, kind)
+
+	var dbErr *Error
+	require.ErrorAs(t, err, &dbErr)
+	// SQLite does not report which foreign key was violated
+	var sqliteErr *sqlite.Error
+	if kind == ErrForeignKeyViolation && errors.As(err, &sqliteErr) {
+		return
+	}
+	require.Equal(t, constraint, dbErr.Constraint)
+}
+
+func testConformanceUsers(t *testing.T, store Store) {
+	ctx := context.Background()
+	user := conformanceUser(t, store)
+	require.True(t, user.PasswordChangedAt.IsZero())
+	require.NotZero(t, user.CreatedAt)
+
+	got, err := store.GetUser(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Email, got.Email)
+
+	_, err = store.CreateUser(ctx, CreateUserParams{
+		Username: user.Username,
+		Email:    util.RandomEmail(),
+	})
+	requireConstraint(t, err, ErrUniqueViolation, "users_pkey")
+
+	_, err = store.CreateUser(ctx, CreateUserParams{
+		Username: util.RandomOwner(),
+		Email:    user.Email,
+	})
+	requireConstraint(t, err, ErrUniqueViolation, "users_email_key")
+
+	_, err = store.GetUser(ctx, util.RandomOwner())
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func testConformanceAccounts(t *testing.T, store Store) {
+	ctx := context.Background()
+	account := conformanceAccount(t, store, 100)
+	require.NotZero(t, account.ID)
+	require.Equal(t, int64(100), account.Balance)
+
+	got, err := store.GetAccountByNumber(ctx, account.AccountNumber)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, got.ID)
+
+	accounts, err := store.ListAccountsFromReplica(ctx, ListAccountsParams{Username: account.Owner, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	_, err = store.CreateAccount(ctx, CreateAccountParams{
+		Owner:         account.Owner,
+		Currency:      account.Currency,
+		AccountNumber: util.RandomAccountNumber(),
+	})
+	requireConstraint(t, err, ErrUniqueViolation, "owner_currency_key")
+
+	_, err = store.CreateAccount(ctx, CreateAccountParams{
+		Owner:         conformanceUser(t, store).Username,
+		Currency:      account.Currency,
+		AccountNumber: account.AccountNumber,
+	})
+	requireConstraint(t, err, ErrUniqueViolation, "account_number_key")
+
+	_, err = store.CreateAccount(ctx, CreateAccountParams{
+		Owner:         util.RandomOwner(),
+		Currency:      account.Currency,
+		AccountNumber: util.RandomAccountNumber(),
+	})
+	requireConstraint(t, err, ErrForeignKeyViolation, "account_owner_fkey")
+
+	updated, err := store.AddAccountBalance(ctx, AddAccountBalanceParams{ID: account.ID, Amount: -30})
+	require.NoError(t, err)
+	require.Equal(t, int64(70), updated.Balance)
+
+	_, err = store.GetAccount(ctx, account.ID+1_000_000)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func testConformanceAccountHolders(t *testing.T, store Store) {
+	ctx := context.Background()
+	account := conformanceAccount(t, store, 100)
+	joint := conformanceUser(t, store)
+
+	_, err := store.CreateAccountHolder(ctx, CreateAccountHolderParams{
+		AccountID: account.ID,
+		Username:  joint.Username,
+		Role:      AccountHolderRoleJoint,
+		Status:    AccountHolderStatusAccepted,
+	})
+	require.NoError(t, err)
+
+	holders, err := store.ListAccountHolders(ctx, account.ID)
+	require.NoError(t, err)
+	require.Len(t, holders, 2)
+	require.Equal(t, account.Owner, holders[0].Username)
+	require.Equal(t, AccountHolderRolePrimary, holders[0].Role)
+
+	// joint holders see the account in their list
+	accounts, err := store.ListAccounts(ctx, ListAccountsParams{Username: joint.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	_, err = store.CreateAccountHolder(ctx, CreateAccountHolderParams{
+		AccountID: account.ID,
+		Username:  joint.Username,
+		Role:      AccountHolderRoleViewOnly,
+		Status:    AccountHolderStatusAccepted,
+	})
+	requireConstraint(t, err, ErrUniqueViolation, "account_holders_pkey")
+
+	_, err = store.CreateAccountHolder(ctx, CreateAccountHolderParams{
+		AccountID: account.ID,
+		Username:  conformanceUser(t, store).Username,
+		Role:      "owner",
+		Status:    AccountHolderStatusAccepted,
+	})
+	requireConstraint(t, err, ErrCheckViolation, "account_holders_role_check")
+
+	err = store.DeleteAccountHolder(ctx, DeleteAccountHolderParams{AccountID: account.ID, Username: joint.Username})
+	require.NoError(t, err)
+
+	_, err = store.GetAccountHolder(ctx, GetAccountHolderParams{AccountID: account.ID, Username: joint.Username})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	_, err = store.CreateAccountHolder(ctx, CreateAccountHolderParams{
+		AccountID: account.ID,
+		Username:  conformanceUser(t, store).Username,
+		Role:      AccountHolderRoleJoint,
+		Status:    "invited",
+	})
+	requireConstraint(t, err, ErrCheckViolation, "account_holders_status_check")
+}
+
+func testConformanceAccountInvitations(t *testing.T, store Store) {
+	ctx := context.Background()
+	account := conformanceAccount(t, store, 100)
+	invitee := conformanceUser(t, store)
+
+	invited, err := store.CreateAccountHolder(ctx, CreateAccountHolderParams{
+		AccountID: account.ID,
+		Username:  invitee.Username,
+		Role:      AccountHolderRoleJoint,
+		Status:    AccountHolderStatusPending,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountHolderStatusPending, invited.Status)
+
+	// a pending invitee does not see the account yet
+	accounts, err := store.ListAccounts(ctx, ListAccountsParams{Username: invitee.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	invitations, err := store.ListAccountInvitations(ctx, invitee.Username)
+	require.NoError(t, err)
+	require.Len(t, invitations, 1)
+	require.Equal(t, account.ID, invitations[0].AccountID)
+
+	accepted, err := store.AcceptAccountHolder(ctx, AcceptAccountHolderParams{AccountID: account.ID, Username: invitee.Username})
+	require.NoError(t, err)
+	require.Equal(t, AccountHolderStatusAccepted, accepted.Status)
+	require.Equal(t, AccountHolderRoleJoint, accepted.Role)
+
+	_, err = store.AcceptAccountHolder(ctx, AcceptAccountHolderParams{AccountID: account.ID, Username: invitee.Username})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	invitations, err = store.ListAccountInvitations(ctx, invitee.Username)
+	require.NoError(t, err)
+	require.Empty(t, invitations)
+
+	accounts, err = store.ListAccounts(ctx, ListAccountsParams{Username: invitee.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+}
+
+func testConformanceDeleteAccount(t *testing.T, store Store) {
+	ctx := context.Background()
+	account1 := conformanceAccount(t, store, 100)
+	account2 := conformanceAccount(t, store, 100)
+
+	// the history of an account keeps it from being deleted
+	_, err := store.TransferTx(ctx, TransferTXParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+
+	err = store.DeleteAccount(ctx, account1.ID)
+	require.ErrorIs(t, err, ErrForeignKeyViolation)
+
+	// an unused account is deleted along with its holders
+	account3 := conformanceAccount(t, store, 100)
+	require.NoError(t, store.DeleteAccount(ctx, account3.ID))
+
+	_, err = store.GetAccount(ctx, account3.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	holders, err := store.ListAccountHolders(ctx, account3.ID)
+	require.NoError(t, err)
+	require.Empty(t, holders)
+}
+
+func testConformancePayees(t *testing.T, store Store) {
+	ctx := context.Background()
+	owner := conformanceUser(t, store)
+	account1 := conformanceAccount(t, store, 100)
+	account2 := conformanceAccount(t, store, 100)
+
+	payee, err := store.CreatePayee(ctx, CreatePayeeParams{
+		Owner:     owner.Username,
+		Nickname:  "landlord",
+		AccountID: account1.ID,
+		Currency:  account1.Currency,
+	})
+	require.NoError(t, err)
+
+	_, err = store.CreatePayee(ctx, CreatePayeeParams{
+		Owner:     owner.Username,
+		Nickname:  "landlord",
+		AccountID: account2.ID,
+		Currency:  account2.Currency,
+	})
+	requireConstraint(t, err, ErrUniqueViolation, "owner_nickname_key")
+
+	_, err = store.CreatePayee(ctx, CreatePayeeParams{
+		Owner:     owner.Username,
+		Nickname:  "plumber",
+		AccountID: account1.ID,
+		Currency:  account1.Currency,
+	})
+	requireConstraint(t, err, ErrUniqueViolation, "owner_account_key")
+
+	_, err = store.CreatePayee(ctx, CreatePayeeParams{
+		Owner:     owner.Username,
+		Nickname:  "plumber",
+		AccountID: account2.ID + 1_000_000,
+		Currency:  account2.Currency,
+	})
+	requireConstraint(t, err, ErrForeignKeyViolation, "payees_account_id_fkey")
+
+	byAccount, err := store.GetPayeeByAccount(ctx, GetPayeeByAccountParams{Owner: owner.Username, AccountID: account1.ID})
+	require.NoError(t, err)
+	require.Equal(t, payee.ID, byAccount.ID)
+
+	_, err = store.GetPayeeByAccount(ctx, GetPayeeByAccountParams{Owner: owner.Username, AccountID: account2.ID})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	updated, err := store.UpdatePayee(ctx, UpdatePayeeParams{ID: payee.ID, Nickname: "old landlord"})
+	require.NoError(t, err)
+	require.Equal(t, "old landlord", updated.Nickname)
+
+	require.NoError(t, store.DeletePayee(ctx, payee.ID))
+	_, err = store.GetPayee(ctx, payee.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func testConformanceTransferTx(t *testing.T, store Store) {
+	ctx := context.Background()
+	account1 := conformanceAccount(t, store, 100)
+	account2 := conformanceAccount(t, store, 100)
+
+	result, err := store.TransferTx(ctx, TransferTXParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        30,
+		Reference:     "INV-1",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(70), result.FromAccount.Balance)
+	require.Equal(t, int64(130), result.ToAccount.Balance)
+	require.Equal(t, int64(-30), result.FromEntry.Amount)
+	require.Equal(t, int64(30), result.ToEntry.Amount)
+
+	transfer, err := store.GetTransfer(ctx, result.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, "INV-1", transfer.Reference)
+
+	entries, err := store.ListEntries(ctx, ListEntriesParams{AccountID: account2.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, result.ToEntry.ID, entries[0].ID)
+
+	transfers, err := store.SearchTransfers(ctx, SearchTransfersParams{AccountID: account2.ID, Query: "inv", Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+
+	// the balance change is published to the outbox
+	events, err := store.ListAccountEvents(ctx, ListAccountEventsParams{AccountID: account1.ID, BatchSize: 5})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	var changed BalanceChangedEvent
+	require.NoError(t, json.Unmarshal(events[0].Payload, &changed))
+	require.Equal(t, int64(70), changed.Account.Balance)
+	require.Equal(t, result.FromEntry.ID, changed.Entry.ID)
+	// the internal account ids are not published
+	require.NotContains(t, string(events[0].Payload), "account_id")
+}
+
+func testConformanceSearchTransfersLiteral(t *testing.T, store Store) {
+	ctx := context.Background()
+	account1 := conformanceAccount(t, store, 100)
+	account2 := conformanceAccount(t, store, 100)
+
+	descriptions := []string{"100% rent", "1000 rent", "rent_due", "rent due", `C:\rent`}
+	transfers := make([]Transfer, len(descriptions))
+	for i, description := range descriptions {
+		result, err := store.TransferTx(ctx, TransferTXParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        1,
+			Description:   description,
+		})
+		require.NoError(t, err)
+		transfers[i] = result.Transfer
+	}
+
+	// LIKE wildcards in the query only match themselves
+	testCases := []struct {
+		query string
+		want  []Transfer
+	}{
+		{"0%", []Transfer{transfers[0]}},
+		{"%", []Transfer{transfers[0]}},
+		{"t_d", []Transfer{transfers[2]}},
+		{`\`, []Transfer{transfers[4]}},
+	}
+	for _, tc := range testCases {
+		found, err := store.SearchTransfers(ctx, SearchTransfersParams{AccountID: account1.ID, Query: tc.query, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, found, len(tc.want), "query %q", tc.query)
+		for i := range tc.want {
+			require.Equal(t, tc.want[i].ID, found[i].ID, "query %q", tc.query)
+		}
+	}
+}
+
+func testConformanceTransferTxRollsBack(t *testing.T, store Store) {
+	ctx := context.Background()
+	account1 := conformanceAccount(t, store, 100)
+	account2 := conformanceAccount(t, store, 100)
+
+	_, err := store.TransferTx(ctx, TransferTXParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 101})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.TransferTx(ctx, TransferTXParams{FromAccountID: account1.ID, ToAccountID: account2.ID + 1_000_000, Amount: 10})
+	requireConstraint(t, err, ErrForeignKeyViolation, "transfers_to_account_id_fkey")
+
+	// neither transfer left any trace
+	for _, account := range []Account{account1, account2} {
+		got, err := store.GetAccount(ctx, account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, got.Balance)
+
+		entries, err := store.ListEntries(ctx, ListEntriesParams{AccountID: account.ID, Limit: 5})
+		require.NoError(t, err)
+		require.Empty(t, entries)
+
+		transfers, err := store.ListTransfers(ctx, ListTransfersParams{FromAccountID: account.ID, ToAccountID: account.ID, Limit: 5})
+		require.NoError(t, err)
+		require.Empty(t, transfers)
+
+		events, err := store.ListAccountEvents(ctx, ListAccountEventsParams{AccountID: account.ID, BatchSize: 5})
+		require.NoError(t, err)
+		require.Empty(t, events)
+	}
+}
+
+func testConformanceTransferTxConcurrent(t *testing.T, store Store) {
+	ctx := context.Background()
+	account1 := conformanceAccount(t, store, 100)
+	account2 := conformanceAccount(t, store, 100)
+
+	// only three of the transfers fit in the balance of account1
+	n := 10
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(ctx, TransferTXParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 30})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+	}
+	require.Equal(t, 3, succeeded)
+
+	got1, err := store.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), got1.Balance)
+
+	got2, err := store.GetAccount(ctx, account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(190), got2.Balance)
+}
+
+func testConformanceAcceptPaymentRequestTx(t *testing.T, store Store) {
+	ctx := context.Background()
+	toAccount := conformanceAccount(t, store, 100)
+	fromAccount := conformanceAccount(t, store, 100)
+
+	paymentRequest, err := store.CreatePaymentRequest(ctx, CreatePaymentRequestParams{
+		Requester:   toAccount.Owner,
+		Payer:       fromAccount.Owner,
+		ToAccountID: toAccount.ID,
+		Amount:      40,
+		Currency:    toAccount.Currency,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestStatusPending, paymentRequest.Status)
+
+	arg := AcceptPaymentRequestTxParams{PaymentRequestID: paymentRequest.ID, FromAccountID: fromAccount.ID}
+	result, err := store.AcceptPaymentRequestTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestStatusAccepted, result.PaymentRequest.Status)
+	require.Equal(t, result.Transfer.ID, result.PaymentRequest.TransferID.Int64)
+	require.Equal(t, int64(60), result.FromAccount.Balance)
+
+	// a request is only paid once
+	_, err = store.AcceptPaymentRequestTx(ctx, arg)
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+
+	// and a late decline does not overwrite the acceptance
+	_, err = store.UpdatePaymentRequestStatus(ctx, UpdatePaymentRequestStatusParams{
+		ID:     paymentRequest.ID,
+		Status: PaymentRequestStatusDeclined,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	accepted, err := store.GetPaymentRequest(ctx, paymentRequest.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestStatusAccepted, accepted.Status)
+	require.Equal(t, result.Transfer.ID, accepted.TransferID.Int64)
+
+	expired, err := store.CreatePaymentRequest(ctx, CreatePaymentRequestParams{
+		Requester:   toAccount.Owner,
+		Payer:       fromAccount.Owner,
+		ToAccountID: toAccount.ID,
+		Amount:      40,
+		Currency:    toAccount.Currency,
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	_, err = store.AcceptPaymentRequestTx(ctx, AcceptPaymentRequestTxParams{PaymentRequestID: expired.ID, FromAccountID: fromAccount.ID})
+	require.ErrorIs(t, err, ErrPaymentRequestExpired)
+
+	_, err = store.CreatePaymentRequest(ctx, CreatePaymentRequestParams{
+		Requester:   toAccount.Owner,
+		Payer:       fromAccount.Owner,
+		ToAccountID: toAccount.ID,
+		Amount:      0,
+		Currency:    toAccount.Currency,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	requireConstraint(t, err, ErrCheckViolation, "payment_requests_amount_check")
+}
+
+func testConformanceRequestPaymentApprovalTx(t *testing.T, store Store) {
+	ctx := context.Background()
+	fromAccount := conformanceAccount(t, store, 100)
+	toAccount := conformanceAccount(t, store, 100)
+	checker := conformanceUser(t, store).Username
+
+	newPaymentRequest := func() PaymentRequest {
+		paymentRequest, err := store.CreatePaymentRequest(ctx, CreatePaymentRequestParams{
+			Requester:   toAccount.Owner,
+			Payer:       fromAccount.Owner,
+			ToAccountID: toAccount.ID,
+			Amount:      40,
+			Currency:    toAccount.Currency,
+			ExpiresAt:   time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		return paymentRequest
+	}
+
+	paymentRequest := newPaymentRequest()
+	arg := RequestPaymentApprovalTxParams{
+		PaymentRequestID: paymentRequest.ID,
+		FromAccountID:    fromAccount.ID,
+		Maker:            fromAccount.Owner,
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+	approval, err := store.RequestPaymentApprovalTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, paymentRequest.ID, approval.PaymentRequestID.Int64)
+	require.Equal(t, toAccount.ID, approval.ToAccountID)
+	require.Equal(t, int64(40), approval.Amount)
+
+	// no money moves and the request stays pending until the approval
+	paymentRequest, err = store.GetPaymentRequest(ctx, paymentRequest.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestStatusPending, paymentRequest.Status)
+
+	_, err = store.RequestPaymentApprovalTx(ctx, arg)
+	require.ErrorIs(t, err, ErrPaymentRequestAwaitingApproval)
+
+	result, err := store.ApproveTransferTx(ctx, DecideTransferTxParams{TransferApprovalID: approval.ID, Checker: checker})
+	require.NoError(t, err)
+	require.Equal(t, int64(60), result.FromAccount.Balance)
+
+	paymentRequest, err = store.GetPaymentRequest(ctx, paymentRequest.ID)
+	require.NoError(t, err)
+	require.Equal(t, PaymentRequestStatusAccepted, paymentRequest.Status)
+	require.Equal(t, result.Transfer.ID, paymentRequest.TransferID.Int64)
+
+	_, err = store.RequestPaymentApprovalTx(ctx, arg)
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+
+	// a request declined while its payment waits for approval is not paid
+	declined := newPaymentRequest()
+	arg.PaymentRequestID = declined.ID
+	approval, err = store.RequestPaymentApprovalTx(ctx, arg)
+	require.NoError(t, err)
+
+	_, err = store.UpdatePaymentRequestStatus(ctx, UpdatePaymentRequestStatusParams{
+		ID:     declined.ID,
+		Status: PaymentRequestStatusDeclined,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ApproveTransferTx(ctx, DecideTransferTxParams{TransferApprovalID: approval.ID, Checker: checker})
+	require.ErrorIs(t, err, ErrPaymentRequestNotPending)
+
+	account, err := store.GetAccount(ctx, fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(60), account.Balance)
+}
+
+func testConformanceTransferApprovals(t *testing.T, store Store) {
+	ctx := context.Background()
+	fromAccount := conformanceAccount(t, store, 100)
+	toAccount := conformanceAccount(t, store, 100)
+	checker := conformanceUser(t, store)
+	_, err := store.CreateAccountHolder(ctx, CreateAccountHolderParams{
+		AccountID: fromAccount.ID,
+		Username:  checker.Username,
+		Role:      AccountHolderRoleJoint,
+		Status:    AccountHolderStatusAccepted,
+	})
+	require.NoError(t, err)
+
+	arg := CreateTransferApprovalParams{
+		Maker:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        50,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+	approval, err := store.CreateTransferApprovalTx(ctx, arg)
+	require.NoError(t, err)
+
+	// the co-holder is asked to approve it
+	pending, err := store.ListPendingTransferApprovalsForHolder(ctx, ListPendingTransferApprovalsForHolderParams{Username: checker.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, approval.ID, pending[0].ID)
+
+	// but not the maker, who cannot approve it
+	pending, err = store.ListPendingTransferApprovalsForHolder(ctx, ListPendingTransferApprovalsForHolderParams{Username: fromAccount.Owner, Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	notifications, err := store.ListNotifications(ctx, ListNotificationsParams{Username: checker.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, NotificationTransferApprovalRequested, notifications[0].Kind)
+
+	result, err := store.ApproveTransferTx(ctx, DecideTransferTxParams{TransferApprovalID: approval.ID, Checker: checker.Username})
+	require.NoError(t, err)
+	require.Equal(t, TransferApprovalStatusApproved, result.TransferApproval.Status)
+	require.Equal(t, checker.Username, result.TransferApproval.Checker)
+	require.Equal(t, int64(50), result.FromAccount.Balance)
+
+	_, err = store.RejectTransferTx(ctx, DecideTransferTxParams{TransferApprovalID: approval.ID, Checker: checker.Username})
+	require.ErrorIs(t, err, ErrTransferApprovalNotPending)
+
+	notifications, err = store.ListNotifications(ctx, ListNotificationsParams{Username: fromAccount.Owner, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, NotificationTransferApproved, notifications[0].Kind)
+
+	// an approval that would overdraw the account fails and stays pending
+	arg.Amount = 60
+	approval, err = store.CreateTransferApprovalTx(ctx, arg)
+	require.NoError(t, err)
+
+	_, err = store.ApproveTransferTx(ctx, DecideTransferTxParams{TransferApprovalID: approval.ID, Checker: checker.Username})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	approval, err = store.GetTransferApproval(ctx, approval.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferApprovalStatusPending, approval.Status)
+}
+
+func testConformanceWebhookDeliveries(t *testing.T, store Store) {
+	ctx := context.Background()
+	webhook, err := store.CreateWebhook(ctx, CreateWebhookParams{
+		Owner:  conformanceUser(t, store).Username,
+		Url:    "https://example.com/hooks",
+		Secret: "0123456789abcdef",
+	})
+	require.NoError(t, err)
+	defer store.DeleteWebhook(ctx, webhook.ID)
+
+	conformanceAccount(t, store, 100)
+
+	n, err := store.FanOutOutboxEvents(ctx, 1000)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, n, int64(1))
+
+	n, err = store.FanOutOutboxEvents(ctx, 1000)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	deliveries, err := store.ClaimWebhookDeliveries(ctx, ClaimWebhookDeliveriesParams{
+		LeaseUntil: time.Now().Add(time.Minute),
+		BatchSize:  1000,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, deliveries)
+
+	// leased deliveries are not claimed again until the lease ends
+	claimed, err := store.ClaimWebhookDeliveries(ctx, ClaimWebhookDeliveriesParams{
+		LeaseUntil: time.Now().Add(time.Minute),
+		BatchSize:  1000,
+	})
+	require.NoError(t, err)
+	require.Empty(t, claimed)
+
+	require.NoError(t, store.MarkWebhookDelivered(ctx, deliveries[0].ID))
+	delivered, err := store.GetWebhookDelivery(ctx, deliveries[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliveryStatusDelivered, delivered.Status)
+	require.Equal(t, int32(1), delivered.Attempts)
+}
+
+func testConformanceListenAccountEvents(t *testing.T, store Store) {
+	account1 := conformanceAccount(t, store, 100)
+	account2 := conformanceAccount(t, store, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	notifications := make(chan AccountEventNotification, 10)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- store.ListenAccountEvents(ctx, func(n AccountEventNotification) {
+			notifications <- n
+		})
+	}()
+
+	// give the listener time to start before the transfer commits
+	time.Sleep(200 * time.Millisecond)
+
+	result, err := store.TransferTx(context.Background(), TransferTXParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	accounts := map[int64]bool{}
+	for len(accounts) < 2 {
+		select {
+		case n := <-notifications:
+			if n.AccountID == account1.ID || n.AccountID == account2.ID {
+				accounts[n.AccountID] = true
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for notifications")
+		}
+	}
+	require.True(t, accounts[result.FromAccount.ID])
+	require.True(t, accounts[result.ToAccount.ID])
+
+	cancel()
+	require.NoError(t, <-errs)
+}
+
+func testConformanceAuditLog(t *testing.T, store Store) {
+	ctx := context.Background()
+	actor := util.RandomOwner()
+	resourceID := util.RandomAccountNumber()
+	since := time.Now().Add(-time.Minute)
+
+	created, err := store.CreateAuditLog(ctx, CreateAuditLogParams{
+		Actor:        actor,
+		Action:       "account.created",
+		ResourceType: "account",
+		ResourceID:   resourceID,
+		ClientIp:     "192.0.2.1",
+		After:        json.RawMessage(`{"balance":0}`),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, created.ID)
+	require.Nil(t, created.Before)
+	require.JSONEq(t, `{"balance":0}`, string(created.After))
+	require.WithinDuration(t, time.Now(), created.CreatedAt, time.Minute)
+
+	adjusted, err := store.CreateAuditLog(ctx, CreateAuditLogParams{
+		Actor:        actor,
+		Action:       "account.balance_adjusted",
+		ResourceType: "account",
+		ResourceID:   resourceID,
+		Before:       json.RawMessage(`{"balance":0}`),
+		After:        json.RawMessage(`{"balance":10}`),
+	})
+	require.NoError(t, err)
+
+	logs, err := store.ListAuditLogs(ctx, ListAuditLogsParams{
+		ResourceType: "account",
+		ResourceID:   resourceID,
+		Since:        since,
+		Until:        time.Now().Add(time.Minute),
+		Limit:        10,
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	require.Equal(t, adjusted.ID, logs[0].ID)
+	require.Equal(t, created.ID, logs[1].ID)
+	require.Equal(t, "192.0.2.1", logs[1].ClientIp)
+	require.JSONEq(t, `{"balance":0}`, string(logs[0].Before))
+
+	logs, err = store.ListAuditLogs(ctx, ListAuditLogsParams{
+		Actor: actor,
+		Since: time.Now().Add(time.Minute),
+		Until: time.Now().Add(2 * time.Minute),
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, logs)
+
+	// the log is append-only; the memory store has no way to change it at all
+	var exec func(query string) error
+	switch s := store.(type) {
+	case *SQLStore:
+		exec = func(query string) error {
+			_, err := s.connPool.Exec(ctx, query, created.ID)
+			return err
+		}
+	case *SQLiteStore:
+		exec = func(query string) error {
+			_, err := s.db.ExecContext(ctx, strings.ReplaceAll(query, "$1", "?"), created.ID)
+			return err
+		}
+	default:
+		return
+	}
+	require.Error(t, exec("UPDATE audit_log SET actor = 'someone else' WHERE id = $1"))
+	require.Error(t, exec("DELETE FROM audit_log WHERE id = $1"))
+}
+
+func testConformanceLedgerChain(t *testing.T, store Store) {
+	ctx := context.Background()
+	account1 := conformanceAccount(t, store, 100)
+	account2 := conformanceAccount(t, store, 100)
+
+	for _, amount := range []int64{10, 20} {
+		_, err := store.TransferTx(ctx, TransferTXParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+			Description:   "rent",
+		})
+		require.NoError(t, err)
+	}
+
+	// each entry is chained to the previous entry of its account
+	entries, err := store.ListEntries(ctx, ListEntriesParams{AccountID: account1.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	var prevHash []byte
+	for _, entry := range entries {
+		require.True(t, entry.TransferID.Valid)
+		require.Equal(t, prevHash, entry.PrevHash)
+
+		transfer, err := store.GetTransfer(ctx, entry.TransferID.Int64)
+		require.NoError(t, err)
+		require.Equal(t, EntryHash(prevHash, entry, transfer), entry.Hash)
+		prevHash = entry.Hash
+	}
+
+	rows, err := store.ListLedgerEntries(ctx, ListLedgerEntriesParams{
+		AfterAccountID: account1.ID,
+		AfterID:        entries[0].ID,
+		Limit:          1,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, entries[1].ID, rows[0].ID)
+	require.Equal(t, entries[1].Hash, rows[0].Hash)
+	require.Equal(t, account1.ID, rows[0].TransferFromAccountID)
+	require.Equal(t, int64(20), rows[0].TransferAmount)
+	require.Equal(t, "rent", rows[0].TransferDescription)
+
+	// only the head of the chain of an account is checkpointed, once
+	heads, err := store.ListUncheckpointedHeads(ctx, 1000)
+	require.NoError(t, err)
+	var head ListUncheckpointedHeadsRow
+	for _, h := range heads {
+		if h.AccountID == account1.ID {
+			head = h
+		}
+	}
+	require.Equal(t, ListUncheckpointedHeadsRow{AccountID: account1.ID, ID: entries[1].ID, Hash: entries[1].Hash}, head)
+
+	checkpoint, err := store.CreateLedgerCheckpoint(ctx, CreateLedgerCheckpointParams{
+		AccountID: head.AccountID,
+		EntryID:   head.ID,
+		Hash:      head.Hash,
+		Signature: []byte("signature"),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, checkpoint.ID)
+	require.WithinDuration(t, time.Now(), checkpoint.CreatedAt, time.Minute)
+
+	heads, err = store.ListUncheckpointedHeads(ctx, 1000)
+	require.NoError(t, err)
+	for _, h := range heads {
+		require.NotEqual(t, account1.ID, h.AccountID)
+	}
+
+	checkpoints, err := store.ListLedgerCheckpoints(ctx, ListLedgerCheckpointsParams{AfterID: checkpoint.ID - 1, Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, []LedgerCheckpoint{checkpoint}, checkpoints)
+
+	// the checkpoints are append-only
+	var exec func(query string) error
+	switch s := store.(type) {
+	case *SQLStore:
+		exec = func(query string) error {
+			_, err := s.connPool.Exec(ctx, query, checkpoint.ID)
+			return err
+		}
+	case *SQLiteStore:
+		exec = func(query string) error {
+			_, err := s.db.ExecContext(ctx, strings.ReplaceAll(query, "$1", "?"), checkpoint.ID)
+			return err
+		}
+	default:
+		return
+	}
+	require.Error(t, exec("UPDATE ledger_checkpoints SET hash = NULL WHERE id = $1"))
+	require.Error(t, exec("DELETE FROM ledger_checkpoints WHERE id = $1"))
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"simple_bank/logger"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("simple_bank/db")
+
+// QueryTracer logs every query with the logger carried by its context, so
+// that database logs share the request ID of the request that caused them.
+// Failed queries are logged as warnings and the others at debug level.
+// Each query is also recorded as a span, named after the query, under the
+// span carried by its context. Query arguments are never logged or recorded
+// since they may hold credentials.
+type QueryTracer struct{}
+
+type queryTraceKey struct{}
+
+type queryTrace struct {
+	name  string
+	start time.Time
+}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name := queryName(data.SQL)
+	ctx, _ = tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBOperationName(name)),
+	)
+	return context.WithValue(ctx, queryTraceKey{}, queryTrace{
+		name:  name,
+		start: time.Now(),
+	})
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	qt, ok := ctx.Value(queryTraceKey{}).(queryTrace)
+	if !ok {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	l := logger.FromContext(ctx)
+	duration := time.Since(qt.start)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+		l.WarnContext(ctx, "query failed", "query", qt.name, "duration", duration, "error", data.Err)
+		return
+	}
+	l.DebugContext(ctx, "query", "query", qt.name, "duration", duration, "rows", data.CommandTag.RowsAffected())
+}
+
+// queryName returns the sqlc name of a query, e.g. "GetAccount", or its first
+// line for queries that were not generated by sqlc.
+func queryName(sql string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(sql), "\n")
+	if name, ok := strings.CutPrefix(line, "-- name: "); ok {
+		name, _, _ = strings.Cut(name, " ")
+		return name
+	}
+	return line
+}
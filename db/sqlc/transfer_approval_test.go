@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"simple_bank/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomTransferApproval(t *testing.T, fromAccount Account, toAccount Account, expiresAt time.Time) TransferApproval {
+	arg := CreateTransferApprovalParams{
+		Maker:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        util.RandomMoney(),
+		Description:   "invoice",
+		Reference:     "INV-1",
+		Category:      "business",
+		ExpiresAt:     expiresAt,
+	}
+
+	approval, err := testStore.CreateTransferApprovalTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, approval)
+
+	require.Equal(t, arg.Maker, approval.Maker)
+	require.Equal(t, arg.FromAccountID, approval.FromAccountID)
+	require.Equal(t, arg.ToAccountID, approval.ToAccountID)
+	require.Equal(t, arg.Amount, approval.Amount)
+	require.Equal(t, TransferApprovalStatusPending, approval.Status)
+	require.Empty(t, approval.Checker)
+	require.False(t, approval.TransferID.Valid)
+
+	require.NotZero(t, approval.ID)
+	require.NotZero(t, approval.CreatedAt)
+
+	return approval
+}
+
+func addJointHolder(t *testing.T, account Account) User {
+	user := createRandomUser(t)
+	_, err := testStore.CreateAccountHolder(context.Background(), CreateAccountHolderParams{
+		AccountID: account.ID,
+		Username:  user.Username,
+		Role:      AccountHolderRoleJoint,
+		Status:    AccountHolderStatusAccepted,
+	})
+	require.NoError(t, err)
+	return user
+}
+
+func TestCreateTransferApprovalTxNotifiesCoHolders(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	coHolder := addJointHolder(t, fromAccount)
+
+	createRandomTransferApproval(t, fromAccount, createRandomAccount(t), time.Now().Add(time.Hour))
+
+	notifications, err := testStore.ListNotifications(context.Background(), ListNotificationsParams{
+		Username: coHolder.Username,
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, NotificationTransferApprovalRequested, notifications[0].Kind)
+
+	approvals, err := testStore.ListPendingTransferApprovalsForHolder(context.Background(), ListPendingTransferApprovalsForHolderParams{
+		Username: coHolder.Username,
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Len(t, approvals, 1)
+}
+
+func TestApproveTransferTx(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+	coHolder := addJointHolder(t, fromAccount)
+	approval := createRandomTransferApproval(t, fromAccount, toAccount, time.Now().Add(time.Hour))
+
+	// nothing moves until the transfer is approved
+	account, err := testStore.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance, account.Balance)
+
+	arg := DecideTransferTxParams{
+		TransferApprovalID: approval.ID,
+		Checker:            coHolder.Username,
+	}
+
+	result, err := testStore.ApproveTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, TransferApprovalStatusApproved, result.TransferApproval.Status)
+	require.Equal(t, coHolder.Username, result.TransferApproval.Checker)
+	require.Equal(t, result.Transfer.ID, result.TransferApproval.TransferID.Int64)
+	require.Equal(t, approval.Reference, result.Transfer.Reference)
+	require.Equal(t, fromAccount.Balance-approval.Amount, result.FromAccount.Balance)
+	require.Equal(t, toAccount.Balance+approval.Amount, result.ToAccount.Balance)
+
+	notifications, err := testStore.ListNotifications(context.Background(), ListNotificationsParams{
+		Username: fromAccount.Owner,
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, NotificationTransferApproved, notifications[0].Kind)
+
+	// a transfer can only be approved once
+	_, err = testStore.ApproveTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrTransferApprovalNotPending)
+}
+
+func TestRejectTransferTx(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	coHolder := addJointHolder(t, fromAccount)
+	approval := createRandomTransferApproval(t, fromAccount, createRandomAccount(t), time.Now().Add(time.Hour))
+
+	rejected, err := testStore.RejectTransferTx(context.Background(), DecideTransferTxParams{
+		TransferApprovalID: approval.ID,
+		Checker:            coHolder.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferApprovalStatusRejected, rejected.Status)
+	require.False(t, rejected.TransferID.Valid)
+
+	account, err := testStore.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance, account.Balance)
+}
+
+func TestExpireTransferApprovalsTx(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	expired := createRandomTransferApproval(t, fromAccount, createRandomAccount(t), time.Now().Add(-time.Minute))
+
+	n, err := testStore.ExpireTransferApprovalsTx(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, n, int64(1))
+
+	approval, err := testStore.GetTransferApproval(context.Background(), expired.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferApprovalStatusExpired, approval.Status)
+
+	notifications, err := testStore.ListNotifications(context.Background(), ListNotificationsParams{
+		Username: fromAccount.Owner,
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	require.Equal(t, NotificationTransferApprovalExpired, notifications[0].Kind)
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +16,9 @@ func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
 		FromAccountID: account1.ID,
 		ToAccountID:   account2.ID,
 		Amount:        util.RandomMoney(),
+		Description:   "memo " + util.RandomString(8),
+		Reference:     util.RandomString(12),
+		Category:      "general",
 	}
 
 	transfer, err := testStore.CreateTransfer(context.Background(), arg)
@@ -24,6 +28,9 @@ func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
 	require.Equal(t, arg.FromAccountID, transfer.FromAccountID)
 	require.Equal(t, arg.ToAccountID, transfer.ToAccountID)
 	require.Equal(t, arg.Amount, transfer.Amount)
+	require.Equal(t, arg.Description, transfer.Description)
+	require.Equal(t, arg.Reference, transfer.Reference)
+	require.Equal(t, arg.Category, transfer.Category)
 
 	require.NotZero(t, transfer.ID)
 	require.NotZero(t, transfer.CreatedAt)
@@ -50,6 +57,9 @@ func TestGetTransfer(t *testing.T) {
 	require.Equal(t, transfer1.FromAccountID, transfer2.FromAccountID)
 	require.Equal(t, transfer1.ToAccountID, transfer2.ToAccountID)
 	require.Equal(t, transfer1.Amount, transfer2.Amount)
+	require.Equal(t, transfer1.Description, transfer2.Description)
+	require.Equal(t, transfer1.Reference, transfer2.Reference)
+	require.Equal(t, transfer1.Category, transfer2.Category)
 	require.WithinDuration(t, transfer1.CreatedAt, transfer2.CreatedAt, time.Second)
 }
 
@@ -81,3 +91,53 @@ func TestListTransfers(t *testing.T) {
 		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
 	}
 }
+
+func TestSearchTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	reference := "INV-" + util.RandomString(8)
+	matched, err := testStore.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        util.RandomMoney(),
+		Description:   "October rent",
+		Reference:     reference,
+		Category:      "rent",
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		createRandomTransfer(t, account2, account1)
+	}
+
+	// search by reference, case-insensitive
+	transfers, err := testStore.SearchTransfers(context.Background(), SearchTransfersParams{
+		AccountID: account1.ID,
+		Query:     strings.ToLower(reference),
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, matched.ID, transfers[0].ID)
+
+	// filter by category only
+	transfers, err = testStore.SearchTransfers(context.Background(), SearchTransfersParams{
+		AccountID: account1.ID,
+		Category:  "general",
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 3)
+
+	// no filters returns the whole history
+	transfers, err = testStore.SearchTransfers(context.Background(), SearchTransfersParams{
+		AccountID: account1.ID,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 4)
+}
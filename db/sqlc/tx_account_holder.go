@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	AccountHolderRolePrimary  = "primary"
+	AccountHolderRoleJoint    = "joint"
+	AccountHolderRoleViewOnly = "view_only"
+)
+
+// A holder invited to an account is pending, and has no access to it, until
+// it accepts the invitation.
+const (
+	AccountHolderStatusPending  = "pending"
+	AccountHolderStatusAccepted = "accepted"
+)
+
+var ErrAccountInvitationNotPending = errors.New("account invitation was already accepted")
+
+// CreateAccountTx creates an account, registers its owner as the primary
+// holder and publishes an account.created event.
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, readCommitted, func(q *Queries) error {
+		var err error
+		account, err = openAccount(ctx, q, arg)
+		return err
+	})
+
+	return account, err
+}
+
+// openAccount is the body of CreateAccountTx, using q, which must be bound
+// to a transaction.
+func openAccount(ctx context.Context, q Querier, arg CreateAccountParams) (Account, error) {
+	account, err := q.CreateAccount(ctx, arg)
+	if err != nil {
+		return account, err
+	}
+
+	_, err = q.CreateAccountHolder(ctx, CreateAccountHolderParams{
+		AccountID: account.ID,
+		Username:  account.Owner,
+		Role:      AccountHolderRolePrimary,
+		Status:    AccountHolderStatusAccepted,
+	})
+	if err != nil {
+		return account, err
+	}
+
+	return account, publish(ctx, q, EventAccountCreated, account.ID, account)
+}
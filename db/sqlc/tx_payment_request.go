@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	PaymentRequestStatusPending  = "pending"
+	PaymentRequestStatusAccepted = "accepted"
+	PaymentRequestStatusDeclined = "declined"
+	PaymentRequestStatusExpired  = "expired"
+)
+
+var (
+	ErrPaymentRequestNotPending = errors.New("payment request is no longer pending")
+	ErrPaymentRequestExpired    = errors.New("payment request has expired")
+	// ErrPaymentRequestAwaitingApproval is returned when a payment request is
+	// accepted again while its payment is still waiting for approval.
+	ErrPaymentRequestAwaitingApproval = errors.New("payment request is already waiting for approval")
+)
+
+// AcceptPaymentRequestTxParams contains the input parameters of the accept payment request transaction.
+type AcceptPaymentRequestTxParams struct {
+	PaymentRequestID int64 `json:"payment_request_id"`
+	FromAccountID    int64 `json:"from_account_id"`
+}
+
+// AcceptPaymentRequestTxResult is the result of the accept payment request transaction.
+type AcceptPaymentRequestTxResult struct {
+	PaymentRequest PaymentRequest `json:"payment_request"`
+	TransferTXResult
+}
+
+// AcceptPaymentRequestTx pays a pending payment request from the given account.
+// The request row is locked so that it can only be paid once.
+func (store *SQLStore) AcceptPaymentRequestTx(ctx context.Context, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error) {
+	var result AcceptPaymentRequestTxResult
+
+	err := store.execTx(ctx, readCommitted, func(q *Queries) error {
+		var err error
+		result, err = acceptPaymentRequest(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// acceptPaymentRequest is the body of AcceptPaymentRequestTx, using q, which
+// must be bound to a transaction.
+func acceptPaymentRequest(ctx context.Context, q Querier, arg AcceptPaymentRequestTxParams) (AcceptPaymentRequestTxResult, error) {
+	var result AcceptPaymentRequestTxResult
+
+	paymentRequest, err := q.GetPaymentRequestForUpdate(ctx, arg.PaymentRequestID)
+	if err != nil {
+		return result, err
+	}
+
+	if paymentRequest.Status != PaymentRequestStatusPending {
+		return result, ErrPaymentRequestNotPending
+	}
+	if !time.Now().Before(paymentRequest.ExpiresAt) {
+		return result, ErrPaymentRequestExpired
+	}
+
+	result.TransferTXResult, err = transfer(ctx, q, TransferTXParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   paymentRequest.ToAccountID,
+		Amount:        paymentRequest.Amount,
+		Description:   paymentRequest.Description,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.PaymentRequest, err = q.UpdatePaymentRequestStatus(ctx, UpdatePaymentRequestStatusParams{
+		ID:         arg.PaymentRequestID,
+		Status:     PaymentRequestStatusAccepted,
+		TransferID: pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
+	})
+	return result, err
+}
+
+// RequestPaymentApprovalTxParams contains the input parameters of the request
+// payment approval transaction.
+type RequestPaymentApprovalTxParams struct {
+	PaymentRequestID int64     `json:"payment_request_id"`
+	FromAccountID    int64     `json:"from_account_id"`
+	Maker            string    `json:"maker"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// RequestPaymentApprovalTx accepts a pending payment request whose payment
+// needs a second user's approval: the payment is parked as a transfer
+// approval, and the request is marked accepted once the transfer is approved.
+func (store *SQLStore) RequestPaymentApprovalTx(ctx context.Context, arg RequestPaymentApprovalTxParams) (TransferApproval, error) {
+	var approval TransferApproval
+
+	err := store.execTx(ctx, readCommitted, func(q *Queries) error {
+		var err error
+		approval, err = requestPaymentApproval(ctx, q, arg)
+		return err
+	})
+
+	return approval, err
+}
+
+// requestPaymentApproval is the body of RequestPaymentApprovalTx, using q,
+// which must be bound to a transaction.
+func requestPaymentApproval(ctx context.Context, q Querier, arg RequestPaymentApprovalTxParams) (TransferApproval, error) {
+	paymentRequest, err := q.GetPaymentRequestForUpdate(ctx, arg.PaymentRequestID)
+	if err != nil {
+		return TransferApproval{}, err
+	}
+
+	if paymentRequest.Status != PaymentRequestStatusPending {
+		return TransferApproval{}, ErrPaymentRequestNotPending
+	}
+	if !time.Now().Before(paymentRequest.ExpiresAt) {
+		return TransferApproval{}, ErrPaymentRequestExpired
+	}
+
+	approval, err := requestTransferApproval(ctx, q, CreateTransferApprovalParams{
+		Maker:            arg.Maker,
+		FromAccountID:    arg.FromAccountID,
+		ToAccountID:      paymentRequest.ToAccountID,
+		Amount:           paymentRequest.Amount,
+		Description:      paymentRequest.Description,
+		ExpiresAt:        arg.ExpiresAt,
+		PaymentRequestID: pgtype.Int8{Int64: paymentRequest.ID, Valid: true},
+	})
+	if errors.Is(err, ErrUniqueViolation) {
+		return approval, ErrPaymentRequestAwaitingApproval
+	}
+	return approval, err
+}
@@ -0,0 +1,234 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	TransferApprovalStatusPending  = "pending_approval"
+	TransferApprovalStatusApproved = "approved"
+	TransferApprovalStatusRejected = "rejected"
+	TransferApprovalStatusExpired  = "expired"
+)
+
+const (
+	NotificationTransferApprovalRequested = "transfer_approval_requested"
+	NotificationTransferApproved          = "transfer_approved"
+	NotificationTransferRejected          = "transfer_rejected"
+	NotificationTransferApprovalExpired   = "transfer_approval_expired"
+)
+
+var (
+	ErrTransferApprovalNotPending = errors.New("transfer is no longer pending approval")
+	ErrTransferApprovalExpired    = errors.New("transfer approval has expired")
+)
+
+// DecideTransferTxParams contains the input parameters of the approve and reject transactions.
+type DecideTransferTxParams struct {
+	TransferApprovalID int64  `json:"transfer_approval_id"`
+	Checker            string `json:"checker"`
+}
+
+// ApproveTransferTxResult is the result of the approve transfer transaction.
+type ApproveTransferTxResult struct {
+	TransferApproval TransferApproval `json:"transfer_approval"`
+	TransferTXResult
+}
+
+// CreateTransferApprovalTx parks a transfer until a second user approves it,
+// and notifies the other holders who are allowed to approve it.
+func (store *SQLStore) CreateTransferApprovalTx(ctx context.Context, arg CreateTransferApprovalParams) (TransferApproval, error) {
+	var approval TransferApproval
+
+	err := store.execTx(ctx, readCommitted, func(q *Queries) error {
+		var err error
+		approval, err = requestTransferApproval(ctx, q, arg)
+		return err
+	})
+
+	return approval, err
+}
+
+// ApproveTransferTx executes a pending transfer and records who approved it.
+// The approval row is locked so that the transfer can only be executed once.
+func (store *SQLStore) ApproveTransferTx(ctx context.Context, arg DecideTransferTxParams) (ApproveTransferTxResult, error) {
+	var result ApproveTransferTxResult
+
+	err := store.execTx(ctx, readCommitted, func(q *Queries) error {
+		var err error
+		result, err = approveTransfer(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// RejectTransferTx rejects a pending transfer without moving any money.
+func (store *SQLStore) RejectTransferTx(ctx context.Context, arg DecideTransferTxParams) (TransferApproval, error) {
+	var result TransferApproval
+
+	err := store.execTx(ctx, readCommitted, func(q *Queries) error {
+		var err error
+		result, err = rejectTransfer(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// ExpireTransferApprovalsTx expires every pending transfer past its deadline,
+// notifies the makers, and returns the number of expired transfers.
+func (store *SQLStore) ExpireTransferApprovalsTx(ctx context.Context) (int64, error) {
+	var expired int64
+
+	err := store.execTx(ctx, readCommitted, func(q *Queries) error {
+		var err error
+		expired, err = expirePendingTransferApprovals(ctx, q)
+		return err
+	})
+
+	return expired, err
+}
+
+// The transfer approval transactions are shared by the stores backed by a
+// database, and run using q, which must be bound to a transaction.
+
+func requestTransferApproval(ctx context.Context, q Querier, arg CreateTransferApprovalParams) (TransferApproval, error) {
+	approval, err := q.CreateTransferApproval(ctx, arg)
+	if err != nil {
+		return approval, err
+	}
+
+	holders, err := q.ListAccountHolders(ctx, arg.FromAccountID)
+	if err != nil {
+		return approval, err
+	}
+
+	message := fmt.Sprintf("%s is waiting for approval of a transfer of %d (approval %d)", arg.Maker, arg.Amount, approval.ID)
+	for _, holder := range holders {
+		if holder.Username == arg.Maker || holder.Role == AccountHolderRoleViewOnly || holder.Status != AccountHolderStatusAccepted {
+			continue
+		}
+		if err := notify(ctx, q, holder.Username, NotificationTransferApprovalRequested, message); err != nil {
+			return approval, err
+		}
+	}
+
+	return approval, nil
+}
+
+func approveTransfer(ctx context.Context, q Querier, arg DecideTransferTxParams) (ApproveTransferTxResult, error) {
+	var result ApproveTransferTxResult
+
+	approval, err := lockPendingTransferApproval(ctx, q, arg.TransferApprovalID)
+	if err != nil {
+		return result, err
+	}
+
+	result.TransferTXResult, err = transfer(ctx, q, TransferTXParams{
+		FromAccountID: approval.FromAccountID,
+		ToAccountID:   approval.ToAccountID,
+		Amount:        approval.Amount,
+		Description:   approval.Description,
+		Reference:     approval.Reference,
+		Category:      approval.Category,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.TransferApproval, err = q.DecideTransferApproval(ctx, DecideTransferApprovalParams{
+		ID:         approval.ID,
+		Status:     TransferApprovalStatusApproved,
+		Checker:    arg.Checker,
+		TransferID: pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if approval.PaymentRequestID.Valid {
+		_, err = q.UpdatePaymentRequestStatus(ctx, UpdatePaymentRequestStatusParams{
+			ID:         approval.PaymentRequestID.Int64,
+			Status:     PaymentRequestStatusAccepted,
+			TransferID: pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
+		})
+		if errors.Is(err, ErrRecordNotFound) {
+			// the payer declined the request while it waited for approval
+			return result, ErrPaymentRequestNotPending
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+
+	message := fmt.Sprintf("%s approved transfer approval %d", arg.Checker, approval.ID)
+	return result, notify(ctx, q, approval.Maker, NotificationTransferApproved, message)
+}
+
+func rejectTransfer(ctx context.Context, q Querier, arg DecideTransferTxParams) (TransferApproval, error) {
+	approval, err := lockPendingTransferApproval(ctx, q, arg.TransferApprovalID)
+	if err != nil {
+		return approval, err
+	}
+
+	result, err := q.DecideTransferApproval(ctx, DecideTransferApprovalParams{
+		ID:      approval.ID,
+		Status:  TransferApprovalStatusRejected,
+		Checker: arg.Checker,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	message := fmt.Sprintf("%s rejected transfer approval %d", arg.Checker, approval.ID)
+	return result, notify(ctx, q, approval.Maker, NotificationTransferRejected, message)
+}
+
+func expirePendingTransferApprovals(ctx context.Context, q Querier) (int64, error) {
+	approvals, err := q.ExpireTransferApprovals(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, approval := range approvals {
+		message := fmt.Sprintf("transfer approval %d expired without a decision", approval.ID)
+		if err := notify(ctx, q, approval.Maker, NotificationTransferApprovalExpired, message); err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(approvals)), nil
+}
+
+// lockPendingTransferApproval locks a transfer approval and checks that it can
+// still be decided.
+func lockPendingTransferApproval(ctx context.Context, q Querier, id int64) (TransferApproval, error) {
+	approval, err := q.GetTransferApprovalForUpdate(ctx, id)
+	if err != nil {
+		return approval, err
+	}
+
+	if approval.Status != TransferApprovalStatusPending {
+		return approval, ErrTransferApprovalNotPending
+	}
+	if !time.Now().Before(approval.ExpiresAt) {
+		return approval, ErrTransferApprovalExpired
+	}
+
+	return approval, nil
+}
+
+func notify(ctx context.Context, q Querier, username string, kind string, message string) error {
+	_, err := q.CreateNotification(ctx, CreateNotificationParams{
+		Username: username,
+		Kind:     kind,
+		Message:  message,
+	})
+	return err
+}
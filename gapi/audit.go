@@ -0,0 +1,47 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"simple_bank/audit"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	userAgentHeaderKey = "user-agent"
+	requestIDHeaderKey = "x-request-id"
+)
+
+// recordAudit is the gRPC counterpart of the HTTP recordAudit: it records that
+// actor performed action on a resource, along with the peer the call came
+// from.
+func (server *Server) recordAudit(ctx context.Context, actor string, action string, resourceType string, resourceID string, before any, after any) {
+	entry := audit.Entry{
+		Actor:        actor,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Before:       before,
+		After:        after,
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		entry.ClientIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(entry.ClientIP); err == nil {
+			entry.ClientIP = host
+		}
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(userAgentHeaderKey); len(values) > 0 {
+			entry.UserAgent = values[0]
+		}
+		if values := md.Get(requestIDHeaderKey); len(values) > 0 {
+			entry.RequestID = values[0]
+		}
+	}
+
+	audit.Record(ctx, server.store, entry)
+}
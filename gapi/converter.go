@@ -0,0 +1,52 @@
+package gapi
+
+import (
+	db "simple_bank/db/sqlc"
+	"simple_bank/pb"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func convertUser(user db.User) *pb.User {
+	return &pb.User{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+	}
+}
+
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		AccountNumber: account.AccountNumber,
+		Owner:         account.Owner,
+		Balance:       account.Balance,
+		Currency:      account.Currency,
+		CreatedAt:     timestamppb.New(account.CreatedAt),
+	}
+}
+
+// convertTransfer converts a transfer between fromAccount and toAccount,
+// which carry the account numbers shown in place of the internal IDs.
+func convertTransfer(transfer db.Transfer, fromAccount, toAccount db.Account) *pb.Transfer {
+	return &pb.Transfer{
+		Id:                transfer.ID,
+		FromAccountNumber: fromAccount.AccountNumber,
+		ToAccountNumber:   toAccount.AccountNumber,
+		Amount:            transfer.Amount,
+		Description:       transfer.Description,
+		Reference:         transfer.Reference,
+		Category:          transfer.Category,
+		CreatedAt:         timestamppb.New(transfer.CreatedAt),
+	}
+}
+
+func convertEntry(entry db.Entry, account db.Account) *pb.Entry {
+	return &pb.Entry{
+		Id:            entry.ID,
+		AccountNumber: account.AccountNumber,
+		Amount:        entry.Amount,
+		CreatedAt:     timestamppb.New(entry.CreatedAt),
+	}
+}
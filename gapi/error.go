@@ -0,0 +1,14 @@
+package gapi
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func invalidArgumentError(field string, err error) error {
+	return status.Errorf(codes.InvalidArgument, "invalid %s: %v", field, err)
+}
+
+func internalError(err error) error {
+	return status.Error(codes.Internal, err.Error())
+}
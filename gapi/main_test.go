@@ -0,0 +1,73 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"simple_bank/pb"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient serves a gRPC server backed by store on an in-memory
+// listener and returns a client connected to it.
+func newTestClient(t *testing.T, store db.Store) (*Server, pb.SimpleBankClient) {
+	config := util.Config{
+		TokenSymmetricKey:   util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+	}
+
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(server.AuthInterceptor))
+	pb.RegisterSimpleBankServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return server, pb.NewSimpleBankClient(conn)
+}
+
+// withAuthorization returns a context carrying an access token for username.
+func withAuthorization(t *testing.T, server *Server, username string) context.Context {
+	accessToken, err := server.tokenMaker.CreateToken(username, time.Minute)
+	require.NoError(t, err)
+
+	header := fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken)
+	return metadata.AppendToOutgoingContext(context.Background(), authorizationHeaderKey, header)
+}
+
+// expectAudit expects the action of actor to be recorded once in the audit
+// log, with the user agent the test client sends.
+func expectAudit(t *testing.T, store *mockdb.MockStore, actor string, action string) {
+	store.EXPECT().
+		CreateAuditLog(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+			require.Equal(t, actor, arg.Actor)
+			require.Equal(t, action, arg.Action)
+			require.Contains(t, arg.UserAgent, "grpc-go")
+			return db.AuditLog{}, nil
+		})
+}
@@ -0,0 +1,117 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple_bank/audit"
+	db "simple_bank/db/sqlc"
+	"simple_bank/pb"
+	"simple_bank/policy"
+	"simple_bank/util"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		return nil, invalidArgumentError("currency", err)
+	}
+
+	arg := db.CreateAccountParams{
+		Owner:    authorizationPayload(ctx).Username,
+		Balance:  0,
+		Currency: req.GetCurrency(),
+	}
+
+	account, err := policy.CreateAccount(ctx, server.store, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrUniqueViolation) || errors.Is(err, db.ErrForeignKeyViolation) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, internalError(err)
+	}
+
+	server.recordAudit(ctx, arg.Owner, audit.ActionAccountCreated, audit.ResourceAccount, account.AccountNumber, nil, account)
+
+	return &pb.CreateAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	if err := validateAccountRef(req.GetId()); err != nil {
+		return nil, invalidArgumentError("id", err)
+	}
+
+	account, err := server.accountByRef(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := server.authorizeAccount(ctx, account.ID); err != nil {
+		return nil, err
+	}
+
+	return &pb.GetAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	if req.GetPageId() < 1 {
+		return nil, invalidArgumentError("page_id", fmt.Errorf("must be at least 1"))
+	}
+	if req.GetPageSize() < 5 || req.GetPageSize() > 10 {
+		return nil, invalidArgumentError("page_size", fmt.Errorf("must be between 5 and 10"))
+	}
+
+	arg := db.ListAccountsParams{
+		Username: authorizationPayload(ctx).Username,
+		Limit:    req.GetPageSize(),
+		Offset:   (req.GetPageId() - 1) * req.GetPageSize(),
+	}
+
+	accounts, err := server.store.ListAccountsFromReplica(ctx, arg)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	res := &pb.ListAccountsResponse{}
+	for _, account := range accounts {
+		res.Accounts = append(res.Accounts, convertAccount(account))
+	}
+
+	return res, nil
+}
+
+// accountByRef loads an account by its numeric ID or its account number.
+func (server *Server) accountByRef(ctx context.Context, ref string) (db.Account, error) {
+	var account db.Account
+	var err error
+	if id, parseErr := strconv.ParseInt(ref, 10, 64); parseErr == nil {
+		account, err = server.store.GetAccount(ctx, id)
+	} else {
+		account, err = server.store.GetAccountByNumber(ctx, util.NormalizeAccountNumber(ref))
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return account, status.Errorf(codes.NotFound, "account %s not found", ref)
+		}
+		return account, internalError(err)
+	}
+
+	return account, nil
+}
+
+// authorizeAccount enforces policy.AuthorizeAccount for the authenticated
+// user.
+func (server *Server) authorizeAccount(ctx context.Context, accountID int64, roles ...string) error {
+	_, err := policy.AuthorizeAccount(ctx, server.store, accountID, authorizationPayload(ctx).Username, roles...)
+	if err != nil {
+		if errors.Is(err, policy.ErrNotAccountHolder) || errors.Is(err, policy.ErrRoleNotAllowed) {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+		return internalError(err)
+	}
+
+	return nil
+}
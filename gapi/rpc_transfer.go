@@ -0,0 +1,143 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple_bank/audit"
+	db "simple_bank/db/sqlc"
+	"simple_bank/pb"
+	"simple_bank/policy"
+	"simple_bank/util"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	if err := validateCreateTransferRequest(req); err != nil {
+		return nil, err
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccount(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	// view-only holders can see the account but not move money out of it
+	err = server.authorizeAccount(ctx, fromAccount.ID, db.AccountHolderRolePrimary, db.AccountHolderRoleJoint)
+	if err != nil {
+		return nil, err
+	}
+
+	toAccount, err := server.validAccount(ctx, req.GetToAccount(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := server.checkPayeeCoolingOff(ctx, toAccount.ID, req.GetAmount()); err != nil {
+		return nil, err
+	}
+
+	username := authorizationPayload(ctx).Username
+	threshold := server.config.TransferApprovalThreshold
+	if threshold > 0 && req.GetAmount() > threshold {
+		approval, err := server.store.CreateTransferApprovalTx(ctx, db.CreateTransferApprovalParams{
+			Maker:         username,
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        req.GetAmount(),
+			Description:   req.GetDescription(),
+			Reference:     req.GetReference(),
+			Category:      req.GetCategory(),
+			ExpiresAt:     time.Now().Add(server.config.TransferApprovalTTL),
+		})
+		if err != nil {
+			return nil, internalError(err)
+		}
+		server.recordAudit(ctx, username, audit.ActionTransferApprovalRequested, audit.ResourceTransferApproval,
+			strconv.FormatInt(approval.ID, 10), nil, approval)
+		return &pb.CreateTransferResponse{TransferApprovalId: approval.ID}, nil
+	}
+
+	result, err := server.store.TransferTx(ctx, db.TransferTXParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        req.GetAmount(),
+		Description:   req.GetDescription(),
+		Reference:     req.GetReference(),
+		Category:      req.GetCategory(),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		return nil, internalError(err)
+	}
+
+	server.recordAudit(ctx, username, audit.ActionTransferCreated, audit.ResourceTransfer,
+		strconv.FormatInt(result.Transfer.ID, 10), nil, result)
+
+	return &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer, result.FromAccount, result.ToAccount),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry, result.FromAccount),
+		ToEntry:     convertEntry(result.ToEntry, result.ToAccount),
+	}, nil
+}
+
+func validateCreateTransferRequest(req *pb.CreateTransferRequest) error {
+	if err := validateAccountRef(req.GetFromAccount()); err != nil {
+		return invalidArgumentError("from_account", err)
+	}
+	if err := validateAccountRef(req.GetToAccount()); err != nil {
+		return invalidArgumentError("to_account", err)
+	}
+	if req.GetAmount() <= 0 {
+		return invalidArgumentError("amount", fmt.Errorf("must be positive"))
+	}
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		return invalidArgumentError("currency", err)
+	}
+	if err := validatePattern(req.GetDescription(), 140, util.MemoPattern); err != nil {
+		return invalidArgumentError("description", err)
+	}
+	if err := validatePattern(req.GetReference(), 35, util.ReferencePattern); err != nil {
+		return invalidArgumentError("reference", err)
+	}
+	if err := validatePattern(req.GetCategory(), 32, util.CategoryPattern); err != nil {
+		return invalidArgumentError("category", err)
+	}
+	return nil
+}
+
+func (server *Server) validAccount(ctx context.Context, ref string, currency string) (db.Account, error) {
+	account, err := server.accountByRef(ctx, ref)
+	if err != nil {
+		return account, err
+	}
+
+	if account.Currency != currency {
+		return account, status.Errorf(codes.InvalidArgument,
+			"account %s currency mismatch: expected %s, got %s", account.AccountNumber, currency, account.Currency)
+	}
+
+	return account, nil
+}
+
+// checkPayeeCoolingOff enforces policy.CheckPayeeCoolingOff for a transfer
+// by the authenticated user.
+func (server *Server) checkPayeeCoolingOff(ctx context.Context, toAccountID int64, amount int64) error {
+	err := policy.CheckPayeeCoolingOff(ctx, server.store, server.config, authorizationPayload(ctx).Username, toAccountID, amount)
+	if err != nil {
+		if errors.Is(err, policy.ErrPayeeCoolingOff) {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+		return internalError(err)
+	}
+
+	return nil
+}
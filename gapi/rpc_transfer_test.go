@@ -0,0 +1,232 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"simple_bank/audit"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"simple_bank/pb"
+	"simple_bank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID:            util.RandomInt(1, 1000),
+		Owner:         owner,
+		Balance:       util.RandomMoney(),
+		Currency:      util.USD,
+		AccountNumber: util.RandomAccountNumber(),
+	}
+}
+
+func stubAccountHolder(store *mockdb.MockStore, account db.Account, username string, role string) {
+	arg := db.GetAccountHolderParams{AccountID: account.ID, Username: username}
+	store.EXPECT().
+		GetAccountHolder(gomock.Any(), gomock.Eq(arg)).
+		AnyTimes().
+		Return(db.AccountHolder{
+			AccountID: account.ID,
+			Username:  username,
+			Role:      role,
+			Status:    db.AccountHolderStatusAccepted,
+		}, nil)
+}
+
+func TestCreateTransferRPC(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	viewer, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	amount := int64(10)
+
+	testCases := []struct {
+		name          string
+		username      string
+		authorize     bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name:      "OK",
+			username:  user1.Username,
+			authorize: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				stubAccountHolder(store, account1, user1.Username, db.AccountHolderRolePrimary)
+
+				arg := db.TransferTXParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+				}
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.TransferTXResult{
+						Transfer:    db.Transfer{ID: 1, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount},
+						FromAccount: account1,
+						ToAccount:   account2,
+					}, nil)
+				expectAudit(t, store, user1.Username, audit.ActionTransferCreated)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, amount, res.GetTransfer().GetAmount())
+				require.Equal(t, account1.AccountNumber, res.GetFromAccount().GetAccountNumber())
+				require.Equal(t, account1.AccountNumber, res.GetTransfer().GetFromAccountNumber())
+				require.Equal(t, account2.AccountNumber, res.GetTransfer().GetToAccountNumber())
+				require.Equal(t, account2.AccountNumber, res.GetToEntry().GetAccountNumber())
+			},
+		},
+		{
+			name:      "ViewOnlyHolder",
+			username:  viewer.Username,
+			authorize: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				stubAccountHolder(store, account1, viewer.Username, db.AccountHolderRoleViewOnly)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			username:  user1.Username,
+			authorize: false,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name:      "AccountNotFound",
+			username:  user1.Username,
+			authorize: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account1.ID)).
+					Times(1).
+					Return(db.Account{}, db.ErrRecordNotFound)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name:      "InsufficientFunds",
+			username:  user1.Username,
+			authorize: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				stubAccountHolder(store, account1, user1.Username, db.AccountHolderRolePrimary)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TransferTXResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server, client := newTestClient(t, store)
+
+			ctx := context.Background()
+			if tc.authorize {
+				ctx = withAuthorization(t, server, tc.username)
+			}
+
+			res, err := client.CreateTransfer(ctx, &pb.CreateTransferRequest{
+				FromAccount: fmt.Sprint(account1.ID),
+				ToAccount:   fmt.Sprint(account2.ID),
+				Amount:      amount,
+				Currency:    util.USD,
+			})
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestCreateTransferRPCPayeeCoolingOff(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+	stubAccountHolder(store, account1, user1.Username, db.AccountHolderRolePrimary)
+
+	// the destination is given as a raw account, not as the payee
+	arg := db.GetPayeeByAccountParams{Owner: user1.Username, AccountID: account2.ID}
+	store.EXPECT().
+		GetPayeeByAccount(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return(db.Payee{ID: 1, Owner: user1.Username, AccountID: account2.ID, Currency: util.USD, CreatedAt: time.Now()}, nil)
+	store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+
+	server, client := newTestClient(t, store)
+	server.config.PayeeCoolingOffPeriod = 24 * time.Hour
+	server.config.PayeeCoolingOffLimit = 5
+
+	_, err := client.CreateTransfer(withAuthorization(t, server, user1.Username), &pb.CreateTransferRequest{
+		FromAccount: fmt.Sprint(account1.ID),
+		ToAccount:   fmt.Sprint(account2.ID),
+		Amount:      10,
+		Currency:    util.USD,
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestValidateCreateTransferRequestMemoLength(t *testing.T) {
+	// "đ" takes two bytes, so this memo is 140 characters but 280 bytes
+	memo := strings.Repeat("đ", 140)
+
+	req := &pb.CreateTransferRequest{
+		FromAccount: "1",
+		ToAccount:   "2",
+		Amount:      10,
+		Currency:    util.USD,
+		Description: memo,
+	}
+	require.NoError(t, validateCreateTransferRequest(req))
+
+	req.Description = memo + "đ"
+	err := validateCreateTransferRequest(req)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,85 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"simple_bank/audit"
+	db "simple_bank/db/sqlc"
+	"simple_bank/pb"
+	"simple_bank/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	if err := validateUsername(req.GetUsername()); err != nil {
+		return nil, invalidArgumentError("username", err)
+	}
+	if err := validatePassword(req.GetPassword()); err != nil {
+		return nil, invalidArgumentError("password", err)
+	}
+	if err := validateFullName(req.GetFullName()); err != nil {
+		return nil, invalidArgumentError("full_name", err)
+	}
+	if err := validateEmail(req.GetEmail()); err != nil {
+		return nil, invalidArgumentError("email", err)
+	}
+
+	hashedPassword, err := util.HashPassword(req.GetPassword())
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	arg := db.CreateUserParams{
+		Username:       req.GetUsername(),
+		HashedPassword: hashedPassword,
+		FullName:       req.GetFullName(),
+		Email:          req.GetEmail(),
+	}
+
+	user, err := server.store.CreateUser(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrUniqueViolation) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		return nil, internalError(err)
+	}
+
+	return &pb.CreateUserResponse{User: convertUser(user)}, nil
+}
+
+func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	if err := validateUsername(req.GetUsername()); err != nil {
+		return nil, invalidArgumentError("username", err)
+	}
+	if err := validatePassword(req.GetPassword()); err != nil {
+		return nil, invalidArgumentError("password", err)
+	}
+
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.recordAudit(ctx, req.GetUsername(), audit.ActionLoginFailed, audit.ResourceUser, req.GetUsername(), nil, nil)
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, internalError(err)
+	}
+
+	if err := util.CheckPassword(req.GetPassword(), user.HashedPassword); err != nil {
+		server.recordAudit(ctx, user.Username, audit.ActionLoginFailed, audit.ResourceUser, user.Username, nil, nil)
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	accessToken, err := server.tokenMaker.CreateToken(user.Username, server.config.AccessTokenDuration)
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	server.recordAudit(ctx, user.Username, audit.ActionLoggedIn, audit.ResourceUser, user.Username, nil, nil)
+
+	return &pb.LoginUserResponse{
+		User:        convertUser(user),
+		AccessToken: accessToken,
+	}, nil
+}
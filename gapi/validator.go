@@ -0,0 +1,74 @@
+package gapi
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"simple_bank/util"
+	"strconv"
+	"unicode/utf8"
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+func validateUsername(username string) error {
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("must contain only letters and digits")
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < 6 {
+		return fmt.Errorf("must be at least 6 characters")
+	}
+	return nil
+}
+
+func validateFullName(fullName string) error {
+	if fullName == "" {
+		return fmt.Errorf("is required")
+	}
+	return nil
+}
+
+func validateEmail(email string) error {
+	if _, err := mail.ParseAddress(email); err != nil {
+		return fmt.Errorf("is not a valid email address")
+	}
+	return nil
+}
+
+func validateCurrency(currency string) error {
+	if !util.IsSupportedCurrency(currency) {
+		return fmt.Errorf("unsupported currency %q", currency)
+	}
+	return nil
+}
+
+// validateAccountRef accepts either a positive account ID or a valid account
+// number.
+func validateAccountRef(ref string) error {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		if id <= 0 {
+			return fmt.Errorf("must be positive")
+		}
+		return nil
+	}
+	if !util.IsValidAccountNumber(ref) {
+		return fmt.Errorf("is not a valid account id or account number")
+	}
+	return nil
+}
+
+// validatePattern checks a free-text field. Like the HTTP max tags and the
+// varchar columns, maxLength counts characters, not bytes.
+func validatePattern(value string, maxLength int, pattern *regexp.Regexp) error {
+	if utf8.RuneCountInString(value) > maxLength {
+		return fmt.Errorf("must be at most %d characters", maxLength)
+	}
+	if value != "" && !pattern.MatchString(value) {
+		return fmt.Errorf("contains invalid characters")
+	}
+	return nil
+}
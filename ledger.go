@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"simple_bank/ledger"
+	"simple_bank/util"
+)
+
+const ledgerUsage = `usage: main ledger <command>
+
+commands:
+  verify        walk the chain of every account and report the first broken link
+  checkpoint    sign the heads of the chains that grew since their last checkpoint`
+
+// runLedgerCommand runs a ledger subcommand against the configured database,
+// e.g. `main ledger verify`.
+func runLedgerCommand(config util.Config, args []string) error {
+	if len(args) != 1 || (args[0] != "verify" && args[0] != "checkpoint") {
+		return errors.New(ledgerUsage)
+	}
+
+	key := []byte(config.LedgerCheckpointKey)
+	if len(key) == 0 {
+		if args[0] == "checkpoint" {
+			return errors.New("LEDGER_CHECKPOINT_KEY is not set")
+		}
+		slog.Warn("LEDGER_CHECKPOINT_KEY is not set, the checkpoints are not verified")
+	}
+
+	store, closeDB := openStore(config)
+	defer closeDB()
+
+	ctx := context.Background()
+	if args[0] == "checkpoint" {
+		n, err := ledger.Checkpoint(ctx, store, key)
+		if err != nil {
+			return err
+		}
+		slog.Info("ledger checkpointed", "checkpoints", n)
+		return nil
+	}
+
+	report, err := ledger.Verify(ctx, store, key)
+	if err != nil {
+		return err
+	}
+	slog.Info("ledger verified",
+		"entries", report.Entries,
+		"unchained", report.Unchained,
+		"checkpoints", report.Checkpoints,
+	)
+	if report.Broken != nil {
+		return fmt.Errorf("broken ledger chain at %s", report.Broken)
+	}
+	return nil
+}
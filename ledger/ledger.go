@@ -0,0 +1,275 @@
+// Package ledger verifies the hash chains that link the entries of each
+// account, see db.EntryHash, and takes the signed checkpoints that pin them.
+//
+// The chains detect entries and transfers that were changed or removed in the
+// database, but anyone who can write to it could rewrite a chain from the
+// changed entry on. A checkpoint signs the head of the chain of an account
+// with a key the database does not hold, so the history up to it cannot be
+// rewritten without breaking the signature.
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"maps"
+	db "simple_bank/db/sqlc"
+	"slices"
+)
+
+// pageSize is the number of rows read or written at a time.
+const pageSize = 1000
+
+// Sign returns the signature of a checkpoint of the chain of an account at
+// the entry with the given id and hash.
+func Sign(key []byte, accountID int64, entryID int64, hash []byte) []byte {
+	var b []byte
+	b = binary.BigEndian.AppendUint64(b, uint64(accountID))
+	b = binary.BigEndian.AppendUint64(b, uint64(entryID))
+	b = append(b, hash...)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return mac.Sum(nil)
+}
+
+// Checkpoint signs the head of the chain of every account that has grown
+// since its last checkpoint, and returns the number of checkpoints taken.
+func Checkpoint(ctx context.Context, store db.Querier, key []byte) (int, error) {
+	n := 0
+	for {
+		heads, err := store.ListUncheckpointedHeads(ctx, pageSize)
+		if err != nil {
+			return n, err
+		}
+
+		for _, head := range heads {
+			_, err := store.CreateLedgerCheckpoint(ctx, db.CreateLedgerCheckpointParams{
+				AccountID: head.AccountID,
+				EntryID:   head.ID,
+				Hash:      head.Hash,
+				Signature: Sign(key, head.AccountID, head.ID, head.Hash),
+			})
+			if err != nil {
+				return n, err
+			}
+			n++
+		}
+
+		if len(heads) < pageSize {
+			return n, nil
+		}
+	}
+}
+
+// BrokenLink is an entry at which the chain of an account does not verify.
+type BrokenLink struct {
+	AccountID int64
+	EntryID   int64
+	Reason    string
+}
+
+func (link *BrokenLink) String() string {
+	return fmt.Sprintf("account %d, entry %d: %s", link.AccountID, link.EntryID, link.Reason)
+}
+
+// Report is the result of verifying the ledger.
+type Report struct {
+	// Entries is the number of chained entries verified, and Unchained the
+	// number of entries written before the chains began.
+	Entries   int64
+	Unchained int64
+	// Checkpoints is the number of checkpoints whose signature was verified.
+	Checkpoints int64
+	// Broken is the first broken link found, nil if the ledger is intact.
+	Broken *BrokenLink
+}
+
+// Verify walks the chain of every account, in the order of the accounts, and
+// stops at the first broken link. Unless key is empty it also verifies the
+// signatures of the checkpoints, and that the latest checkpoint of every
+// account is on its chain.
+func Verify(ctx context.Context, store db.Querier, key []byte) (Report, error) {
+	var report Report
+
+	var checkpoints map[int64]db.LedgerCheckpoint
+	if len(key) > 0 {
+		var err error
+		checkpoints, err = latestCheckpoints(ctx, store, key, &report)
+		if err != nil || report.Broken != nil {
+			return report, err
+		}
+	}
+
+	var walk *chainWalk
+	arg := db.ListLedgerEntriesParams{Limit: pageSize}
+	for {
+		rows, err := store.ListLedgerEntries(ctx, arg)
+		if err != nil {
+			return report, err
+		}
+
+		for _, row := range rows {
+			if walk == nil || walk.accountID != row.AccountID {
+				if report.Broken = walk.end(); report.Broken != nil {
+					return report, nil
+				}
+				walk = newChainWalk(row.AccountID, checkpoints)
+			}
+
+			if report.Broken = walk.next(row, &report); report.Broken != nil {
+				return report, nil
+			}
+		}
+
+		if len(rows) < pageSize {
+			break
+		}
+		last := rows[len(rows)-1]
+		arg.AfterAccountID, arg.AfterID = last.AccountID, last.ID
+	}
+	if report.Broken = walk.end(); report.Broken != nil {
+		return report, nil
+	}
+
+	// the checkpoints left are of accounts that have no entries any more
+	if len(checkpoints) > 0 {
+		first := slices.Min(slices.Collect(maps.Keys(checkpoints)))
+		report.Broken = missingCheckpointedEntry(checkpoints[first])
+	}
+	return report, nil
+}
+
+// latestCheckpoints verifies the signature of every checkpoint, and returns
+// the latest checkpoint of each account.
+func latestCheckpoints(ctx context.Context, store db.Querier, key []byte, report *Report) (map[int64]db.LedgerCheckpoint, error) {
+	latest := make(map[int64]db.LedgerCheckpoint)
+	arg := db.ListLedgerCheckpointsParams{Limit: pageSize}
+	for {
+		checkpoints, err := store.ListLedgerCheckpoints(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, checkpoint := range checkpoints {
+			signature := Sign(key, checkpoint.AccountID, checkpoint.EntryID, checkpoint.Hash)
+			if !hmac.Equal(signature, checkpoint.Signature) {
+				report.Broken = &BrokenLink{
+					AccountID: checkpoint.AccountID,
+					EntryID:   checkpoint.EntryID,
+					Reason:    fmt.Sprintf("checkpoint %d has an invalid signature", checkpoint.ID),
+				}
+				return nil, nil
+			}
+			report.Checkpoints++
+
+			if checkpoint.EntryID > latest[checkpoint.AccountID].EntryID {
+				latest[checkpoint.AccountID] = checkpoint
+			}
+		}
+
+		if len(checkpoints) < pageSize {
+			return latest, nil
+		}
+		arg.AfterID = checkpoints[len(checkpoints)-1].ID
+	}
+}
+
+// chainWalk is a walk along the chain of one account.
+type chainWalk struct {
+	accountID int64
+	// checkpoint is the latest checkpoint of the account, if any.
+	checkpoint    *db.LedgerCheckpoint
+	checkpointHit bool
+	prevID        int64
+	prevHash      []byte
+}
+
+// newChainWalk starts a walk along the chain of an account, taking its
+// latest checkpoint out of checkpoints.
+func newChainWalk(accountID int64, checkpoints map[int64]db.LedgerCheckpoint) *chainWalk {
+	walk := &chainWalk{accountID: accountID}
+	if checkpoint, ok := checkpoints[accountID]; ok {
+		walk.checkpoint = &checkpoint
+		delete(checkpoints, accountID)
+	}
+	return walk
+}
+
+// next verifies the next entry of the chain.
+func (walk *chainWalk) next(row db.ListLedgerEntriesRow, report *Report) *BrokenLink {
+	broken := func(format string, args ...any) *BrokenLink {
+		return &BrokenLink{AccountID: row.AccountID, EntryID: row.ID, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if row.Hash == nil {
+		// the entries written before the chain began come first
+		if walk.prevHash != nil {
+			return broken("entry is not chained")
+		}
+		report.Unchained++
+		return nil
+	}
+
+	if !bytes.Equal(row.PrevHash, walk.prevHash) {
+		if walk.prevHash == nil {
+			return broken("prev_hash does not match any entry")
+		}
+		return broken("prev_hash does not match the hash of entry %d", walk.prevID)
+	}
+
+	entry := db.Entry{
+		ID:         row.ID,
+		AccountID:  row.AccountID,
+		Amount:     row.Amount,
+		CreatedAt:  row.CreatedAt,
+		TransferID: row.TransferID,
+	}
+	var transfer db.Transfer
+	if row.TransferID.Valid {
+		transfer = db.Transfer{
+			ID:            row.TransferID.Int64,
+			FromAccountID: row.TransferFromAccountID,
+			ToAccountID:   row.TransferToAccountID,
+			Amount:        row.TransferAmount,
+			CreatedAt:     row.TransferCreatedAt,
+			Description:   row.TransferDescription,
+			Reference:     row.TransferReference,
+			Category:      row.TransferCategory,
+		}
+	}
+	if !bytes.Equal(row.Hash, db.EntryHash(row.PrevHash, entry, transfer)) {
+		return broken("hash does not match the entry and its transfer")
+	}
+
+	if walk.checkpoint != nil && walk.checkpoint.EntryID == row.ID {
+		if !bytes.Equal(row.Hash, walk.checkpoint.Hash) {
+			return broken("hash does not match checkpoint %d", walk.checkpoint.ID)
+		}
+		walk.checkpointHit = true
+	}
+
+	walk.prevID, walk.prevHash = row.ID, row.Hash
+	report.Entries++
+	return nil
+}
+
+// end checks that the walk passed the latest checkpoint of the account. It
+// may be called on a nil walk.
+func (walk *chainWalk) end() *BrokenLink {
+	if walk == nil || walk.checkpoint == nil || walk.checkpointHit {
+		return nil
+	}
+	return missingCheckpointedEntry(*walk.checkpoint)
+}
+
+func missingCheckpointedEntry(checkpoint db.LedgerCheckpoint) *BrokenLink {
+	return &BrokenLink{
+		AccountID: checkpoint.AccountID,
+		EntryID:   checkpoint.EntryID,
+		Reason:    fmt.Sprintf("entry of checkpoint %d is missing", checkpoint.ID),
+	}
+}
@@ -0,0 +1,180 @@
+package ledger
+
+import (
+	"context"
+	db "simple_bank/db/sqlc"
+	"simple_bank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testKey = []byte(util.RandomString(32))
+
+// newTestLedger returns a store with two accounts and three transfers
+// between them, and the entries of the first account.
+func newTestLedger(t *testing.T) (db.Store, []db.Entry) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+
+	var accounts []db.Account
+	for i := 0; i < 2; i++ {
+		user, err := store.CreateUser(ctx, db.CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		})
+		require.NoError(t, err)
+
+		account, err := store.CreateAccountTx(ctx, db.CreateAccountParams{
+			Owner:         user.Username,
+			Balance:       100,
+			Currency:      util.EUR,
+			AccountNumber: util.RandomAccountNumber(),
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+
+	for _, amount := range []int64{10, 20, 30} {
+		_, err := store.TransferTx(ctx, db.TransferTXParams{
+			FromAccountID: accounts[0].ID,
+			ToAccountID:   accounts[1].ID,
+			Amount:        amount,
+		})
+		require.NoError(t, err)
+	}
+
+	entries, err := store.ListEntries(ctx, db.ListEntriesParams{AccountID: accounts[0].ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	return store, entries
+}
+
+func TestVerify(t *testing.T) {
+	store, _ := newTestLedger(t)
+
+	report, err := Verify(context.Background(), store, nil)
+	require.NoError(t, err)
+	require.Nil(t, report.Broken)
+	require.Equal(t, int64(6), report.Entries)
+	require.Zero(t, report.Unchained)
+	require.Zero(t, report.Checkpoints)
+}
+
+func TestVerifyTamperedEntry(t *testing.T) {
+	ctx := context.Background()
+	store, entries := newTestLedger(t)
+
+	_, err := store.ChainEntry(ctx, db.ChainEntryParams{
+		ID:       entries[1].ID,
+		PrevHash: entries[1].PrevHash,
+		Hash:     entries[2].Hash,
+	})
+	require.NoError(t, err)
+
+	report, err := Verify(ctx, store, nil)
+	require.NoError(t, err)
+	require.Equal(t, &BrokenLink{
+		AccountID: entries[1].AccountID,
+		EntryID:   entries[1].ID,
+		Reason:    "hash does not match the entry and its transfer",
+	}, report.Broken)
+	require.Equal(t, int64(1), report.Entries)
+}
+
+func TestVerifyRechainedEntry(t *testing.T) {
+	ctx := context.Background()
+	store, entries := newTestLedger(t)
+
+	// the entry hashes correctly, but is no longer linked to its predecessor
+	transfer, err := store.GetTransfer(ctx, entries[1].TransferID.Int64)
+	require.NoError(t, err)
+	_, err = store.ChainEntry(ctx, db.ChainEntryParams{
+		ID:   entries[1].ID,
+		Hash: db.EntryHash(nil, entries[1], transfer),
+	})
+	require.NoError(t, err)
+
+	report, err := Verify(ctx, store, nil)
+	require.NoError(t, err)
+	require.NotNil(t, report.Broken)
+	require.Equal(t, entries[1].ID, report.Broken.EntryID)
+	require.Contains(t, report.Broken.Reason, "prev_hash")
+}
+
+func TestCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	store, entries := newTestLedger(t)
+
+	n, err := Checkpoint(ctx, store, testKey)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	// nothing changed since
+	n, err = Checkpoint(ctx, store, testKey)
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	checkpoints, err := store.ListLedgerCheckpoints(ctx, db.ListLedgerCheckpointsParams{Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 2)
+	require.Equal(t, entries[2].ID, checkpoints[0].EntryID)
+	require.Equal(t, entries[2].Hash, checkpoints[0].Hash)
+	require.Equal(t, Sign(testKey, entries[2].AccountID, entries[2].ID, entries[2].Hash), checkpoints[0].Signature)
+
+	report, err := Verify(ctx, store, testKey)
+	require.NoError(t, err)
+	require.Nil(t, report.Broken)
+	require.Equal(t, int64(2), report.Checkpoints)
+
+	// the signatures do not verify with another key
+	report, err = Verify(ctx, store, []byte(util.RandomString(32)))
+	require.NoError(t, err)
+	require.NotNil(t, report.Broken)
+	require.Contains(t, report.Broken.Reason, "invalid signature")
+}
+
+func TestVerifyCheckpointMismatch(t *testing.T) {
+	ctx := context.Background()
+	store, entries := newTestLedger(t)
+
+	testCases := []struct {
+		name    string
+		entryID int64
+		hash    []byte
+		reason  string
+	}{
+		{
+			name:    "Hash",
+			entryID: entries[1].ID,
+			hash:    entries[2].Hash,
+			reason:  "hash does not match checkpoint",
+		},
+		{
+			name:    "MissingEntry",
+			entryID: entries[2].ID + 1_000,
+			hash:    entries[2].Hash,
+			reason:  "is missing",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := store.CreateLedgerCheckpoint(ctx, db.CreateLedgerCheckpointParams{
+				AccountID: entries[0].AccountID,
+				EntryID:   tc.entryID,
+				Hash:      tc.hash,
+				Signature: Sign(testKey, entries[0].AccountID, tc.entryID, tc.hash),
+			})
+			require.NoError(t, err)
+
+			report, err := Verify(ctx, store, testKey)
+			require.NoError(t, err)
+			require.NotNil(t, report.Broken)
+			require.Equal(t, tc.entryID, report.Broken.EntryID)
+			require.Contains(t, report.Broken.Reason, tc.reason)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package logger configures structured logging and carries the request-scoped
+// logger through context.Context.
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// redacted replaces the value of every attribute that may hold a secret.
+const redacted = "[REDACTED]"
+
+var sensitiveKeys = map[string]bool{
+	"password":        true,
+	"hashed_password": true,
+	"access_token":    true,
+	"refresh_token":   true,
+	"token":           true,
+	"authorization":   true,
+	"secret":          true,
+}
+
+// New creates a JSON logger writing to w at the given level ("debug", "info",
+// "warn" or "error"). Sensitive attributes are redacted.
+func New(w io.Writer, level string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelInfo
+	}
+
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       lvl,
+		ReplaceAttr: redact,
+	}))
+}
+
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
+
+type loggerKey struct{}
+
+// WithContext returns a copy of ctx carrying l.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
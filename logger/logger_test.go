@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "info")
+
+	l.Info("login", "username", "alice", "password", "hunter22", slog.Group("user", "access_token", "v2.local.abc"))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "alice", entry["username"])
+	require.Equal(t, redacted, entry["password"])
+	require.Equal(t, redacted, entry["user"].(map[string]any)["access_token"])
+	require.NotContains(t, buf.String(), "hunter22")
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "warn")
+
+	l.Info("ignored")
+	require.Zero(t, buf.Len())
+
+	l.Warn("kept")
+	require.Contains(t, buf.String(), "kept")
+}
+
+func TestContext(t *testing.T) {
+	require.Equal(t, slog.Default(), FromContext(context.Background()))
+
+	var buf bytes.Buffer
+	l := New(&buf, "info").With("request_id", "abc")
+	FromContext(WithContext(context.Background(), l)).Info("hello")
+	require.Contains(t, buf.String(), `"request_id":"abc"`)
+}
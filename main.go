@@ -2,13 +2,33 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"simple_bank/api"
 	db "simple_bank/db/sqlc"
+	"simple_bank/gapi"
+	"simple_bank/logger"
+	"simple_bank/metrics"
+	"simple_bank/pb"
+	"simple_bank/tracing"
 	"simple_bank/util"
+	"simple_bank/worker"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
@@ -16,23 +36,301 @@ func main() {
 	if err != nil {
 		log.Fatal("cannot load config: ", err)
 	}
-	connPool, err := pgxpool.New(context.Background(), config.DBSource)
 
+	// the standard log package writes through the default logger as well
+	slog.SetDefault(logger.New(os.Stdout, config.LogLevel))
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		// errors go to stderr as plain text since they may be usage help
+		if err := runMigrateCommand(config.DBDriver, config.DBSource, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "ledger" {
+		if err := runLedgerCommand(config, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.MigrateOnStartup {
+		if err := runDBMigration(config.DBDriver, config.DBSource); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config)
+	if err != nil {
+		log.Fatal("cannot set up tracing: ", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	store, closeDB := openStore(config)
+	store = metrics.InstrumentStore(store)
+	prometheus.MustRegister(metrics.NewTxCollector(store))
+
+	// the first SIGINT or SIGTERM, or the failure of any component, cancels
+	// ctx and shuts every other component down
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	waitGroup, ctx := errgroup.WithContext(ctx)
+
+	runWorkers(ctx, waitGroup, config, store)
+	runGrpcServer(ctx, waitGroup, config, store)
+	runGinServer(ctx, waitGroup, config, store)
+	runMetricsServer(ctx, waitGroup, config)
+
+	err = waitGroup.Wait()
+	// the servers have drained, so no request is using the database any more
+	closeDB()
+	if err != nil {
+		log.Fatal("error from wait group: ", err)
+	}
+	slog.Info("shutdown complete")
+}
+
+// openStore opens the store of the configured db driver and returns it with
+// a function that closes it.
+func openStore(config util.Config) (db.Store, func()) {
+	switch config.DBDriver {
+	case util.DBDriverPostgres:
+		return openPostgresStore(config)
+	case util.DBDriverSQLite:
+		return openSQLiteStore(config)
+	default:
+		log.Fatal("unsupported db driver: ", config.DBDriver)
+		return nil, nil
+	}
+}
+
+// openPostgresStore connects to the Postgres database, and its read replica
+// if any, and returns a store backed by them with a function that closes
+// the connections.
+func openPostgresStore(config util.Config) (db.Store, func()) {
+	poolConfig, err := newPoolConfig(config.DBSource, config)
+	if err != nil {
+		log.Fatal("cannot parse db source: ", err)
+	}
+
+	connPool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		log.Fatal("cannot connect to db: ", err)
 	}
+	prometheus.MustRegister(metrics.NewPoolCollector(connPool))
+
+	var storeOptions []db.StoreOption
+	var replicaPool *pgxpool.Pool
+	if config.DBReplicaSource != "" {
+		replicaConfig, err := newPoolConfig(config.DBReplicaSource, config)
+		if err != nil {
+			log.Fatal("cannot parse db replica source: ", err)
+		}
+
+		replicaPool, err = pgxpool.NewWithConfig(context.Background(), replicaConfig)
+		if err != nil {
+			log.Fatal("cannot connect to db replica: ", err)
+		}
+
+		storeOptions = append(storeOptions, db.WithReplica(replicaPool, config.DBReplicaMaxLag))
+	}
+
+	closeDB := func() {
+		connPool.Close()
+		if replicaPool != nil {
+			replicaPool.Close()
+		}
+	}
+	return db.NewStore(connPool, storeOptions...), closeDB
+}
+
+// openSQLiteStore opens the SQLite database file and returns a store backed
+// by it with a function that closes the database. The pool, timeout and
+// replica settings only apply to Postgres.
+func openSQLiteStore(config util.Config) (db.Store, func()) {
+	conn, err := db.OpenSQLite(config.DBSource)
+	if err != nil {
+		log.Fatal("cannot open db: ", err)
+	}
+
+	closeDB := func() {
+		if err := conn.Close(); err != nil {
+			slog.Error("cannot close db", "error", err)
+		}
+	}
+	return db.NewSQLiteStore(conn), closeDB
+}
+
+// newPoolConfig parses dbSource into the configuration of a traced pool
+// tuned by config.
+func newPoolConfig(dbSource string, config util.Config) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(dbSource)
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig.ConnConfig.Tracer = db.QueryTracer{}
+
+	// zero values keep the pgxpool defaults, or the pool_* parameters of
+	// the DSN
+	if config.DBMaxConns > 0 {
+		poolConfig.MaxConns = config.DBMaxConns
+	}
+	if config.DBMinConns > 0 {
+		poolConfig.MinConns = config.DBMinConns
+	}
+	if config.DBMaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.DBMaxConnLifetime
+	}
+	if config.DBHealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = config.DBHealthCheckPeriod
+	}
+	if config.DBStatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(config.DBStatementTimeout.Milliseconds(), 10)
+	}
 
-	store := db.NewStore(connPool)
+	return poolConfig, nil
+}
+
+// runWorkers starts the background workers, which stop once ctx is done.
+func runWorkers(ctx context.Context, waitGroup *errgroup.Group, config util.Config, store db.Store) {
+	expirer := worker.NewPaymentRequestExpirer(store, config.PaymentRequestExpiryInterval)
+	waitGroup.Go(func() error {
+		return expirer.Run(ctx)
+	})
+
+	approvalExpirer := worker.NewTransferApprovalExpirer(store, config.TransferApprovalExpiryInterval)
+	waitGroup.Go(func() error {
+		return approvalExpirer.Run(ctx)
+	})
+
+	dispatcher := worker.NewWebhookDispatcher(store, config)
+	waitGroup.Go(func() error {
+		return dispatcher.Run(ctx)
+	})
+
+	if config.LedgerCheckpointKey != "" {
+		checkpointer := worker.NewLedgerCheckpointer(store, []byte(config.LedgerCheckpointKey), config.LedgerCheckpointInterval)
+		waitGroup.Go(func() error {
+			return checkpointer.Run(ctx)
+		})
+	}
+}
+
+func runGrpcServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config, store db.Store) {
+	server, err := gapi.NewServer(config, store)
+	if err != nil {
+		log.Fatal("cannot create gRPC server: ", err)
+	}
 
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(server.AuthInterceptor))
+	pb.RegisterSimpleBankServer(grpcServer, server)
+	reflection.Register(grpcServer)
+
+	listener, err := net.Listen("tcp", config.GRPCServerAddress)
+	if err != nil {
+		log.Fatal("cannot create gRPC listener: ", err)
+	}
+
+	waitGroup.Go(func() error {
+		slog.Info("start gRPC server", "address", listener.Addr().String())
+		if err := grpcServer.Serve(listener); err != nil {
+			return fmt.Errorf("gRPC server failed: %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		// keep serving while the load balancer reacts to the failing
+		// readiness probe of the HTTP server
+		time.Sleep(config.ShutdownDelay)
+		slog.Info("shutting down gRPC server")
+
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(config.ShutdownTimeout):
+			slog.Warn("gRPC server did not drain in time")
+			grpcServer.Stop()
+		}
+		slog.Info("gRPC server stopped")
+		return nil
+	})
+}
+
+func runGinServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config, store db.Store) {
 	server, err := api.NewServer(config, store)
-	if server == nil {
+	if err != nil {
 		log.Fatal("cannot create server: ", err)
 	}
 
-	err = server.Start(config.ServerAddress)
+	waitGroup.Go(func() error {
+		server.ListenForEvents(ctx)
+		return nil
+	})
 
-	if err != nil {
-		log.Fatal("cannot start server: ", err)
+	waitGroup.Go(func() error {
+		slog.Info("start HTTP server", "address", config.ServerAddress)
+		if err := server.Start(config.ServerAddress); err != nil {
+			return fmt.Errorf("HTTP server failed: %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		slog.Info("shutting down HTTP server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownDelay+config.ShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("cannot shut down HTTP server: %w", err)
+		}
+		slog.Info("HTTP server stopped")
+		return nil
+	})
+}
+
+// runMetricsServer serves the Prometheus metrics on their own address, so
+// that they are not exposed through the public API.
+func runMetricsServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config) {
+	if config.MetricsServerAddress == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+	metricsServer := &http.Server{
+		Addr:              config.MetricsServerAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
 
+	waitGroup.Go(func() error {
+		slog.Info("start metrics server", "address", config.MetricsServerAddress)
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			return fmt.Errorf("metrics server failed: %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("cannot shut down metrics server: %w", err)
+		}
+		slog.Info("metrics server stopped")
+		return nil
+	})
 }
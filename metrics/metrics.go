@@ -0,0 +1,36 @@
+// Package metrics holds the Prometheus collectors that are not tied to a
+// transport: business counters and database pool statistics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "simple_bank"
+
+var (
+	// TransfersTotal counts executed transfers by currency.
+	TransfersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Number of executed transfers.",
+	}, []string{"currency"})
+
+	// TransferVolume sums the amount of executed transfers by currency, in
+	// minor units.
+	TransferVolume = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfer_volume_total",
+		Help:      "Total amount moved by executed transfers, in minor units.",
+	}, []string{"currency"})
+)
+
+// AuditLogFailures counts the audit log entries that could not be written.
+// The actions they describe were committed nonetheless, so any increase
+// needs to be investigated.
+var AuditLogFailures = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "audit_log_failures_total",
+	Help:      "Number of audit log entries that could not be written.",
+})
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	db "simple_bank/db/sqlc"
+)
+
+// Store records business metrics for the transactions of the store it wraps.
+type Store struct {
+	db.Store
+}
+
+// InstrumentStore wraps store so that every committed transfer is counted,
+// whichever API or transaction executed it.
+func InstrumentStore(store db.Store) db.Store {
+	return &Store{Store: store}
+}
+
+func (store *Store) TransferTx(ctx context.Context, arg db.TransferTXParams) (db.TransferTXResult, error) {
+	result, err := store.Store.TransferTx(ctx, arg)
+	if err == nil {
+		observeTransfer(result)
+	}
+	return result, err
+}
+
+func (store *Store) AcceptPaymentRequestTx(ctx context.Context, arg db.AcceptPaymentRequestTxParams) (db.AcceptPaymentRequestTxResult, error) {
+	result, err := store.Store.AcceptPaymentRequestTx(ctx, arg)
+	if err == nil {
+		observeTransfer(result.TransferTXResult)
+	}
+	return result, err
+}
+
+func (store *Store) ApproveTransferTx(ctx context.Context, arg db.DecideTransferTxParams) (db.ApproveTransferTxResult, error) {
+	result, err := store.Store.ApproveTransferTx(ctx, arg)
+	if err == nil {
+		observeTransfer(result.TransferTXResult)
+	}
+	return result, err
+}
+
+func observeTransfer(result db.TransferTXResult) {
+	currency := result.FromAccount.Currency
+	TransfersTotal.WithLabelValues(currency).Inc()
+	TransferVolume.WithLabelValues(currency).Add(float64(result.Transfer.Amount))
+}
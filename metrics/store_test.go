@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"simple_bank/util"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	result := db.TransferTXResult{
+		Transfer:    db.Transfer{Amount: 250},
+		FromAccount: db.Account{Currency: util.EUR},
+	}
+
+	mock := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		mock.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(result, nil),
+		mock.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTXResult{}, errors.New("rolled back")),
+	)
+	mock.EXPECT().
+		ApproveTransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.ApproveTransferTxResult{TransferTXResult: result}, nil)
+
+	transfers := testutil.ToFloat64(TransfersTotal.WithLabelValues(util.EUR))
+	volume := testutil.ToFloat64(TransferVolume.WithLabelValues(util.EUR))
+
+	store := InstrumentStore(mock)
+
+	_, err := store.TransferTx(context.Background(), db.TransferTXParams{})
+	require.NoError(t, err)
+	_, err = store.TransferTx(context.Background(), db.TransferTXParams{})
+	require.Error(t, err)
+	_, err = store.ApproveTransferTx(context.Background(), db.DecideTransferTxParams{})
+	require.NoError(t, err)
+
+	// failed transfers are not counted
+	require.Equal(t, transfers+2, testutil.ToFloat64(TransfersTotal.WithLabelValues(util.EUR)))
+	require.Equal(t, volume+500, testutil.ToFloat64(TransferVolume.WithLabelValues(util.EUR)))
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	db "simple_bank/db/sqlc"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TxCollector exports the retry counts of the transactions of a store.
+type TxCollector struct {
+	store db.Store
+
+	retries   *prometheus.Desc
+	exhausted *prometheus.Desc
+}
+
+// NewTxCollector creates a collector reading the transaction stats of store on
+// every scrape.
+func NewTxCollector(store db.Store) *TxCollector {
+	return &TxCollector{
+		store: store,
+		retries: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db_tx", "retries_total"),
+			"Number of transactions retried, by the error that caused the retry.",
+			[]string{"reason"}, nil,
+		),
+		exhausted: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db_tx", "retries_exhausted_total"),
+			"Number of transactions that still failed after the last retry.",
+			nil, nil,
+		),
+	}
+}
+
+func (collector *TxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.retries
+	ch <- collector.exhausted
+}
+
+func (collector *TxCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := collector.store.TxStats()
+
+	ch <- prometheus.MustNewConstMetric(collector.retries, prometheus.CounterValue, float64(stats.SerializationRetries), "serialization_failure")
+	ch <- prometheus.MustNewConstMetric(collector.retries, prometheus.CounterValue, float64(stats.DeadlockRetries), "deadlock")
+	ch <- prometheus.MustNewConstMetric(collector.exhausted, prometheus.CounterValue, float64(stats.Exhausted))
+}
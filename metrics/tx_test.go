@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxCollector(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().TxStats().AnyTimes().Return(db.TxStats{
+		SerializationRetries: 3,
+		DeadlockRetries:      2,
+		Exhausted:            1,
+	})
+
+	expected := `
+# HELP simple_bank_db_tx_retries_exhausted_total Number of transactions that still failed after the last retry.
+# TYPE simple_bank_db_tx_retries_exhausted_total counter
+simple_bank_db_tx_retries_exhausted_total 1
+# HELP simple_bank_db_tx_retries_total Number of transactions retried, by the error that caused the retry.
+# TYPE simple_bank_db_tx_retries_total counter
+simple_bank_db_tx_retries_total{reason="deadlock"} 2
+simple_bank_db_tx_retries_total{reason="serialization_failure"} 3
+`
+	err := testutil.CollectAndCompare(NewTxCollector(store), strings.NewReader(expected))
+	require.NoError(t, err)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"simple_bank/db/migration"
+	"slices"
+	"strconv"
+)
+
+const migrateUsage = `usage: main migrate <command>
+
+commands:
+  up [N]        apply all pending migrations, or the next N
+  down [N]      roll back the last migration, or the last N
+  goto V        migrate up or down to version V
+  status        print the current and latest version`
+
+// runMigrateCommand runs a migrate subcommand against the configured
+// database, e.g. `main migrate up`.
+func runMigrateCommand(dbDriver string, dbSource string, args []string) error {
+	if len(args) == 0 || !slices.Contains([]string{"up", "down", "goto", "status"}, args[0]) {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := migration.New(dbDriver, dbSource)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		n, err := optionalCount(args[1:], 0)
+		if err != nil {
+			return err
+		}
+		err = migrator.Up(n)
+		if err != nil {
+			return err
+		}
+	case "down":
+		n, err := optionalCount(args[1:], 1)
+		if err != nil {
+			return err
+		}
+		err = migrator.Down(n)
+		if err != nil {
+			return err
+		}
+	case "goto":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		err = migrator.Goto(uint(version))
+		if err != nil {
+			return err
+		}
+	}
+
+	return printMigrationStatus(migrator)
+}
+
+func printMigrationStatus(migrator *migration.Migrator) error {
+	version, dirty, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	latest, err := migration.LatestVersion()
+	if err != nil {
+		return err
+	}
+
+	slog.Info("migration status", "version", version, "latest", latest, "dirty", dirty)
+	return nil
+}
+
+// optionalCount parses the optional step count of up and down.
+func optionalCount(args []string, fallback int) (int, error) {
+	switch len(args) {
+	case 0:
+		return fallback, nil
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid number of migrations %q", args[0])
+		}
+		return n, nil
+	default:
+		return 0, errors.New(migrateUsage)
+	}
+}
+
+// runDBMigration applies all pending migrations before the server starts.
+func runDBMigration(dbDriver string, dbSource string) error {
+	migrator, err := migration.New(dbDriver, dbSource)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if err := migrator.Up(0); err != nil {
+		return fmt.Errorf("cannot migrate db: %w", err)
+	}
+	return printMigrationStatus(migrator)
+}
@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"simple_bank/api"
-	mockdb "simple_bank/db/mock"
 	db "simple_bank/db/sqlc"
 	"simple_bank/util"
-	"time"
+)
 
-	"github.com/golang/mock/gomock"
+// The demo users and accounts match the requests of loadtest/main.go, which
+// transfers USD from account 1 to account 2.
+const (
+	demoPassword = "secret123"
+	demoBalance  = 1_000_000
 )
 
+var demoUsers = []string{"testuser", "otheruser"}
+
 func main() {
 	// Load config
 	config, err := util.LoadConfig("../")
@@ -19,24 +25,24 @@ func main() {
 		log.Fatal("cannot load config:", err)
 	}
 
-	// Create mock controller
-	ctrl := gomock.NewController(nil)
-	defer ctrl.Finish()
-
-	// Create mock store
-	mockStore := mockdb.NewMockStore(ctrl)
-
-	// Setup common mock expectations that will be used for load testing
-	setupMockExpectations(mockStore)
+	// The in-memory store behaves like the database, so every request is
+	// validated and executed as it would be in production
+	store := db.NewMemoryStore()
+	if err := seed(context.Background(), store); err != nil {
+		log.Fatal("cannot seed store:", err)
+	}
 
-	// Create server with mock store
-	server, err := api.NewServer(config, mockStore)
+	server, err := api.NewServer(config, store)
 	if err != nil {
 		log.Fatal("cannot create server:", err)
 	}
+	go server.ListenForEvents(context.Background())
 
 	fmt.Println("Starting mock server for load testing on :8081...")
-	fmt.Println("Mock database configured with sample data")
+	fmt.Println("In-memory database seeded with sample data:")
+	for i, username := range demoUsers {
+		fmt.Printf("  user %s (password %s) holds USD account %d\n", username, demoPassword, i+1)
+	}
 	fmt.Println("Available endpoints:")
 	fmt.Println("  POST /users - Create user")
 	fmt.Println("  POST /users/login - Login user")
@@ -54,107 +60,34 @@ func main() {
 	}
 }
 
-func setupMockExpectations(store *mockdb.MockStore) {
-	// Create a proper hashed password for "secret123"
-	hashedPassword, _ := util.HashPassword("secret123")
-
-	// Mock user creation - always succeeds
-	store.EXPECT().
-		CreateUser(gomock.Any(), gomock.Any()).
-		AnyTimes().
-		Return(db.User{
-			Username:  "testuser",
-			FullName:  "Test User",
-			Email:     "test@example.com",
-			CreatedAt: time.Now(),
-		}, nil)
-
-	// Mock get user by username - always returns a valid user with proper hash
-	store.EXPECT().
-		GetUser(gomock.Any(), gomock.Any()).
-		AnyTimes().
-		Return(db.User{
-			Username:          "testuser",
-			HashedPassword:    hashedPassword,
-			FullName:          "Test User",
-			Email:             "test@example.com",
-			PasswordChangedAt: time.Now().Add(-time.Hour),
-			CreatedAt:         time.Now().Add(-time.Hour),
-		}, nil)
-
-	// Mock account creation - always succeeds
-	store.EXPECT().
-		CreateAccount(gomock.Any(), gomock.Any()).
-		AnyTimes().
-		Return(db.Account{
-			ID:       1,
-			Owner:    "testuser",
-			Balance:  100,
-			Currency: "USD",
-		}, nil)
-
-	// Mock get account - always returns valid account
-	store.EXPECT().
-		GetAccount(gomock.Any(), gomock.Any()).
-		AnyTimes().
-		Return(db.Account{
-			ID:       1,
-			Owner:    "testuser",
-			Balance:  100,
-			Currency: "USD",
-		}, nil)
+// seed creates the demo users, each with a funded USD account.
+func seed(ctx context.Context, store db.Store) error {
+	hashedPassword, err := util.HashPassword(demoPassword)
+	if err != nil {
+		return err
+	}
 
-	// Mock list accounts - returns sample accounts
-	store.EXPECT().
-		ListAccounts(gomock.Any(), gomock.Any()).
-		AnyTimes().
-		Return([]db.Account{
-			{
-				ID:       1,
-				Owner:    "testuser",
-				Balance:  100,
-				Currency: "USD",
-			},
-			{
-				ID:       2,
-				Owner:    "testuser",
-				Balance:  200,
-				Currency: "EUR",
-			},
-		}, nil)
+	for _, username := range demoUsers {
+		_, err := store.CreateUser(ctx, db.CreateUserParams{
+			Username:       username,
+			HashedPassword: hashedPassword,
+			FullName:       username,
+			Email:          username + "@example.com",
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = store.CreateAccountTx(ctx, db.CreateAccountParams{
+			Owner:         username,
+			Balance:       demoBalance,
+			Currency:      util.USD,
+			AccountNumber: util.RandomAccountNumber(),
+		})
+		if err != nil {
+			return err
+		}
+	}
 
-	// Mock transfer transaction - always succeeds
-	store.EXPECT().
-		TransferTx(gomock.Any(), gomock.Any()).
-		AnyTimes().
-		Return(db.TransferTXResult{
-			Transfer: db.Transfer{
-				ID:            1,
-				FromAccountID: 1,
-				ToAccountID:   2,
-				Amount:        10,
-			},
-			FromAccount: db.Account{
-				ID:       1,
-				Owner:    "testuser",
-				Balance:  90,
-				Currency: "USD",
-			},
-			ToAccount: db.Account{
-				ID:       2,
-				Owner:    "testuser",
-				Balance:  210,
-				Currency: "USD",
-			},
-			FromEntry: db.Entry{
-				ID:        1,
-				AccountID: 1,
-				Amount:    -10,
-			},
-			ToEntry: db.Entry{
-				ID:        2,
-				AccountID: 2,
-				Amount:    10,
-			},
-		}, nil)
+	return nil
 }
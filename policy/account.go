@@ -0,0 +1,73 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "simple_bank/db/sqlc"
+	"simple_bank/util"
+	"slices"
+)
+
+const (
+	// accountNumberConstraint is the unique constraint on account numbers.
+	accountNumberConstraint = "account_number_key"
+	// accountNumberAttempts is how many account numbers CreateAccount draws
+	// before giving up.
+	accountNumberAttempts = 3
+)
+
+// newAccountNumber draws the number of a new account. Tests replace it to
+// force collisions.
+var newAccountNumber = util.RandomAccountNumber
+
+var (
+	// ErrNotAccountHolder is returned when the user does not hold the account,
+	// or was invited to it and has not accepted yet.
+	ErrNotAccountHolder = errors.New("account does not belong to the authenticated user")
+	// ErrRoleNotAllowed is returned when the user holds the account in a role
+	// that does not allow the action.
+	ErrRoleNotAllowed = errors.New("holders of the account cannot perform this action")
+)
+
+// AuthorizeAccount checks that the user holds the account in one of the given
+// roles, and returns the holder. Any role is accepted when no roles are given.
+// Invited users do not hold the account until they accept.
+func AuthorizeAccount(ctx context.Context, store db.Querier, accountID int64, username string, roles ...string) (db.AccountHolder, error) {
+	holder, err := store.GetAccountHolder(ctx, db.GetAccountHolderParams{
+		AccountID: accountID,
+		Username:  username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return holder, ErrNotAccountHolder
+		}
+		return holder, err
+	}
+
+	if holder.Status != db.AccountHolderStatusAccepted {
+		return holder, ErrNotAccountHolder
+	}
+	if len(roles) > 0 && !slices.Contains(roles, holder.Role) {
+		return holder, fmt.Errorf("%s %w", holder.Role, ErrRoleNotAllowed)
+	}
+
+	return holder, nil
+}
+
+// CreateAccount creates an account and its primary holder under a random
+// account number, drawing another number when the one drawn is already taken.
+// The AccountNumber of arg is ignored.
+func CreateAccount(ctx context.Context, store db.Store, arg db.CreateAccountParams) (account db.Account, err error) {
+	for range accountNumberAttempts {
+		arg.AccountNumber = newAccountNumber()
+		account, err = store.CreateAccountTx(ctx, arg)
+
+		var dbErr *db.Error
+		if !errors.As(err, &dbErr) || dbErr.Kind != db.ErrUniqueViolation || dbErr.Constraint != accountNumberConstraint {
+			return account, err
+		}
+	}
+
+	return account, err
+}
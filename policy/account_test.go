@@ -0,0 +1,106 @@
+package policy
+
+import (
+	"context"
+	db "simple_bank/db/sqlc"
+	"simple_bank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizeAccount(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	account := newTestAccount(t, store)
+
+	holders := make(map[string]string)
+	for _, status := range []string{db.AccountHolderStatusAccepted, db.AccountHolderStatusPending} {
+		user, err := store.CreateUser(ctx, db.CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: util.RandomString(32),
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		})
+		require.NoError(t, err)
+
+		_, err = store.CreateAccountHolder(ctx, db.CreateAccountHolderParams{
+			AccountID: account.ID,
+			Username:  user.Username,
+			Role:      db.AccountHolderRoleJoint,
+			Status:    status,
+		})
+		require.NoError(t, err)
+		holders[status] = user.Username
+	}
+
+	testCases := []struct {
+		name     string
+		username string
+		roles    []string
+		err      error
+	}{
+		{"Primary", account.Owner, []string{db.AccountHolderRolePrimary}, nil},
+		{"AnyRole", holders[db.AccountHolderStatusAccepted], nil, nil},
+		{"RoleNotAllowed", holders[db.AccountHolderStatusAccepted], []string{db.AccountHolderRolePrimary}, ErrRoleNotAllowed},
+		{"PendingInvitation", holders[db.AccountHolderStatusPending], nil, ErrNotAccountHolder},
+		{"NotAHolder", util.RandomOwner(), nil, ErrNotAccountHolder},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			holder, err := AuthorizeAccount(ctx, store, account.ID, tc.username, tc.roles...)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.username, holder.Username)
+		})
+	}
+}
+
+func TestCreateAccount(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	taken := newTestAccount(t, store)
+	free := util.RandomAccountNumber()
+
+	// the last number keeps being drawn once the others are used up
+	drawNumbers := func(numbers ...string) {
+		t.Cleanup(func() { newAccountNumber = util.RandomAccountNumber })
+		newAccountNumber = func() string {
+			number := numbers[0]
+			if len(numbers) > 1 {
+				numbers = numbers[1:]
+			}
+			return number
+		}
+	}
+
+	arg := db.CreateAccountParams{
+		Owner:    taken.Owner,
+		Currency: util.USD,
+	}
+
+	drawNumbers(taken.AccountNumber, free)
+	account, err := CreateAccount(ctx, store, arg)
+	require.NoError(t, err)
+	require.Equal(t, free, account.AccountNumber)
+
+	drawNumbers(taken.AccountNumber)
+	_, err = CreateAccount(ctx, store, arg)
+	require.ErrorIs(t, err, db.ErrUniqueViolation)
+
+	// other violations are not retried
+	calls := 0
+	t.Cleanup(func() { newAccountNumber = util.RandomAccountNumber })
+	newAccountNumber = func() string {
+		calls++
+		return util.RandomAccountNumber()
+	}
+	arg.Owner = util.RandomOwner()
+	_, err = CreateAccount(ctx, store, arg)
+	require.ErrorIs(t, err, db.ErrForeignKeyViolation)
+	require.Equal(t, 1, calls)
+}
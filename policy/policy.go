@@ -0,0 +1,45 @@
+// Package policy holds the rules that the HTTP and gRPC servers both enforce
+// before acting on a request. Each rule reports a violation with one of the
+// errors below, which the servers map to their own status codes. It also
+// holds the account creation that both servers perform.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "simple_bank/db/sqlc"
+	"simple_bank/util"
+	"time"
+)
+
+// ErrPayeeCoolingOff is returned for a transfer above the cooling-off limit to
+// a payee saved less than the cooling-off period ago.
+var ErrPayeeCoolingOff = errors.New("payee is in its cooling-off period")
+
+// CheckPayeeCoolingOff rejects transfers above the configured limit to an
+// account the user saved as a payee less than the cooling-off period ago.
+func CheckPayeeCoolingOff(ctx context.Context, store db.Querier, config util.Config, username string, toAccountID int64, amount int64) error {
+	if amount <= config.PayeeCoolingOffLimit || config.PayeeCoolingOffPeriod <= 0 {
+		return nil
+	}
+
+	payee, err := store.GetPayeeByAccount(ctx, db.GetPayeeByAccountParams{
+		Owner:     username,
+		AccountID: toAccountID,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	coolingOffEnds := payee.CreatedAt.Add(config.PayeeCoolingOffPeriod)
+	if time.Now().Before(coolingOffEnds) {
+		return fmt.Errorf("%w until %s: transfers to payee %d are limited to %d",
+			ErrPayeeCoolingOff, coolingOffEnds.Format(time.RFC3339), payee.ID, config.PayeeCoolingOffLimit)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package policy
+
+import (
+	"context"
+	db "simple_bank/db/sqlc"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAccount creates a user and an account it is the primary holder of.
+func newTestAccount(t *testing.T, store db.Store) db.Account {
+	ctx := context.Background()
+	user, err := store.CreateUser(ctx, db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: util.RandomString(32),
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	account, err := store.CreateAccountTx(ctx, db.CreateAccountParams{
+		Owner:         user.Username,
+		Balance:       100,
+		Currency:      util.EUR,
+		AccountNumber: util.RandomAccountNumber(),
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestCheckPayeeCoolingOff(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+	account := newTestAccount(t, store)
+	payeeAccount := newTestAccount(t, store)
+	otherAccount := newTestAccount(t, store)
+
+	_, err := store.CreatePayee(ctx, db.CreatePayeeParams{
+		Owner:     account.Owner,
+		Nickname:  "landlord",
+		AccountID: payeeAccount.ID,
+		Currency:  payeeAccount.Currency,
+	})
+	require.NoError(t, err)
+
+	config := util.Config{PayeeCoolingOffLimit: 100, PayeeCoolingOffPeriod: time.Hour}
+
+	testCases := []struct {
+		name        string
+		config      util.Config
+		toAccountID int64
+		amount      int64
+		err         error
+	}{
+		{"AtLimit", config, payeeAccount.ID, 100, nil},
+		{"AboveLimit", config, payeeAccount.ID, 101, ErrPayeeCoolingOff},
+		{"NotAPayee", config, otherAccount.ID, 101, nil},
+		{"Disabled", util.Config{PayeeCoolingOffLimit: 100}, payeeAccount.ID, 101, nil},
+		{"PeriodOver", util.Config{PayeeCoolingOffLimit: 100, PayeeCoolingOffPeriod: time.Nanosecond}, payeeAccount.ID, 101, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckPayeeCoolingOff(ctx, store, tc.config, account.Owner, tc.toAccountID, tc.amount)
+			if tc.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
@@ -1,9 +1,6 @@
 #!/bin/sh
 set -e
 
-echo "Running migrations..."
-source /app/app.env
-/app/migrate -path /app/migration -database "$DB_SOURCE" -verbose up
-
+# the server applies pending migrations itself when MIGRATE_ON_STARTUP is set
 echo "Starting the application..."
-exec "$@"
\ No newline at end of file
+exec "$@"
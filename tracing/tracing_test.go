@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"simple_bank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	testCases := []struct {
+		name     string
+		exporter string
+		wantErr  bool
+	}{
+		{name: "None", exporter: ExporterNone},
+		{name: "Empty", exporter: ""},
+		{name: "Stdout", exporter: ExporterStdout},
+		{name: "OTLP", exporter: ExporterOTLP},
+		{name: "Unsupported", exporter: "zipkin", wantErr: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			config := util.Config{
+				TracingExporter:    tc.exporter,
+				OTLPEndpoint:       "localhost:4317",
+				TracingSampleRatio: 1,
+			}
+
+			shutdown, err := Setup(context.Background(), config)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// the otlp exporter connects lazily, so shutting down must not
+			// wait on a collector that is not running
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_ = shutdown(ctx)
+		})
+	}
+}
+
+func TestSampleRatio(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+
+	provider := NewTracerProvider(exporter, 0)
+	_, span := provider.Tracer("test").Start(context.Background(), "dropped")
+	span.End()
+	require.NoError(t, provider.ForceFlush(context.Background()))
+	require.Empty(t, exporter.GetSpans())
+
+	provider = NewTracerProvider(exporter, 1)
+	_, span = provider.Tracer("test").Start(context.Background(), "recorded")
+	span.End()
+	require.NoError(t, provider.ForceFlush(context.Background()))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "recorded", spans[0].Name)
+	require.Equal(t, ServiceName, serviceName(spans[0]))
+}
+
+func serviceName(span tracetest.SpanStub) string {
+	for _, attr := range span.Resource.Attributes() {
+		if attr.Key == "service.name" {
+			return attr.Value.AsString()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// AccountNumberPrefix plays the role of the IBAN country code.
+	AccountNumberPrefix = "SB"
+	// accountNumberBBANLength is the number of digits after the check digits.
+	accountNumberBBANLength = 12
+	// AccountNumberLength is the length of a normalized account number.
+	AccountNumberLength = len(AccountNumberPrefix) + 2 + accountNumberBBANLength
+)
+
+// RandomAccountNumber generates a new account number made of the prefix,
+// two ISO 7064 mod-97 check digits and a random 12 digit basic account number.
+// The digits come from crypto/rand, so account numbers cannot be predicted
+// from the ones handed out before.
+func RandomAccountNumber() string {
+	var sb strings.Builder
+	for i := 0; i < accountNumberBBANLength; i++ {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			// crypto/rand never fails on the supported platforms
+			panic(err)
+		}
+		sb.WriteByte(byte('0' + digit.Int64()))
+	}
+
+	bban := sb.String()
+	return fmt.Sprintf("%s%02d%s", AccountNumberPrefix, accountNumberCheckDigits(bban), bban)
+}
+
+// NormalizeAccountNumber strips spaces and upper-cases an account number so
+// that "sb12 3456 ..." and "SB123456..." compare equal.
+func NormalizeAccountNumber(accountNumber string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(accountNumber), ""))
+}
+
+// IsValidAccountNumber reports whether accountNumber is well formed and its
+// check digits are correct.
+func IsValidAccountNumber(accountNumber string) bool {
+	accountNumber = NormalizeAccountNumber(accountNumber)
+	if len(accountNumber) != AccountNumberLength || !strings.HasPrefix(accountNumber, AccountNumberPrefix) {
+		return false
+	}
+
+	for _, c := range accountNumber[len(AccountNumberPrefix):] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	// move prefix and check digits to the end, as IBAN validation does
+	rearranged := accountNumber[4:] + accountNumber[:4]
+	return mod97(rearranged) == 1
+}
+
+// accountNumberCheckDigits computes the check digits for a basic account number.
+func accountNumberCheckDigits(bban string) int {
+	return 98 - mod97(bban+AccountNumberPrefix+"00")
+}
+
+// mod97 computes the remainder of the number formed by s divided by 97, where
+// letters count as two digits (A=10 ... Z=35).
+func mod97(s string) int {
+	remainder := 0
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		}
+	}
+	return remainder
+}
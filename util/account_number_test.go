@@ -0,0 +1,42 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomAccountNumber(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		accountNumber := RandomAccountNumber()
+		require.Len(t, accountNumber, AccountNumberLength)
+		require.True(t, IsValidAccountNumber(accountNumber), accountNumber)
+	}
+}
+
+func TestIsValidAccountNumber(t *testing.T) {
+	accountNumber := RandomAccountNumber()
+
+	// formatting is ignored
+	require.True(t, IsValidAccountNumber(" sb"+accountNumber[2:8]+" "+accountNumber[8:]))
+
+	// a single changed digit is detected
+	last := accountNumber[len(accountNumber)-1]
+	changed := accountNumber[:len(accountNumber)-1] + string('0'+(last-'0'+1)%10)
+	require.False(t, IsValidAccountNumber(changed))
+
+	// two swapped adjacent digits are detected
+	digits := []byte(accountNumber)
+	for i := 4; i < len(digits)-1; i++ {
+		if digits[i] != digits[i+1] {
+			digits[i], digits[i+1] = digits[i+1], digits[i]
+			break
+		}
+	}
+	require.False(t, IsValidAccountNumber(string(digits)))
+
+	require.False(t, IsValidAccountNumber(""))
+	require.False(t, IsValidAccountNumber("42"))
+	require.False(t, IsValidAccountNumber("XX"+accountNumber[2:]))
+	require.False(t, IsValidAccountNumber(accountNumber[:6]+"AB"+accountNumber[8:]))
+}
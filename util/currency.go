@@ -9,6 +9,9 @@ const (
 	AUD = "AUD"
 )
 
+// SupportedCurrencies lists every currency an account can be opened in.
+var SupportedCurrencies = []string{USD, EUR, CAD, VND, JPY, AUD}
+
 func IsSupportedCurrency(currency string) bool {
 	switch currency {
 	case USD, EUR, CAD, VND, JPY, AUD:
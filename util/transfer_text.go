@@ -0,0 +1,14 @@
+package util
+
+import "regexp"
+
+// The free-text fields of a transfer are validated against the same patterns
+// by every transport.
+var (
+	// MemoPattern allows letters and digits in any script plus common punctuation.
+	MemoPattern = regexp.MustCompile(`^[\p{L}\p{N} .,:;'"!?()/&#+\-_@%]*$`)
+	// ReferencePattern is the SEPA end-to-end identifier character set.
+	ReferencePattern = regexp.MustCompile(`^[A-Za-z0-9/\-?:().,'+ ]*$`)
+	// CategoryPattern is a lowercase slug such as "rent" or "groceries".
+	CategoryPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
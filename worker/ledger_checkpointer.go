@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	db "simple_bank/db/sqlc"
+	"simple_bank/ledger"
+	"time"
+)
+
+// LedgerCheckpointer periodically signs the heads of the ledger chains that
+// have grown since their last checkpoint.
+type LedgerCheckpointer struct {
+	store    db.Store
+	key      []byte
+	interval time.Duration
+}
+
+// NewLedgerCheckpointer creates a new LedgerCheckpointer that signs the
+// checkpoints with key.
+func NewLedgerCheckpointer(store db.Store, key []byte, interval time.Duration) *LedgerCheckpointer {
+	return &LedgerCheckpointer{
+		store:    store,
+		key:      key,
+		interval: interval,
+	}
+}
+
+// Run takes checkpoints every interval until ctx is cancelled.
+func (checkpointer *LedgerCheckpointer) Run(ctx context.Context) error {
+	return runEvery(ctx, checkpointer.interval, func(ctx context.Context) {
+		if _, err := checkpointer.CheckpointOnce(ctx); err != nil {
+			slog.ErrorContext(ctx, "cannot checkpoint ledger", "error", err)
+		}
+	})
+}
+
+// CheckpointOnce takes a single round of checkpoints and returns how many
+// were taken.
+func (checkpointer *LedgerCheckpointer) CheckpointOnce(ctx context.Context) (int, error) {
+	return ledger.Checkpoint(ctx, checkpointer.store, checkpointer.key)
+}
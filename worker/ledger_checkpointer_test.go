@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"simple_bank/ledger"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerCheckpointOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	key := []byte("checkpoint-key")
+	head := db.ListUncheckpointedHeadsRow{AccountID: 1, ID: 7, Hash: []byte("hash")}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListUncheckpointedHeads(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ListUncheckpointedHeadsRow{head}, nil)
+	store.EXPECT().
+		CreateLedgerCheckpoint(gomock.Any(), gomock.Eq(db.CreateLedgerCheckpointParams{
+			AccountID: head.AccountID,
+			EntryID:   head.ID,
+			Hash:      head.Hash,
+			Signature: ledger.Sign(key, head.AccountID, head.ID, head.Hash),
+		})).
+		Times(1).
+		Return(db.LedgerCheckpoint{}, nil)
+
+	checkpointer := NewLedgerCheckpointer(store, key, time.Minute)
+	n, err := checkpointer.CheckpointOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+func TestLedgerCheckpointerKeepsRunningOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListUncheckpointedHeads(gomock.Any(), gomock.Any()).MinTimes(2).Return(nil, errors.New("db down"))
+
+	checkpointer := NewLedgerCheckpointer(store, []byte("checkpoint-key"), time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, checkpointer.Run(ctx))
+}
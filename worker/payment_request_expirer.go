@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	db "simple_bank/db/sqlc"
+	"time"
+)
+
+// PaymentRequestExpirer periodically marks pending payment requests whose
+// expiry time has passed as expired.
+type PaymentRequestExpirer struct {
+	store    db.Store
+	interval time.Duration
+}
+
+// NewPaymentRequestExpirer creates a new PaymentRequestExpirer.
+func NewPaymentRequestExpirer(store db.Store, interval time.Duration) *PaymentRequestExpirer {
+	return &PaymentRequestExpirer{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Run sweeps expired payment requests every interval until ctx is cancelled.
+func (expirer *PaymentRequestExpirer) Run(ctx context.Context) error {
+	return runEvery(ctx, expirer.interval, func(ctx context.Context) {
+		if _, err := expirer.ExpireOnce(ctx); err != nil {
+			slog.ErrorContext(ctx, "cannot expire payment requests", "error", err)
+		}
+	})
+}
+
+// ExpireOnce runs a single sweep and returns the number of expired requests.
+func (expirer *PaymentRequestExpirer) ExpireOnce(ctx context.Context) (int64, error) {
+	return expirer.store.ExpirePaymentRequests(ctx)
+}
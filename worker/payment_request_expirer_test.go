@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"context"
+	mockdb "simple_bank/db/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpireOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ExpirePaymentRequests(gomock.Any()).Times(1).Return(int64(3), nil)
+
+	expirer := NewPaymentRequestExpirer(store, time.Minute)
+	n, err := expirer.ExpireOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ExpirePaymentRequests(gomock.Any()).MinTimes(1).Return(int64(0), nil)
+
+	expirer := NewPaymentRequestExpirer(store, time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, expirer.Run(ctx))
+}
@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	db "simple_bank/db/sqlc"
+	"time"
+)
+
+// TransferApprovalExpirer periodically expires transfers that were not
+// approved in time and notifies the users who made them.
+type TransferApprovalExpirer struct {
+	store    db.Store
+	interval time.Duration
+}
+
+// NewTransferApprovalExpirer creates a new TransferApprovalExpirer.
+func NewTransferApprovalExpirer(store db.Store, interval time.Duration) *TransferApprovalExpirer {
+	return &TransferApprovalExpirer{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Run sweeps expired transfer approvals every interval until ctx is cancelled.
+func (expirer *TransferApprovalExpirer) Run(ctx context.Context) error {
+	return runEvery(ctx, expirer.interval, func(ctx context.Context) {
+		if _, err := expirer.ExpireOnce(ctx); err != nil {
+			slog.ErrorContext(ctx, "cannot expire transfer approvals", "error", err)
+		}
+	})
+}
+
+// ExpireOnce runs a single sweep and returns the number of expired transfers.
+func (expirer *TransferApprovalExpirer) ExpireOnce(ctx context.Context) (int64, error) {
+	return expirer.store.ExpireTransferApprovalsTx(ctx)
+}
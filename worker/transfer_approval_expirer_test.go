@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	mockdb "simple_bank/db/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferApprovalExpireOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ExpireTransferApprovalsTx(gomock.Any()).Times(1).Return(int64(2), nil)
+
+	expirer := NewTransferApprovalExpirer(store, time.Minute)
+	n, err := expirer.ExpireOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+}
+
+func TestTransferApprovalExpirerKeepsRunningOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ExpireTransferApprovalsTx(gomock.Any()).MinTimes(2).Return(int64(0), errors.New("db down"))
+
+	expirer := NewTransferApprovalExpirer(store, time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, expirer.Run(ctx))
+}
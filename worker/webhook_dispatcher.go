@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	db "simple_bank/db/sqlc"
+	"simple_bank/util"
+	"time"
+)
+
+const (
+	// WebhookSignatureHeader carries the HMAC-SHA256 of the request body,
+	// keyed with the webhook secret, as "sha256=<hex>".
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	// WebhookEventHeader carries the event type, e.g. "transfer.completed".
+	WebhookEventHeader = "X-Webhook-Event"
+
+	webhookBatchSize  = 100
+	webhookMaxBackoff = time.Hour
+)
+
+// WebhookPayload is the body POSTed to every registered webhook.
+type WebhookPayload struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookDispatcher delivers outbox events to the registered webhooks.
+type WebhookDispatcher struct {
+	store       db.Store
+	client      *http.Client
+	interval    time.Duration
+	backoff     time.Duration
+	maxAttempts int32
+}
+
+// NewWebhookDispatcher creates a new WebhookDispatcher.
+func NewWebhookDispatcher(store db.Store, config util.Config) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		store:       store,
+		client:      &http.Client{Timeout: config.WebhookTimeout},
+		interval:    config.WebhookDispatchInterval,
+		backoff:     config.WebhookBackoff,
+		maxAttempts: config.WebhookMaxAttempts,
+	}
+}
+
+// Run dispatches pending deliveries every interval until ctx is cancelled.
+func (dispatcher *WebhookDispatcher) Run(ctx context.Context) error {
+	return runEvery(ctx, dispatcher.interval, func(ctx context.Context) {
+		if err := dispatcher.DispatchOnce(ctx); err != nil {
+			slog.ErrorContext(ctx, "cannot dispatch webhooks", "error", err)
+		}
+	})
+}
+
+// DispatchOnce queues a delivery of every new outbox event to every webhook,
+// then attempts the deliveries that are due.
+func (dispatcher *WebhookDispatcher) DispatchOnce(ctx context.Context) error {
+	if _, err := dispatcher.store.FanOutOutboxEvents(ctx, webhookBatchSize); err != nil {
+		return err
+	}
+
+	// claimed deliveries are leased so that a crashed dispatcher's work is
+	// picked up again once the lease runs out
+	deliveries, err := dispatcher.store.ClaimWebhookDeliveries(ctx, db.ClaimWebhookDeliveriesParams{
+		LeaseUntil: time.Now().Add(2*dispatcher.client.Timeout + time.Minute),
+		BatchSize:  webhookBatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if err := dispatcher.deliver(ctx, delivery); err != nil {
+			slog.ErrorContext(ctx, "cannot record webhook delivery", "delivery_id", delivery.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// deliver sends one delivery and records the outcome.
+func (dispatcher *WebhookDispatcher) deliver(ctx context.Context, delivery db.WebhookDelivery) error {
+	webhook, err := dispatcher.store.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	event, err := dispatcher.store.GetOutboxEvent(ctx, delivery.EventID)
+	if err != nil {
+		return err
+	}
+
+	sendErr := dispatcher.send(ctx, webhook, event)
+	if sendErr == nil {
+		return dispatcher.store.MarkWebhookDelivered(ctx, delivery.ID)
+	}
+
+	attempts := delivery.Attempts + 1
+	status := db.WebhookDeliveryStatusPending
+	if attempts >= dispatcher.maxAttempts {
+		status = db.WebhookDeliveryStatusDead
+	}
+
+	return dispatcher.store.MarkWebhookDeliveryFailed(ctx, db.MarkWebhookDeliveryFailedParams{
+		ID:            delivery.ID,
+		Status:        status,
+		LastError:     sendErr.Error(),
+		NextAttemptAt: time.Now().Add(dispatcher.backoffFor(attempts)),
+	})
+}
+
+func (dispatcher *WebhookDispatcher) send(ctx context.Context, webhook db.Webhook, event db.OutboxEvent) error {
+	body, err := json.Marshal(WebhookPayload{
+		ID:        event.ID,
+		Type:      event.EventType,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, event.EventType)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, body))
+
+	resp, err := dispatcher.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoffFor returns how long to wait before the next attempt after the given
+// number of failed attempts: the base backoff doubled for every attempt.
+func (dispatcher *WebhookDispatcher) backoffFor(attempts int32) time.Duration {
+	backoff := dispatcher.backoff
+	for i := int32(1); i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
+
+// SignWebhookPayload returns the value of the signature header for body.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,140 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simple_bank/db/mock"
+	db "simple_bank/db/sqlc"
+	"simple_bank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDispatcher(store db.Store) *WebhookDispatcher {
+	return NewWebhookDispatcher(store, util.Config{
+		WebhookDispatchInterval: time.Second,
+		WebhookTimeout:          time.Second,
+		WebhookBackoff:          time.Second,
+		WebhookMaxAttempts:      3,
+	})
+}
+
+func TestDispatchOnce(t *testing.T) {
+	secret := util.RandomString(32)
+	event := db.OutboxEvent{
+		ID:          util.RandomInt(1, 1000),
+		EventType:   db.EventTransferCompleted,
+		AggregateID: util.RandomInt(1, 1000),
+		Payload:     []byte(`{"amount":10}`),
+		CreatedAt:   time.Now(),
+	}
+
+	testCases := []struct {
+		name       string
+		statusCode int
+		attempts   int32
+		buildStubs func(store *mockdb.MockStore, delivery db.WebhookDelivery)
+	}{
+		{
+			name:       "Delivered",
+			statusCode: http.StatusOK,
+			buildStubs: func(store *mockdb.MockStore, delivery db.WebhookDelivery) {
+				store.EXPECT().MarkWebhookDelivered(gomock.Any(), gomock.Eq(delivery.ID)).Times(1)
+				store.EXPECT().MarkWebhookDeliveryFailed(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name:       "Retried",
+			statusCode: http.StatusInternalServerError,
+			buildStubs: func(store *mockdb.MockStore, delivery db.WebhookDelivery) {
+				store.EXPECT().MarkWebhookDelivered(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					MarkWebhookDeliveryFailed(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.MarkWebhookDeliveryFailedParams) error {
+						require.Equal(t, delivery.ID, arg.ID)
+						require.Equal(t, db.WebhookDeliveryStatusPending, arg.Status)
+						require.Contains(t, arg.LastError, "500")
+						require.WithinDuration(t, time.Now().Add(time.Second), arg.NextAttemptAt, time.Second)
+						return nil
+					})
+			},
+		},
+		{
+			name:       "DeadLettered",
+			statusCode: http.StatusBadGateway,
+			attempts:   2,
+			buildStubs: func(store *mockdb.MockStore, delivery db.WebhookDelivery) {
+				store.EXPECT().MarkWebhookDelivered(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					MarkWebhookDeliveryFailed(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.MarkWebhookDeliveryFailedParams) error {
+						require.Equal(t, db.WebhookDeliveryStatusDead, arg.Status)
+						return nil
+					})
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.Equal(t, SignWebhookPayload(secret, body), r.Header.Get(WebhookSignatureHeader))
+				require.Equal(t, event.EventType, r.Header.Get(WebhookEventHeader))
+
+				var payload WebhookPayload
+				require.NoError(t, json.Unmarshal(body, &payload))
+				require.Equal(t, event.ID, payload.ID)
+				require.JSONEq(t, string(event.Payload), string(payload.Data))
+
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer server.Close()
+
+			webhook := db.Webhook{ID: util.RandomInt(1, 1000), Url: server.URL, Secret: secret}
+			delivery := db.WebhookDelivery{
+				ID:        util.RandomInt(1, 1000),
+				WebhookID: webhook.ID,
+				EventID:   event.ID,
+				Status:    db.WebhookDeliveryStatusPending,
+				Attempts:  tc.attempts,
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().FanOutOutboxEvents(gomock.Any(), gomock.Any()).Times(1)
+			store.EXPECT().
+				ClaimWebhookDeliveries(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return([]db.WebhookDelivery{delivery}, nil)
+			store.EXPECT().GetWebhook(gomock.Any(), gomock.Eq(webhook.ID)).Times(1).Return(webhook, nil)
+			store.EXPECT().GetOutboxEvent(gomock.Any(), gomock.Eq(event.ID)).Times(1).Return(event, nil)
+			tc.buildStubs(store, delivery)
+
+			err := newTestDispatcher(store).DispatchOnce(context.Background())
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	dispatcher := newTestDispatcher(nil)
+
+	require.Equal(t, time.Second, dispatcher.backoffFor(1))
+	require.Equal(t, 2*time.Second, dispatcher.backoffFor(2))
+	require.Equal(t, 8*time.Second, dispatcher.backoffFor(4))
+	require.Equal(t, webhookMaxBackoff, dispatcher.backoffFor(30))
+}